@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/cloudhut/kminion/v2/kafka"
 	"github.com/cloudhut/kminion/v2/logging"
@@ -11,13 +10,17 @@ import (
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/mitchellh/mapstructure"
 	"go.uber.org/zap"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -47,6 +50,11 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate minion config: %w", err)
 	}
 
+	err = c.Exporter.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate exporter config: %w", err)
+	}
+
 	err = c.Logger.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate logger config: %w", err)
@@ -55,16 +63,28 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-func newConfig(logger *zap.Logger) (Config, error) {
+// newConfig loads the config from the YAML file and environment variables. If strict is false, unknown keys in the
+// YAML config are logged as warnings instead of failing the startup.
+func newConfig(logger *zap.Logger, strict bool) (Config, error) {
 	k := koanf.New(".")
 	var cfg Config
 	cfg.SetDefaults()
 
-	// 1. Check if a config filepath is set via flags. If there is one we'll try to load the file using a YAML Parser
+	// 1. Check if a config filepath is set via flags. If there is one we'll try to load the file using a YAML Parser.
+	// The filepath may also be an http(s) URL, in which case the config is downloaded.
 	envKey := "CONFIG_FILEPATH"
 	configFilepath := os.Getenv(envKey)
 	if configFilepath == "" {
 		logger.Info("the env variable '" + envKey + "' is not set, therefore no YAML config will be loaded")
+	} else if strings.HasPrefix(configFilepath, "http://") || strings.HasPrefix(configFilepath, "https://") {
+		configBytes, err := fetchConfig(configFilepath, os.Getenv("CONFIG_URL_BEARER_TOKEN"))
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to fetch YAML config: %w", err)
+		}
+		err = k.Load(rawbytes.Provider(configBytes), yaml.Parser())
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse YAML config fetched from url: %w", err)
+		}
 	} else {
 		err := k.Load(file.Provider(configFilepath), yaml.Parser())
 		if err != nil {
@@ -75,32 +95,51 @@ func newConfig(logger *zap.Logger) (Config, error) {
 	// We could unmarshal the loaded koanf input after loading both providers, however we want to unmarshal the YAML
 	// config with `ErrorUnused` set to true, but unmarshal environment variables with `ErrorUnused` set to false (default).
 	// Rationale: Orchestrators like Kubernetes inject unrelated environment variables, which we still want to allow.
-	err := k.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{
+	// Apply the profile before unmarshalling, so that all explicitly configured options override the profile's presets
+	profile := k.String("minion.profile")
+	if envProfile, exists := os.LookupEnv("MINION_PROFILE"); exists {
+		profile = envProfile
+	}
+	err := cfg.Minion.ApplyProfile(profile)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to apply profile: %w", err)
+	}
+
+	metadata := &mapstructure.Metadata{}
+	err = k.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{
 		Tag:       "",
 		FlatPaths: false,
 		DecoderConfig: &mapstructure.DecoderConfig{
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				mapstructure.StringToTimeDurationHookFunc()),
-			Metadata:         nil,
+			Metadata:         metadata,
 			Result:           &cfg,
 			WeaklyTypedInput: true,
-			ErrorUnused:      true,
+			ErrorUnused:      strict,
 		},
 	})
 	if err != nil {
 		return Config{}, err
 	}
+	for _, key := range metadata.Unused {
+		logger.Warn("ignoring unknown key in YAML config", zap.String("key", key))
+	}
 
+	// Remember the values of both providers, so that we can report type conflicts between them in a readable way
+	yamlValues := k.All()
+	envValues := make(map[string]interface{})
 	err = k.Load(env.ProviderWithValue("", ".", func(s string, v string) (string, interface{}) {
 		// key := strings.Replace(strings.ToLower(s), "_", ".", -1)
 		key := strings.Replace(strings.ToLower(s), "_", ".", -1)
 		// Check to exist if we have a configuration option already and see if it's a slice
 		// If there is a comma in the value, split the value into a slice by the comma.
 		if strings.Contains(v, ",") {
-			return key, strings.Split(v, ",")
+			envValues[key] = strings.Split(v, ",")
+			return key, envValues[key]
 		}
 
 		// Otherwise return the new key with the unaltered value
+		envValues[key] = v
 		return key, v
 	}), nil)
 	if err != nil {
@@ -109,118 +148,204 @@ func newConfig(logger *zap.Logger) (Config, error) {
 
 	err = k.Unmarshal("", &cfg)
 	if err != nil {
+		conflicts := findProviderTypeConflicts(yamlValues, envValues)
+		if len(conflicts) > 0 {
+			return Config{}, fmt.Errorf("environment variables conflict with the types of the YAML config: %v: %w",
+				strings.Join(conflicts, "; "), err)
+		}
 		return Config{}, err
 	}
 
-	err = cfg.Validate()
-	if err != nil {
-		return Config{}, fmt.Errorf("failed to validate config: %w", err)
+	// The compatibility modes must be applied before validating the config, because they configure TLS and SASL
+	if cfg.Kafka.ConfluentCloud.Enabled && cfg.Kafka.AzureEventHubs.Enabled {
+		return Config{}, fmt.Errorf("confluent cloud and azure event hubs mode must not be enabled at the same time")
 	}
-
-	// VCAP Specifications
-	type Cluster struct {
-		Brokers string
+	if cfg.Kafka.AzureEventHubs.Enabled {
+		err = applyAzureEventHubsCompatibility(&cfg, logger)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to apply azure event hubs compatibility mode: %w", err)
+		}
 	}
-
-	type Urls struct {
-		CaCert      string `json:"ca_cert"`
-		Certs       string `json:"certs"`
-		CertCurrent string `json:"cert_current"`
-		CertNext    string `json:"cert_next"`
-		Token       string `json:"token"`
+	if cfg.Kafka.ConfluentCloud.Enabled {
+		err = applyConfluentCloudDefaults(&cfg, logger)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to apply confluent cloud mode: %w", err)
+		}
 	}
 
-	type Credentials struct {
-		Username string
-		Password string
-		Cluster  Cluster
-		Urls     Urls
+	err = applyVCAPServices(&cfg, logger)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to apply the kafka service bound via %v: %w", kafka.VCAPServicesVariable, err)
 	}
 
-	type Kafka struct {
-		Credentials Credentials
-		Name        string
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to validate config: %w", err)
 	}
 
-	type VCAP struct {
-		Kafka []Kafka
-	}
+	return cfg, nil
+}
 
-	type Token struct {
-		AccessToken string `json:"access_token"`
+// findProviderTypeConflicts compares the flattened YAML values with the values provided via environment variables and
+// describes all keys whose values have incompatible types, e.g. a list in YAML but a scalar in the environment. Keys
+// are compared case-insensitively, because environment variable names are lower cased.
+func findProviderTypeConflicts(yamlValues map[string]interface{}, envValues map[string]interface{}) []string {
+	yamlValuesByKey := make(map[string]interface{}, len(yamlValues))
+	yamlKeys := make(map[string]string, len(yamlValues))
+	for key, value := range yamlValues {
+		yamlValuesByKey[strings.ToLower(key)] = value
+		yamlKeys[strings.ToLower(key)] = key
 	}
 
-	vcap, vcapPresent := os.LookupEnv("VCAP_SERVICES")
-	if vcapPresent {
-		var vcapStruct VCAP
-		err := json.Unmarshal([]byte(vcap), &vcapStruct)
-		if err != nil {
-			return Config{}, fmt.Errorf("Env read Failed: %w", err)
+	conflicts := make([]string, 0)
+	for envKey, envValue := range envValues {
+		yamlValue, exists := yamlValuesByKey[envKey]
+		if !exists {
+			continue
 		}
-		caURL := vcapStruct.Kafka[0].Credentials.Urls.CertCurrent
-		tokenURL := vcapStruct.Kafka[0].Credentials.Urls.Token
-		err1 := DownloadCertificate(caURL, "current.cer")
-		if err1 != nil {
-			return Config{}, fmt.Errorf("CA Certificate download failed: %w", err)
+		yamlKind := configValueKind(yamlValue)
+		envKind := configValueKind(envValue)
+		if yamlKind == envKind || envKind == "string" && isStringConvertible(envValue.(string), yamlKind) {
+			continue
 		}
+		conflicts = append(conflicts, fmt.Sprintf("key '%v' is a %v in YAML (%v) but a %v in the environment (%v)",
+			yamlKeys[envKey], yamlKind, yamlValue, envKind, envValue))
+	}
+	sort.Strings(conflicts)
 
-		cfg.Kafka.Brokers = strings.Split(vcapStruct.Kafka[0].Credentials.Cluster.Brokers, ",")
-		cfg.Kafka.SASL.Enabled = true
-		cfg.Kafka.SASL.Mechanism = "PLAIN"
+	return conflicts
+}
 
-		basicAuthUserName := vcapStruct.Kafka[0].Credentials.Username
-		basicAuthPassword := vcapStruct.Kafka[0].Credentials.Password
-		cfg.Kafka.SASL.Username = basicAuthUserName
-		tokenString, err := getToken(tokenURL, basicAuthUserName, basicAuthPassword)
-		if err != nil {
-			logger.Error("Kafka Auth Error: Token Fetch Failed")
-		}
+// configValueKind returns a readable kind of a value as parsed by the YAML or env provider.
+func configValueKind(value interface{}) string {
+	switch value.(type) {
+	case []interface{}, []string:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	case bool:
+		return "bool"
+	case int, int64, float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
 
-		token := Token{}
-		err2 := json.Unmarshal([]byte(tokenString), &token)
-		if err2 != nil {
-			return Config{}, fmt.Errorf("Token Fetch Failed: %w", err)
+// isStringConvertible returns whether the string can be decoded into a value of the given kind. Environment
+// variables are always strings, so they only conflict if the string can't be converted.
+func isStringConvertible(value string, kind string) bool {
+	switch kind {
+	case "string":
+		return true
+	case "number":
+		_, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			// Durations are numbers in YAML if no unit is given, but can be decoded from strings with units
+			_, err = time.ParseDuration(value)
 		}
-		cfg.Kafka.SASL.Password = token.AccessToken
-
-		cfg.Kafka.TLS.Enabled = true
-		cfg.Kafka.TLS.InsecureSkipTLSVerify = true
-		cfg.Kafka.TLS.CaFilepath = "./current.cer"
-
-		e, err := json.Marshal(cfg.Kafka)
-		logger.Info("Kafka Config:" + string(e))
-
+		return err == nil
+	case "bool":
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case "list":
+		// A single value is decoded as list with one element
+		return true
+	default:
+		return false
 	}
+}
 
-	return cfg, nil
+// explicitSASLSettings returns the SASL options that have been configured explicitly and would be overwritten by a
+// mode that configures SASL on its own.
+func explicitSASLSettings(cfg kafka.SASLConfig) []string {
+	settings := make([]string, 0)
+	if cfg.Username != "" {
+		settings = append(settings, "username")
+	}
+	if cfg.Password != "" {
+		settings = append(settings, "password")
+	}
+	if cfg.Mechanism != kafka.SASLMechanismPlain {
+		settings = append(settings, "mechanism")
+	}
+	if cfg.CredentialProvider != "" {
+		settings = append(settings, "credentialProvider")
+	}
+	if len(cfg.PasswordCommand) > 0 {
+		settings = append(settings, "passwordCommand")
+	}
+	if cfg.DelegationToken.Enabled {
+		settings = append(settings, "delegationToken")
+	}
+	return settings
 }
 
-func getToken(url string, username string, password string) (string, error) {
+// applyAzureEventHubsCompatibility configures the client the way Azure Event Hubs' Kafka endpoint requires it and
+// disables all features that rely on APIs Event Hubs does not support. It returns an error if SASL has been configured
+// explicitly, because those settings would be overwritten silently.
+func applyAzureEventHubsCompatibility(cfg *Config, logger *zap.Logger) error {
+	if settings := explicitSASLSettings(cfg.Kafka.SASL); len(settings) > 0 {
+		return fmt.Errorf("sasl must not be configured together with the azure event hubs compatibility mode, "+
+			"but the following sasl options are set: %v", strings.Join(settings, ", "))
+	}
 
-	method := "POST"
-	payload := strings.NewReader("grant_type=client_credentials")
+	cfg.Kafka.TLS.Enabled = true
+	cfg.Kafka.SASL.Enabled = true
+	cfg.Kafka.SASL.Mechanism = kafka.SASLMechanismPlain
+	cfg.Kafka.SASL.Username = "$ConnectionString"
+	cfg.Kafka.SASL.Password = cfg.Kafka.AzureEventHubs.ConnectionString
+
+	// Event Hubs neither supports describing log dirs nor listing consumer groups via the Kafka protocol, nor does it
+	// expose the __consumer_offsets topic. The offsets of explicitly configured groups can still be fetched.
+	if cfg.Minion.LogDirs.Enabled {
+		logger.Info("azure event hubs compatibility mode is enabled, disabling log dirs because they are not supported")
+		cfg.Minion.LogDirs.Enabled = false
+	}
+	if !cfg.Minion.ConsumerGroups.Enabled {
+		return nil
+	}
+	if _, isExplicit := cfg.Minion.ConsumerGroups.ExplicitGroupIDs(); !isExplicit {
+		logger.Info("azure event hubs compatibility mode is enabled, disabling consumer groups because listing " +
+			"consumer groups is not supported. Configure literal group ids as allowed groups to monitor them")
+		cfg.Minion.ConsumerGroups.Enabled = false
+		return nil
+	}
+	logger.Info("azure event hubs compatibility mode is enabled, disabling listing consumer groups because it's not " +
+		"supported. Only the explicitly allowed groups are monitored")
+	cfg.Minion.ConsumerGroups.ListGroups = false
+	if cfg.Minion.ConsumerGroups.ScrapeMode == minion.ConsumerGroupScrapeModeOffsetsTopic {
+		logger.Info("azure event hubs compatibility mode is enabled, scraping consumer group offsets via the admin " +
+			"api because the __consumer_offsets topic is not exposed")
+		cfg.Minion.ConsumerGroups.ScrapeMode = minion.ConsumerGroupScrapeModeAdminAPI
+	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, url, payload)
+	return nil
+}
 
+// fetchConfig downloads the config from the given URL. If a bearer token is given it's sent in the Authorization
+// header.
+func fetchConfig(url string, bearerToken string) ([]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
 	}
-	req.SetBasicAuth(username, password)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	res, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return "", err
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("config server responded with status code %v", res.StatusCode)
 	}
 
-	return string(body), err
+	return ioutil.ReadAll(res.Body)
 }
 
 func DownloadCertificate(url string, filename string) error {
@@ -243,3 +368,69 @@ func DownloadCertificate(url string, filename string) error {
 	_, err = io.Copy(out, resp.Body)
 	return err
 }
+
+// applyVCAPServices connects to the Kafka service that is bound via VCAP_SERVICES, if the variable is set. The CA
+// certificate of the service is downloaded and the SASL credentials are provided by the vcap credential provider,
+// which requests a new access token from the service for each broker connection.
+func applyVCAPServices(cfg *Config, logger *zap.Logger) error {
+	service, exists, err := kafka.LookupVCAPKafkaService()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	err = DownloadCertificate(service.CACertURL, "current.cer")
+	if err != nil {
+		return fmt.Errorf("CA Certificate download failed: %w", err)
+	}
+
+	cfg.Kafka.Brokers = service.Brokers
+	cfg.Kafka.SASL.Enabled = true
+	cfg.Kafka.SASL.Mechanism = kafka.SASLMechanismPlain
+	cfg.Kafka.SASL.CredentialProvider = kafka.SASLCredentialProviderVCAP
+	cfg.Kafka.SASL.Username = ""
+	cfg.Kafka.SASL.Password = ""
+
+	cfg.Kafka.TLS.Enabled = true
+	cfg.Kafka.TLS.InsecureSkipTLSVerify = true
+	cfg.Kafka.TLS.CaFilepath = "./current.cer"
+	logger.Info("using the Kafka service bound via VCAP_SERVICES")
+
+	return nil
+}
+
+// applyConfluentCloudDefaults configures the client the way Confluent Cloud requires it. TLS uses the system's root
+// CAs unless a CA has been configured explicitly. It returns an error if SASL has been configured explicitly, because
+// those settings would be overwritten silently.
+func applyConfluentCloudDefaults(cfg *Config, logger *zap.Logger) error {
+	if settings := explicitSASLSettings(cfg.Kafka.SASL); len(settings) > 0 {
+		return fmt.Errorf("sasl must not be configured together with the confluent cloud mode, but the following "+
+			"sasl options are set: %v", strings.Join(settings, ", "))
+	}
+
+	cfg.Kafka.TLS.Enabled = true
+	cfg.Kafka.SASL.Enabled = true
+	cfg.Kafka.SASL.Mechanism = kafka.SASLMechanismPlain
+	cfg.Kafka.SASL.Username = cfg.Kafka.ConfluentCloud.APIKey
+	cfg.Kafka.SASL.Password = cfg.Kafka.ConfluentCloud.APISecret
+	cfg.Kafka.ApplyConfluentCloudTimeouts()
+
+	// Confluent Cloud does not allow describing log dirs, nor does it expose the __consumer_offsets topic
+	if cfg.Minion.LogDirs.Enabled {
+		logger.Info("confluent cloud mode is enabled, disabling log dirs because they are not supported")
+		cfg.Minion.LogDirs.Enabled = false
+	}
+	if cfg.Minion.ConsumerGroups.IncludeLagBytes {
+		logger.Info("confluent cloud mode is enabled, disabling consumer group lags in bytes because they require log dirs")
+		cfg.Minion.ConsumerGroups.IncludeLagBytes = false
+	}
+	if cfg.Minion.ConsumerGroups.ScrapeMode == minion.ConsumerGroupScrapeModeOffsetsTopic {
+		logger.Info("confluent cloud mode is enabled, using the admin api to scrape consumer group offsets because " +
+			"the __consumer_offsets topic can't be consumed")
+		cfg.Minion.ConsumerGroups.ScrapeMode = minion.ConsumerGroupScrapeModeAdminAPI
+	}
+
+	return nil
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/kafka"
+	"github.com/cloudhut/kminion/v2/minion"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindProviderTypeConflicts(t *testing.T) {
+	tt := []struct {
+		name      string
+		yaml      map[string]interface{}
+		env       map[string]interface{}
+		conflicts []string
+	}{
+		{
+			name: "keys only in one provider",
+			yaml: map[string]interface{}{"kafka.clientId": "kminion"},
+			env:  map[string]interface{}{"kafka.rackid": "europe-west1-b"},
+		},
+		{
+			name: "string to int",
+			yaml: map[string]interface{}{"exporter.port": 8080},
+			env:  map[string]interface{}{"exporter.port": "9308"},
+		},
+		{
+			name: "string to float",
+			yaml: map[string]interface{}{"kafka.adminRequestRateLimit": 2.5},
+			env:  map[string]interface{}{"kafka.adminrequestratelimit": "10"},
+		},
+		{
+			name: "string to duration",
+			yaml: map[string]interface{}{"exporter.collectorTimeout": 30},
+			env:  map[string]interface{}{"exporter.collectortimeout": "45s"},
+		},
+		{
+			name: "string to bool",
+			yaml: map[string]interface{}{"minion.consumerGroups.emitZeroLag": true},
+			env:  map[string]interface{}{"minion.consumergroups.emitzerolag": "false"},
+		},
+		{
+			name:      "string not convertible to int",
+			yaml:      map[string]interface{}{"exporter.port": 8080},
+			env:       map[string]interface{}{"exporter.port": "http"},
+			conflicts: []string{"key 'exporter.port' is a number in YAML (8080) but a string in the environment (http)"},
+		},
+		{
+			name:      "string not convertible to bool",
+			yaml:      map[string]interface{}{"minion.consumerGroups.emitZeroLag": true},
+			env:       map[string]interface{}{"minion.consumergroups.emitzerolag": "sometimes"},
+			conflicts: []string{"key 'minion.consumerGroups.emitZeroLag' is a bool in YAML (true) but a string in the environment (sometimes)"},
+		},
+		{
+			name: "single value into list",
+			yaml: map[string]interface{}{"kafka.brokers": []interface{}{"broker-1:9092"}},
+			env:  map[string]interface{}{"kafka.brokers": "broker-2:9092"},
+		},
+		{
+			name: "comma separated list into list",
+			yaml: map[string]interface{}{"kafka.brokers": []interface{}{"broker-1:9092"}},
+			env:  map[string]interface{}{"kafka.brokers": []string{"broker-2:9092", "broker-3:9092"}},
+		},
+		{
+			name:      "list into string",
+			yaml:      map[string]interface{}{"kafka.clientId": "kminion"},
+			env:       map[string]interface{}{"kafka.clientid": []string{"a", "b"}},
+			conflicts: []string{"key 'kafka.clientId' is a string in YAML (kminion) but a list in the environment ([a b])"},
+		},
+		{
+			name:      "string into nested map",
+			yaml:      map[string]interface{}{"exporter.push.groupingKey": map[string]interface{}{"cluster": "batch-1"}},
+			env:       map[string]interface{}{"exporter.push.groupingkey": "batch-2"},
+			conflicts: []string{"key 'exporter.push.groupingKey' is a map in YAML (map[cluster:batch-1]) but a string in the environment (batch-2)"},
+		},
+		{
+			name: "env overrides a nested key of a map",
+			yaml: map[string]interface{}{"exporter.push.groupingKey.cluster": "batch-1"},
+			env:  map[string]interface{}{"exporter.push.groupingkey.cluster": "batch-2"},
+		},
+		{
+			name: "env conflicts are sorted",
+			yaml: map[string]interface{}{"exporter.port": 8080, "minion.consumerGroups.emitZeroLag": true},
+			env:  map[string]interface{}{"minion.consumergroups.emitzerolag": "x", "exporter.port": "y"},
+			conflicts: []string{
+				"key 'exporter.port' is a number in YAML (8080) but a string in the environment (y)",
+				"key 'minion.consumerGroups.emitZeroLag' is a bool in YAML (true) but a string in the environment (x)",
+			},
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			conflicts := findProviderTypeConflicts(test.yaml, test.env)
+			if test.conflicts == nil {
+				test.conflicts = []string{}
+			}
+			if !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Fatalf("expected conflicts %q, got %q", test.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestConfigValueKind(t *testing.T) {
+	tt := []struct {
+		value interface{}
+		kind  string
+	}{
+		{"kminion", "string"},
+		{8080, "number"},
+		{int64(8080), "number"},
+		{2.5, "number"},
+		{true, "bool"},
+		{[]interface{}{"a"}, "list"},
+		{[]string{"a", "b"}, "list"},
+		{map[string]interface{}{"a": map[string]interface{}{"b": 1}}, "map"},
+	}
+
+	for _, test := range tt {
+		if kind := configValueKind(test.value); kind != test.kind {
+			t.Errorf("expected kind of %#v to be %v, got %v", test.value, test.kind, kind)
+		}
+	}
+}
+
+func TestIsStringConvertible(t *testing.T) {
+	tt := []struct {
+		value       string
+		kind        string
+		convertible bool
+	}{
+		{"anything", "string", true},
+		{"42", "number", true},
+		{"-1.5", "number", true},
+		{"1m30s", "number", true},
+		{"forty-two", "number", false},
+		{"true", "bool", true},
+		{"0", "bool", true},
+		{"yes", "bool", false},
+		{"broker-1:9092", "list", true},
+		{"value", "map", false},
+	}
+
+	for _, test := range tt {
+		if convertible := isStringConvertible(test.value, test.kind); convertible != test.convertible {
+			t.Errorf("expected '%v' convertible to %v to be %v", test.value, test.kind, test.convertible)
+		}
+	}
+}
+
+func TestApplyAzureEventHubsCompatibility(t *testing.T) {
+	tt := []struct {
+		name               string
+		allowedGroups      []string
+		scrapeMode         string
+		groupsEnabled      bool
+		expectedScrapeMode string
+	}{
+		{
+			name:               "explicit groups are fetched without listing them",
+			allowedGroups:      []string{"orders-consumer", "bigquery-sink"},
+			scrapeMode:         minion.ConsumerGroupScrapeModeOffsetsTopic,
+			groupsEnabled:      true,
+			expectedScrapeMode: minion.ConsumerGroupScrapeModeAdminAPI,
+		},
+		{
+			name:               "regex groups require listing",
+			allowedGroups:      []string{"/.*/"},
+			scrapeMode:         minion.ConsumerGroupScrapeModeAdminAPI,
+			groupsEnabled:      false,
+			expectedScrapeMode: minion.ConsumerGroupScrapeModeAdminAPI,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Config{}
+			cfg.SetDefaults()
+			cfg.Kafka.Brokers = []string{"kminion.servicebus.windows.net:9093"}
+			cfg.Kafka.AzureEventHubs.Enabled = true
+			cfg.Kafka.AzureEventHubs.ConnectionString = "Endpoint=sb://kminion.servicebus.windows.net/"
+			cfg.Minion.ConsumerGroups.AllowedGroupIDs = test.allowedGroups
+			cfg.Minion.ConsumerGroups.ScrapeMode = test.scrapeMode
+
+			if err := applyAzureEventHubsCompatibility(&cfg, zap.NewNop()); err != nil {
+				t.Fatalf("failed to apply azure event hubs compatibility: %v", err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("expected a valid config, got: %v", err)
+			}
+
+			sasl := cfg.Kafka.SASL
+			if !cfg.Kafka.TLS.Enabled || !sasl.Enabled || sasl.Mechanism != kafka.SASLMechanismPlain {
+				t.Errorf("expected TLS and SASL PLAIN to be enabled")
+			}
+			if sasl.Username != "$ConnectionString" || sasl.Password != cfg.Kafka.AzureEventHubs.ConnectionString {
+				t.Errorf("expected the connection string to be used as SASL credentials, got user '%v'", sasl.Username)
+			}
+			if cfg.Minion.LogDirs.Enabled {
+				t.Errorf("expected log dirs to be disabled")
+			}
+			groups := cfg.Minion.ConsumerGroups
+			if groups.Enabled != test.groupsEnabled {
+				t.Errorf("expected consumer groups enabled to be %v, got %v", test.groupsEnabled, groups.Enabled)
+			}
+			if groups.Enabled && groups.ListGroups {
+				t.Errorf("expected listing consumer groups to be disabled")
+			}
+			if groups.ScrapeMode != test.expectedScrapeMode {
+				t.Errorf("expected scrape mode %v, got %v", test.expectedScrapeMode, groups.ScrapeMode)
+			}
+		})
+	}
+}
+
+func TestNewConfigAppliesProfile(t *testing.T) {
+	tt := []struct {
+		name            string
+		yaml            string
+		logDirsEnabled  bool
+		topicsEnabled   bool
+		includeAge      bool
+		groupsEnabled   bool
+		allowedTopics   []string
+		uncommittedLags bool
+	}{
+		{
+			name:           "lag-only defaults",
+			yaml:           "minion:\n  profile: lag-only\n",
+			logDirsEnabled: false,
+			topicsEnabled:  false,
+			includeAge:     false,
+			groupsEnabled:  true,
+			allowedTopics:  []string{"/.*/"},
+		},
+		{
+			name:           "lag-only with user overrides",
+			yaml:           "minion:\n  profile: lag-only\n  logDirs:\n    enabled: true\n  topics:\n    allowedTopics: [\"orders\"]\n",
+			logDirsEnabled: true,
+			topicsEnabled:  false,
+			includeAge:     false,
+			groupsEnabled:  true,
+			allowedTopics:  []string{"orders"},
+		},
+		{
+			name:           "topics with user overrides",
+			yaml:           "minion:\n  profile: topics\n  topics:\n    includeAge: false\n",
+			logDirsEnabled: true,
+			topicsEnabled:  true,
+			includeAge:     false,
+			groupsEnabled:  false,
+			allowedTopics:  []string{"/.*/"},
+		},
+		{
+			name:            "full defaults",
+			yaml:            "minion:\n  profile: full\n",
+			logDirsEnabled:  true,
+			topicsEnabled:   true,
+			includeAge:      true,
+			groupsEnabled:   true,
+			allowedTopics:   []string{"/.*/"},
+			uncommittedLags: true,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			configFile, err := ioutil.TempFile("", "kminion-config-*.yaml")
+			if err != nil {
+				t.Fatalf("failed to create config file: %v", err)
+			}
+			defer os.Remove(configFile.Name())
+			yaml := "kafka:\n  brokers: [\"localhost:9092\"]\n" + test.yaml
+			if _, err := configFile.WriteString(yaml); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			configFile.Close()
+			os.Setenv("CONFIG_FILEPATH", configFile.Name())
+			defer os.Unsetenv("CONFIG_FILEPATH")
+
+			cfg, err := newConfig(zap.NewNop(), true)
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			minionCfg := cfg.Minion
+			if minionCfg.LogDirs.Enabled != test.logDirsEnabled {
+				t.Errorf("expected log dirs enabled to be %v, got %v", test.logDirsEnabled, minionCfg.LogDirs.Enabled)
+			}
+			if minionCfg.Topics.Enabled != test.topicsEnabled {
+				t.Errorf("expected topics enabled to be %v, got %v", test.topicsEnabled, minionCfg.Topics.Enabled)
+			}
+			if minionCfg.Topics.IncludeAge != test.includeAge {
+				t.Errorf("expected include age to be %v, got %v", test.includeAge, minionCfg.Topics.IncludeAge)
+			}
+			if minionCfg.ConsumerGroups.Enabled != test.groupsEnabled {
+				t.Errorf("expected consumer groups enabled to be %v, got %v", test.groupsEnabled, minionCfg.ConsumerGroups.Enabled)
+			}
+			if !reflect.DeepEqual(minionCfg.Topics.AllowedTopics, test.allowedTopics) {
+				t.Errorf("expected allowed topics %v, got %v", test.allowedTopics, minionCfg.Topics.AllowedTopics)
+			}
+			if minionCfg.ConsumerGroups.IncludeUncommittedPartitions != test.uncommittedLags {
+				t.Errorf("expected uncommitted partition lags to be %v, got %v", test.uncommittedLags,
+					minionCfg.ConsumerGroups.IncludeUncommittedPartitions)
+			}
+		})
+	}
+}
+
+func TestApplyConfluentCloudDefaults(t *testing.T) {
+	newConfluentCloudConfig := func() Config {
+		cfg := Config{}
+		cfg.SetDefaults()
+		cfg.Kafka.Brokers = []string{"pkc-4r087.europe-west1.gcp.confluent.cloud:9092"}
+		cfg.Kafka.ConfluentCloud.Enabled = true
+		cfg.Kafka.ConfluentCloud.APIKey = "api-key"
+		cfg.Kafka.ConfluentCloud.APISecret = "api-secret"
+		cfg.Minion.ConsumerGroups.ScrapeMode = minion.ConsumerGroupScrapeModeOffsetsTopic
+		return cfg
+	}
+
+	cfg := newConfluentCloudConfig()
+	if err := applyConfluentCloudDefaults(&cfg, zap.NewNop()); err != nil {
+		t.Fatalf("failed to apply confluent cloud defaults: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid config, got: %v", err)
+	}
+	sasl := cfg.Kafka.SASL
+	if !cfg.Kafka.TLS.Enabled || !sasl.Enabled || sasl.Mechanism != kafka.SASLMechanismPlain {
+		t.Errorf("expected TLS and SASL PLAIN to be enabled")
+	}
+	if sasl.Username != "api-key" || sasl.Password != "api-secret" {
+		t.Errorf("expected the api key to be used as SASL credentials, got user '%v'", sasl.Username)
+	}
+	if cfg.Kafka.DialTimeout != 30*time.Second || cfg.Kafka.RequestTimeoutOverhead != 30*time.Second {
+		t.Errorf("expected dial and request timeouts of 30s, got %v and %v", cfg.Kafka.DialTimeout,
+			cfg.Kafka.RequestTimeoutOverhead)
+	}
+	if cfg.Kafka.MetadataMaxAge != time.Minute {
+		t.Errorf("expected a metadata max age of 1m, got %v", cfg.Kafka.MetadataMaxAge)
+	}
+	if cfg.Minion.LogDirs.Enabled || cfg.Minion.ConsumerGroups.ScrapeMode != minion.ConsumerGroupScrapeModeAdminAPI {
+		t.Errorf("expected log dirs to be disabled and offsets to be scraped via the admin api")
+	}
+
+	// Timeouts that have been changed by the user are kept
+	cfg = newConfluentCloudConfig()
+	cfg.Kafka.DialTimeout = 5 * time.Second
+	cfg.Kafka.MetadataMaxAge = 10 * time.Minute
+	if err := applyConfluentCloudDefaults(&cfg, zap.NewNop()); err != nil {
+		t.Fatalf("failed to apply confluent cloud defaults: %v", err)
+	}
+	if cfg.Kafka.DialTimeout != 5*time.Second || cfg.Kafka.MetadataMaxAge != 10*time.Minute {
+		t.Errorf("expected the configured timeouts to be kept, got %v and %v", cfg.Kafka.DialTimeout,
+			cfg.Kafka.MetadataMaxAge)
+	}
+	if cfg.Kafka.RequestTimeoutOverhead != 30*time.Second {
+		t.Errorf("expected a request timeout overhead of 30s, got %v", cfg.Kafka.RequestTimeoutOverhead)
+	}
+
+	// Explicit SASL settings conflict with the api key
+	cfg = newConfluentCloudConfig()
+	cfg.Kafka.SASL.Username = "user"
+	if err := applyConfluentCloudDefaults(&cfg, zap.NewNop()); err == nil {
+		t.Errorf("expected explicit SASL settings to be rejected")
+	}
+}
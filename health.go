@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// readiness tracks whether KMinion has successfully connected to Kafka and is ready to serve metrics
+type readiness struct {
+	ready int32
+
+	// isWarmedUp reports whether the warmup scrapes have been completed. It's set before ready is stored.
+	isWarmedUp func() bool
+}
+
+func (r *readiness) setReady(isWarmedUp func() bool) {
+	r.isWarmedUp = isWarmedUp
+	atomic.StoreInt32(&r.ready, 1)
+}
+
+// handleHealthy always responds with 200, as long as the process is able to serve HTTP requests
+func (r *readiness) handleHealthy(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleReady responds with 503 until the connection to Kafka has been established and the warmup scrapes have been
+// completed
+func (r *readiness) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if atomic.LoadInt32(&r.ready) == 0 || !r.isWarmedUp() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("not ready"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ready"))
+}
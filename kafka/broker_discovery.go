@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// srvResolver is implemented by net.Resolver
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// brokerDiscovery resolves the seed brokers from a DNS SRV record. The Kafka client can't change its seed brokers
+// once created, therefore the dialer redirects connections to seed brokers that are no longer part of the SRV
+// record to the currently resolved targets.
+type brokerDiscovery struct {
+	cfg      BrokerDiscoveryConfig
+	logger   *zap.Logger
+	resolver srvResolver
+
+	mutex sync.RWMutex
+	// seeds are the brokers that have been resolved at startup and which the Kafka client has been created with
+	seeds map[string]struct{}
+	// current are the brokers that have been resolved most recently
+	current []string
+	// nextCurrent is the index of the current broker which the next redirected seed broker is dialed at. It must be
+	// accessed atomically, so that dials only need to hold the read lock.
+	nextCurrent *uint32
+}
+
+func newBrokerDiscovery(cfg BrokerDiscoveryConfig, logger *zap.Logger, resolver srvResolver) *brokerDiscovery {
+	return &brokerDiscovery{
+		cfg:         cfg,
+		logger:      logger.With(zap.String("srv_record", cfg.SRVRecord)),
+		resolver:    resolver,
+		nextCurrent: new(uint32),
+	}
+}
+
+// resolve looks up the SRV record and returns the targets as sorted list of host:port addresses
+func (d *brokerDiscovery) resolve(ctx context.Context) ([]string, error) {
+	// Passing an empty service and proto resolves the name as is
+	_, records, err := d.resolver.LookupSRV(ctx, "", "", d.cfg.SRVRecord)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup srv record: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("srv record '%v' has no targets", d.cfg.SRVRecord)
+	}
+
+	brokers := make([]string, len(records))
+	for i, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		brokers[i] = net.JoinHostPort(host, strconv.Itoa(int(record.Port)))
+	}
+	sort.Strings(brokers)
+
+	return brokers, nil
+}
+
+// resolveSeeds resolves the SRV record the first time and returns the seed brokers for the Kafka client
+func (d *brokerDiscovery) resolveSeeds(ctx context.Context) ([]string, error) {
+	brokers, err := d.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.seeds = make(map[string]struct{}, len(brokers))
+	for _, broker := range brokers {
+		d.seeds[broker] = struct{}{}
+	}
+	d.current = brokers
+	d.logger.Info("resolved seed brokers from srv record", zap.Strings("brokers", brokers))
+
+	return brokers, nil
+}
+
+// refresh resolves the SRV record on each refresh interval until the context is done
+func (d *brokerDiscovery) refresh(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resolveCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			d.refreshOnce(resolveCtx)
+			cancel()
+		}
+	}
+}
+
+// refreshOnce resolves the SRV record and stores the targets as current brokers. If the record can't be resolved,
+// the previously resolved brokers are kept.
+func (d *brokerDiscovery) refreshOnce(ctx context.Context) {
+	brokers, err := d.resolve(ctx)
+	if err != nil {
+		d.logger.Warn("failed to refresh seed brokers, keeping the previously resolved brokers", zap.Error(err))
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if strings.Join(brokers, ",") != strings.Join(d.current, ",") {
+		d.logger.Info("resolved seed brokers have changed", zap.Strings("brokers", brokers))
+	}
+	d.current = brokers
+}
+
+// address returns the address that shall be dialed for the given address. Seed brokers that are no longer part of
+// the SRV record are replaced by one of the current targets, all other addresses are returned as is.
+func (d *brokerDiscovery) address(addr string) string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if _, isSeed := d.seeds[addr]; !isSeed || len(d.current) == 0 {
+		return addr
+	}
+	for _, broker := range d.current {
+		if broker == addr {
+			return addr
+		}
+	}
+
+	next := atomic.AddUint32(d.nextCurrent, 1)
+	return d.current[int(next%uint32(len(d.current)))]
+}
+
+// wrapDialer returns a dial function which dials the address as returned by address
+func (d *brokerDiscovery) wrapDialer(dialFn func(ctx context.Context, network, host string) (net.Conn, error)) func(ctx context.Context, network, host string) (net.Conn, error) {
+	return func(ctx context.Context, network, host string) (net.Conn, error) {
+		return dialFn(ctx, network, d.address(host))
+	}
+}
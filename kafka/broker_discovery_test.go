@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockResolver returns the configured SRV records for every lookup
+type mockResolver struct {
+	mutex   sync.Mutex
+	records []*net.SRV
+	err     error
+	lookups []string
+}
+
+func (r *mockResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lookups = append(r.lookups, service+"|"+proto+"|"+name)
+	return name, r.records, r.err
+}
+
+func (r *mockResolver) set(records []*net.SRV, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.records = records
+	r.err = err
+}
+
+func TestBrokerDiscovery(t *testing.T) {
+	resolver := &mockResolver{}
+	resolver.set([]*net.SRV{
+		{Target: "broker-2.kafka.svc.", Port: 9092},
+		{Target: "broker-1.kafka.svc.", Port: 9092},
+	}, nil)
+	cfg := BrokerDiscoveryConfig{SRVRecord: "_kafka._tcp.kafka.svc", RefreshInterval: time.Minute}
+	discovery := newBrokerDiscovery(cfg, zap.NewNop(), resolver)
+
+	seeds, err := discovery.resolveSeeds(context.Background())
+	if err != nil {
+		t.Fatalf("failed to resolve seeds: %v", err)
+	}
+	expected := []string{"broker-1.kafka.svc:9092", "broker-2.kafka.svc:9092"}
+	if !reflect.DeepEqual(seeds, expected) {
+		t.Fatalf("expected seeds %v, got %v", expected, seeds)
+	}
+	// The record name must be resolved as is
+	if !reflect.DeepEqual(resolver.lookups, []string{"||_kafka._tcp.kafka.svc"}) {
+		t.Errorf("expected the srv record to be looked up by its name, got %v", resolver.lookups)
+	}
+	for _, seed := range seeds {
+		if addr := discovery.address(seed); addr != seed {
+			t.Errorf("expected seed %v to be dialed as is, got %v", seed, addr)
+		}
+	}
+
+	// broker-1 has been replaced by broker-3, so that the client's seed must be redirected
+	resolver.set([]*net.SRV{
+		{Target: "broker-2.kafka.svc.", Port: 9092},
+		{Target: "broker-3.kafka.svc.", Port: 9092},
+	}, nil)
+	discovery.refreshOnce(context.Background())
+	redirected := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		redirected[discovery.address("broker-1.kafka.svc:9092")]++
+	}
+	expectedRedirects := map[string]int{"broker-2.kafka.svc:9092": 2, "broker-3.kafka.svc:9092": 2}
+	if !reflect.DeepEqual(redirected, expectedRedirects) {
+		t.Errorf("expected the removed seed to be redirected to the current brokers in turn, got %v", redirected)
+	}
+	if addr := discovery.address("broker-2.kafka.svc:9092"); addr != "broker-2.kafka.svc:9092" {
+		t.Errorf("expected a seed that is still resolved to be dialed as is, got %v", addr)
+	}
+	// Brokers discovered via the cluster metadata are never redirected
+	if addr := discovery.address("broker-7.kafka.svc:9092"); addr != "broker-7.kafka.svc:9092" {
+		t.Errorf("expected a non seed broker to be dialed as is, got %v", addr)
+	}
+
+	// Failed lookups and empty records keep the previously resolved brokers
+	resolver.set(nil, errors.New("no such host"))
+	discovery.refreshOnce(context.Background())
+	resolver.set([]*net.SRV{}, nil)
+	discovery.refreshOnce(context.Background())
+	if addr := discovery.address("broker-1.kafka.svc:9092"); addr == "broker-1.kafka.svc:9092" {
+		t.Errorf("expected the previously resolved brokers to be kept, got %v", addr)
+	}
+}
+
+func TestBrokerDiscoveryConcurrentDials(t *testing.T) {
+	resolver := &mockResolver{}
+	resolver.set([]*net.SRV{{Target: "broker-1.kafka.svc.", Port: 9092}}, nil)
+	cfg := BrokerDiscoveryConfig{SRVRecord: "_kafka._tcp.kafka.svc", RefreshInterval: time.Minute}
+	discovery := newBrokerDiscovery(cfg, zap.NewNop(), resolver)
+	if _, err := discovery.resolveSeeds(context.Background()); err != nil {
+		t.Fatalf("failed to resolve seeds: %v", err)
+	}
+	resolver.set([]*net.SRV{{Target: "broker-2.kafka.svc.", Port: 9092}, {Target: "broker-3.kafka.svc.", Port: 9092}}, nil)
+	discovery.refreshOnce(context.Background())
+
+	// Dials only hold the read lock, run with -race to detect unsynchronized access
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if addr := discovery.address("broker-1.kafka.svc:9092"); addr == "broker-1.kafka.svc:9092" {
+					t.Errorf("expected the removed seed to be redirected")
+					return
+				}
+			}
+		}()
+	}
+	discovery.refreshOnce(context.Background())
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
@@ -16,7 +17,7 @@ import (
 	"go.uber.org/zap"
 	"io/ioutil"
 	"net"
-	"time"
+	"path/filepath"
 
 	krbconfig "github.com/jcmturner/gokrb5/v8/config"
 )
@@ -30,6 +31,9 @@ func NewKgoConfig(cfg Config, logger *zap.Logger, hooks kgo.Hook) ([]kgo.Opt, er
 		kgo.ClientID(cfg.ClientID),
 		kgo.FetchMaxBytes(5 * 1000 * 1000), // 5MB
 		kgo.AllowedConcurrentFetches(10),
+		kgo.MetadataMinAge(cfg.MetadataMinAge),
+		kgo.MetadataMaxAge(cfg.MetadataMaxAge),
+		kgo.ConnTimeoutOverhead(cfg.RequestTimeoutOverhead),
 	}
 
 	// Create Logger
@@ -48,27 +52,37 @@ func NewKgoConfig(cfg Config, logger *zap.Logger, hooks kgo.Hook) ([]kgo.Opt, er
 
 	// Configure SASL
 	if cfg.SASL.Enabled {
+		// The credentials for PLAIN and SCRAM are asked for whenever a broker connection is authenticated
+		provider := cfg.saslCredentialProvider
+		if provider == nil {
+			provider = newCredentialProvider(cfg.SASL, logger)
+		}
+
 		// SASL Plain
 		if cfg.SASL.Mechanism == "PLAIN" {
-			mechanism := plain.Auth{
-				User: cfg.SASL.Username,
-				Pass: cfg.SASL.Password,
-			}.AsMechanism()
+			mechanism := plain.Plain(func(ctx context.Context) (plain.Auth, error) {
+				user, pass, err := provider.FetchSASLCredentials(ctx)
+				return plain.Auth{User: user, Pass: pass}, err
+			})
 			opts = append(opts, kgo.SASL(mechanism))
 		}
 
 		// SASL SCRAM
 		if cfg.SASL.Mechanism == "SCRAM-SHA-256" || cfg.SASL.Mechanism == "SCRAM-SHA-512" {
 			var mechanism sasl.Mechanism
-			scramAuth := scram.Auth{
-				User: cfg.SASL.Username,
-				Pass: cfg.SASL.Password,
+			authFn := func(ctx context.Context) (scram.Auth, error) {
+				user, pass, err := provider.FetchSASLCredentials(ctx)
+				return scram.Auth{
+					User:    user,
+					Pass:    pass,
+					IsToken: cfg.SASL.DelegationToken.Enabled,
+				}, err
 			}
 			if cfg.SASL.Mechanism == "SCRAM-SHA-256" {
-				mechanism = scramAuth.AsSha256Mechanism()
+				mechanism = scram.Sha256(authFn)
 			}
 			if cfg.SASL.Mechanism == "SCRAM-SHA-512" {
-				mechanism = scramAuth.AsSha512Mechanism()
+				mechanism = scram.Sha512(authFn)
 			}
 			opts = append(opts, kgo.SASL(mechanism))
 		}
@@ -109,6 +123,7 @@ func NewKgoConfig(cfg Config, logger *zap.Logger, hooks kgo.Hook) ([]kgo.Opt, er
 
 	// Configure TLS
 	var caCertPool *x509.CertPool
+	dialFn := (&net.Dialer{Timeout: cfg.DialTimeout}).DialContext
 	if cfg.TLS.Enabled {
 		// Root CA
 		if cfg.TLS.CaFilepath != "" {
@@ -123,6 +138,17 @@ func NewKgoConfig(cfg Config, logger *zap.Logger, hooks kgo.Hook) ([]kgo.Opt, er
 			}
 		}
 
+		// Root CAs from a directory - all files are expected to be PEM encoded CA certificates
+		if cfg.TLS.CaDirpath != "" {
+			if caCertPool == nil {
+				caCertPool = x509.NewCertPool()
+			}
+			err := appendCaDirToCertPool(caCertPool, cfg.TLS.CaDirpath, logger)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// If configured load TLS cert & key - Mutual TLS
 		var certificates []tls.Certificate
 		if cfg.TLS.CertFilepath != "" && cfg.TLS.KeyFilepath != "" {
@@ -159,15 +185,54 @@ func NewKgoConfig(cfg Config, logger *zap.Logger, hooks kgo.Hook) ([]kgo.Opt, er
 		}
 
 		tlsDialer := &tls.Dialer{
-			NetDialer: &net.Dialer{Timeout: 10 * time.Second},
+			NetDialer: &net.Dialer{Timeout: cfg.DialTimeout},
 			Config: &tls.Config{
 				InsecureSkipVerify: cfg.TLS.InsecureSkipTLSVerify,
 				Certificates:       certificates,
 				RootCAs:            caCertPool,
 			},
 		}
-		opts = append(opts, kgo.Dialer(tlsDialer.DialContext))
+		dialFn = tlsDialer.DialContext
+	}
+
+	if cfg.brokerDiscovery != nil {
+		dialFn = cfg.brokerDiscovery.wrapDialer(dialFn)
 	}
+	opts = append(opts, kgo.Dialer(dialFn))
 
 	return opts, nil
 }
+
+// appendCaDirToCertPool appends all PEM encoded certificates in the given directory to the cert pool. An error is
+// returned if not a single certificate could be loaded from the directory.
+func appendCaDirToCertPool(pool *x509.CertPool, dirpath string, logger *zap.Logger) error {
+	files, err := ioutil.ReadDir(dirpath)
+	if err != nil {
+		return fmt.Errorf("failed to read ca directory: %w", err)
+	}
+
+	loadedFiles := 0
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		caFilepath := filepath.Join(dirpath, file.Name())
+		ca, err := ioutil.ReadFile(caFilepath)
+		if err != nil {
+			return fmt.Errorf("failed to read ca file '%v': %w", caFilepath, err)
+		}
+		isSuccessful := pool.AppendCertsFromPEM(ca)
+		if !isSuccessful {
+			logger.Warn("failed to append ca file to cert pool, is this a valid PEM format?",
+				zap.String("filepath", caFilepath))
+			continue
+		}
+		loadedFiles++
+	}
+
+	if loadedFiles == 0 {
+		return fmt.Errorf("no valid PEM encoded ca certificate found in directory '%v'", dirpath)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCA creates a self-signed CA certificate with the given common name
+func newTestCA(t *testing.T, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func writePEM(t *testing.T, path string, cert *x509.Certificate) {
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("failed to write ca file: %v", err)
+	}
+}
+
+func TestAppendCaDirToCertPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kminion-ca")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldCA := newTestCA(t, "old issuing ca")
+	newCA := newTestCA(t, "new issuing ca")
+	writePEM(t, filepath.Join(dir, "old.pem"), oldCA)
+	writePEM(t, filepath.Join(dir, "new.pem"), newCA)
+	// Files that are not PEM encoded and sub directories are skipped
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "archive"), 0700); err != nil {
+		t.Fatalf("failed to create sub directory: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if err := appendCaDirToCertPool(pool, dir, zap.NewNop()); err != nil {
+		t.Fatalf("expected ca directory to be loaded, got error: %v", err)
+	}
+	for _, ca := range []*x509.Certificate{oldCA, newCA} {
+		if _, err := ca.Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+			t.Errorf("expected '%v' to be in the cert pool: %v", ca.Subject.CommonName, err)
+		}
+	}
+}
+
+func TestAppendCaDirToCertPoolWithoutCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kminion-ca")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		dirpath string
+	}{
+		{"empty directory", dir},
+		{"missing directory", filepath.Join(dir, "missing")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := appendCaDirToCertPool(x509.NewCertPool(), test.dirpath, zap.NewNop()); err == nil {
+				t.Errorf("expected an error for a directory without certificates")
+			}
+		})
+	}
+}
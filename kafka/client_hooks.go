@@ -1,11 +1,15 @@
 package kafka
 
 import (
+	"crypto/tls"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"go.uber.org/zap"
 	"net"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,29 +23,59 @@ type clientHooks struct {
 
 	requestsReceivedCount prometheus.Counter
 	bytesReceived         prometheus.Counter
+
+	connectionErrors *prometheus.CounterVec
+	tlsCertExpiry    *prometheus.GaugeVec
+
+	brokerRequests *prometheus.CounterVec
+
+	// successfulConnects is the number of successfully established broker connections. It must be accessed atomically.
+	successfulConnects *uint64
 }
 
-func newClientHooks(logger *zap.Logger, metricsNamespace string) *clientHooks {
-	requestSentCount := promauto.NewCounter(prometheus.CounterOpts{
+func newClientHooks(logger *zap.Logger, metricsNamespace string, registerer prometheus.Registerer) *clientHooks {
+	factory := promauto.With(registerer)
+	requestSentCount := factory.NewCounter(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "kafka",
 		Name:      "requests_sent_total"})
-	bytesSent := promauto.NewCounter(prometheus.CounterOpts{
+	bytesSent := factory.NewCounter(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "kafka",
 		Name:      "sent_bytes",
 	})
 
-	requestsReceivedCount := promauto.NewCounter(prometheus.CounterOpts{
+	requestsReceivedCount := factory.NewCounter(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "kafka",
 		Name:      "requests_received_total"})
-	bytesReceived := promauto.NewCounter(prometheus.CounterOpts{
+	bytesReceived := factory.NewCounter(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "kafka",
 		Name:      "received_bytes",
 	})
 
+	connectionErrors := factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "broker_connection_errors_total",
+		Help:      "The number of failed connection attempts and failed reads or writes on broker connections",
+	}, []string{"broker_id", "address"})
+
+	tlsCertExpiry := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "broker_tls_cert_expiry_seconds",
+		Help:      "The expiry date of the broker's TLS certificate as unix timestamp in seconds, as presented on the most recent connection",
+	}, []string{"broker_id"})
+
+	brokerRequests := factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "broker_requests_total",
+		Help:      "The number of requests kminion's client has sent to a broker, by Kafka API",
+	}, []string{"broker_id", "api"})
+
 	return &clientHooks{
 		logger: logger,
 
@@ -50,17 +84,58 @@ func newClientHooks(logger *zap.Logger, metricsNamespace string) *clientHooks {
 
 		requestsReceivedCount: requestsReceivedCount,
 		bytesReceived:         bytesReceived,
+
+		connectionErrors: connectionErrors,
+		tlsCertExpiry:    tlsCertExpiry,
+
+		brokerRequests:     brokerRequests,
+		successfulConnects: new(uint64),
+	}
+}
+
+// brokerIDLabel returns the broker_id label value of the given broker. Seed brokers, which are used until the cluster
+// metadata has been fetched, have negative broker ids assigned by the client. They are all reported as "seed", so
+// that the series don't depend on the client's internal numbering.
+func brokerIDLabel(meta kgo.BrokerMetadata) string {
+	if meta.NodeID < 0 {
+		return "seed"
 	}
+	return strconv.Itoa(int(meta.NodeID))
+}
+
+// countConnectionError increments the connection errors of the given broker
+func (c clientHooks) countConnectionError(meta kgo.BrokerMetadata) {
+	address := net.JoinHostPort(meta.Host, strconv.Itoa(int(meta.Port)))
+	c.connectionErrors.WithLabelValues(brokerIDLabel(meta), address).Inc()
 }
 
-func (c clientHooks) OnConnect(meta kgo.BrokerMetadata, dialDur time.Duration, _ net.Conn, err error) {
+func (c clientHooks) OnConnect(meta kgo.BrokerMetadata, dialDur time.Duration, conn net.Conn, err error) {
 	if err != nil {
 		c.logger.Debug("kafka connection failed", zap.String("broker_host", meta.Host), zap.Error(err))
+		c.countConnectionError(meta)
 		return
 	}
+	atomic.AddUint64(c.successfulConnects, 1)
 	c.logger.Debug("kafka connection succeeded",
 		zap.String("host", meta.Host),
 		zap.Duration("dial_duration", dialDur))
+	c.observeTLSCertExpiry(meta, conn)
+}
+
+// observeTLSCertExpiry reports the expiry of the broker's leaf certificate if the connection uses TLS. The TLS
+// handshake has already been completed by the dialer at this point. Seed brokers are skipped because their broker id
+// is not known yet.
+func (c clientHooks) observeTLSCertExpiry(meta kgo.BrokerMetadata, conn net.Conn) {
+	tlsConn, isTLS := conn.(*tls.Conn)
+	if !isTLS || meta.NodeID < 0 {
+		return
+	}
+
+	peerCertificates := tlsConn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return
+	}
+	c.tlsCertExpiry.WithLabelValues(strconv.Itoa(int(meta.NodeID))).Set(float64(peerCertificates[0].NotAfter.Unix()))
 }
 
 func (c clientHooks) OnDisconnect(meta kgo.BrokerMetadata, _ net.Conn) {
@@ -75,7 +150,10 @@ func (c clientHooks) OnDisconnect(meta kgo.BrokerMetadata, _ net.Conn) {
 //
 // The bytes written does not count any tls overhead.
 // OnRead is called after a read from a broker.
-func (c clientHooks) OnRead(_ kgo.BrokerMetadata, _ int16, bytesRead int, _, _ time.Duration, _ error) {
+func (c clientHooks) OnRead(meta kgo.BrokerMetadata, _ int16, bytesRead int, _, _ time.Duration, err error) {
+	if err != nil {
+		c.countConnectionError(meta)
+	}
 	c.requestsReceivedCount.Inc()
 	c.bytesReceived.Add(float64(bytesRead))
 }
@@ -87,7 +165,11 @@ func (c clientHooks) OnRead(_ kgo.BrokerMetadata, _ int16, bytesRead int, _, _ t
 //
 // The bytes written does not count any tls overhead.
 // OnWrite is called after a write to a broker.
-func (c clientHooks) OnWrite(_ kgo.BrokerMetadata, _ int16, bytesWritten int, _, _ time.Duration, _ error) {
+func (c clientHooks) OnWrite(meta kgo.BrokerMetadata, key int16, bytesWritten int, _, _ time.Duration, err error) {
+	if err != nil {
+		c.countConnectionError(meta)
+	}
+	c.brokerRequests.WithLabelValues(brokerIDLabel(meta), kmsg.NameForKey(key)).Inc()
 	c.requestSentCount.Inc()
 	c.bytesSent.Add(float64(bytesWritten))
 }
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientHooksCountConnectionErrors(t *testing.T) {
+	hooks := newClientHooks(zap.NewNop(), "kminion", prometheus.NewRegistry())
+	errConnection := errors.New("connection reset by peer")
+	flakyBroker := kgo.BrokerMetadata{NodeID: 1, Host: "broker-1", Port: 9092}
+	healthyBroker := kgo.BrokerMetadata{NodeID: 2, Host: "broker-2", Port: 9092}
+
+	hooks.OnConnect(flakyBroker, 0, nil, errConnection)
+	hooks.OnWrite(flakyBroker, 0, 0, 0, 0, errConnection)
+	hooks.OnRead(flakyBroker, 0, 0, 0, 0, errConnection)
+	hooks.OnWrite(healthyBroker, 0, 100, 0, 0, nil)
+	hooks.OnRead(healthyBroker, 0, 100, 0, 0, nil)
+
+	tests := []struct {
+		brokerID string
+		address  string
+		errors   float64
+	}{
+		{"1", "broker-1:9092", 3},
+		{"2", "broker-2:9092", 0},
+	}
+	for _, test := range tests {
+		if count := testutil.ToFloat64(hooks.connectionErrors.WithLabelValues(test.brokerID, test.address)); count != test.errors {
+			t.Errorf("expected %v connection errors for broker %v, got %v", test.errors, test.brokerID, count)
+		}
+	}
+}
+
+// newTestServerCertificate creates a self-signed server certificate that expires at the given time
+func newTestServerCertificate(t *testing.T, notAfter time.Time) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "broker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientHooksObserveTLSCertExpiry(t *testing.T) {
+	hooks := newClientHooks(zap.NewNop(), "kminion", prometheus.NewRegistry())
+	notAfter := time.Unix(1900000000, 0)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{newTestServerCertificate(t, notAfter)},
+	})
+	if err != nil {
+		t.Fatalf("failed to start tls server: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	dial := func() net.Conn {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("failed to connect to tls server: %v", err)
+		}
+		return conn
+	}
+
+	// Seed brokers and plaintext connections are not reported
+	seedConn := dial()
+	defer seedConn.Close()
+	hooks.OnConnect(kgo.BrokerMetadata{NodeID: -1, Host: "127.0.0.1"}, 0, seedConn, nil)
+	plaintextConn, _ := net.Pipe()
+	defer plaintextConn.Close()
+	hooks.OnConnect(kgo.BrokerMetadata{NodeID: 4, Host: "127.0.0.1"}, 0, plaintextConn, nil)
+
+	conn := dial()
+	defer conn.Close()
+	hooks.OnConnect(kgo.BrokerMetadata{NodeID: 3, Host: "127.0.0.1"}, 0, conn, nil)
+
+	if expiry := testutil.ToFloat64(hooks.tlsCertExpiry.WithLabelValues("3")); expiry != float64(notAfter.Unix()) {
+		t.Errorf("expected cert expiry of %v, got %v", notAfter.Unix(), expiry)
+	}
+	if series := testutil.CollectAndCount(hooks.tlsCertExpiry); series != 1 {
+		t.Errorf("expected only the cert expiry of broker 3 to be reported, got %d series", series)
+	}
+}
+
+func TestClientHooksCountBrokerRequests(t *testing.T) {
+	hooks := newClientHooks(zap.NewNop(), "kminion", prometheus.NewRegistry())
+	broker1 := kgo.BrokerMetadata{NodeID: 1, Host: "broker-1", Port: 9092}
+	broker2 := kgo.BrokerMetadata{NodeID: 2, Host: "broker-2", Port: 9092}
+	// Seed brokers are numbered with negative ids by the client
+	seed1 := kgo.BrokerMetadata{NodeID: -1, Host: "seed-1", Port: 9092}
+	seed2 := kgo.BrokerMetadata{NodeID: -2, Host: "seed-2", Port: 9092}
+
+	requests := []struct {
+		broker kgo.BrokerMetadata
+		key    int16
+	}{
+		{seed1, (&kmsg.ApiVersionsRequest{}).Key()},
+		{seed1, (&kmsg.MetadataRequest{}).Key()},
+		{seed2, (&kmsg.MetadataRequest{}).Key()},
+		{broker1, (&kmsg.MetadataRequest{}).Key()},
+		{broker1, (&kmsg.ProduceRequest{}).Key()},
+		{broker1, (&kmsg.ProduceRequest{}).Key()},
+		{broker1, (&kmsg.FetchRequest{}).Key()},
+		{broker2, (&kmsg.FetchRequest{}).Key()},
+		{broker2, (&kmsg.FetchRequest{}).Key()},
+		{broker2, (&kmsg.FetchRequest{}).Key()},
+	}
+	for _, request := range requests {
+		hooks.OnWrite(request.broker, request.key, 100, 0, 0, nil)
+	}
+
+	expected := []struct {
+		brokerID string
+		api      string
+		count    float64
+	}{
+		{"seed", "ApiVersions", 1},
+		{"seed", "Metadata", 2},
+		{"1", "Metadata", 1},
+		{"1", "Produce", 2},
+		{"1", "Fetch", 1},
+		{"2", "Fetch", 3},
+	}
+	for _, test := range expected {
+		if count := testutil.ToFloat64(hooks.brokerRequests.WithLabelValues(test.brokerID, test.api)); count != test.count {
+			t.Errorf("expected %v %v requests to broker %v, got %v", test.count, test.api, test.brokerID, count)
+		}
+	}
+	if series := testutil.CollectAndCount(hooks.brokerRequests); series != len(expected) {
+		t.Errorf("expected %d broker request series, got %d", len(expected), series)
+	}
+}
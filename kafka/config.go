@@ -1,6 +1,16 @@
 package kafka
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMetadataMinAge         = 10 * time.Second
+	defaultMetadataMaxAge         = 5 * time.Minute
+	defaultDialTimeout            = 10 * time.Second
+	defaultRequestTimeoutOverhead = 20 * time.Second
+)
 
 type Config struct {
 	// General
@@ -8,19 +18,104 @@ type Config struct {
 	ClientID string   `koanf:"clientId"`
 	RackID   string   `koanf:"rackId"`
 
+	// MetadataMinAge is the minimum time between two metadata requests of the client, so that errors do not cause
+	// a flood of metadata requests
+	MetadataMinAge time.Duration `koanf:"metadataMinAge"`
+	// MetadataMaxAge is the maximum age of the client's cached metadata, after which it is refreshed in order to
+	// detect topology changes such as new brokers or partitions
+	MetadataMaxAge time.Duration `koanf:"metadataMaxAge"`
+
+	// DialTimeout is the maximum duration for establishing a broker connection, including the TLS handshake
+	DialTimeout time.Duration `koanf:"dialTimeout"`
+	// RequestTimeoutOverhead is the time a broker may take to respond to a request. For requests that carry their
+	// own timeout (e.g. ListOffsets), it's added on top of that timeout.
+	RequestTimeoutOverhead time.Duration `koanf:"requestTimeoutOverhead"`
+
+	// BrokerDiscovery resolves the seed brokers from a DNS SRV record instead of using the static list of brokers
+	BrokerDiscovery BrokerDiscoveryConfig `koanf:"brokerDiscovery"`
+
+	// ConnectRetry configures how often the initial connection is retried, e.g. if Kafka is started at the same time
+	ConnectRetry ConnectRetryConfig `koanf:"connectRetry"`
+
+	// AdminRequestRateLimit is the maximum number of admin requests (e.g. Metadata, ListOffsets, OffsetFetch or
+	// DescribeLogDirs) per second that KMinion issues for its metrics. Requests over the limit wait rather than fail.
+	// Requests which are issued internally by the Kafka clients, such as fetching the offsets topic or metadata
+	// refreshes, are not limited. Zero disables the rate limit.
+	AdminRequestRateLimit float64 `koanf:"adminRequestRateLimit"`
+	// AdminRequestRateLimitBurst is the number of admin requests that may be issued at once, before the rate limit
+	// applies.
+	AdminRequestRateLimitBurst int `koanf:"adminRequestRateLimitBurst"`
+
 	TLS  TLSConfig  `koanf:"tls"`
 	SASL SASLConfig `koanf:"sasl"`
+
+	// AzureEventHubs configures the compatibility mode for Azure Event Hubs' Kafka endpoint
+	AzureEventHubs AzureEventHubsConfig `koanf:"azureEventHubs"`
+
+	// ConfluentCloud configures the convenience mode for Confluent Cloud clusters
+	ConfluentCloud ConfluentCloudConfig `koanf:"confluentCloud"`
+
+	// brokerDiscovery is set by the service if the seed brokers are resolved from a SRV record
+	brokerDiscovery *brokerDiscovery
+
+	// saslCredentialProvider is set by the service and provides the SASL credentials for PLAIN and SCRAM
+	saslCredentialProvider CredentialProvider
 }
 
 func (c *Config) SetDefaults() {
 	c.ClientID = "kminion"
+	c.AdminRequestRateLimitBurst = 10
+	c.MetadataMinAge = defaultMetadataMinAge
+	c.MetadataMaxAge = defaultMetadataMaxAge
+	c.DialTimeout = defaultDialTimeout
+	c.RequestTimeoutOverhead = defaultRequestTimeoutOverhead
+
+	c.BrokerDiscovery.SetDefaults()
+	c.ConnectRetry.SetDefaults()
 
 	c.TLS.SetDefaults()
 	c.SASL.SetDefaults()
 }
 
 func (c *Config) Validate() error {
-	err := c.TLS.Validate()
+	// The client rejects a metadata min age below 10ms and a max age above 1h
+	if c.MetadataMinAge < 10*time.Millisecond {
+		return fmt.Errorf("metadata min age must be at least 10ms")
+	}
+	if c.MetadataMaxAge > time.Hour {
+		return fmt.Errorf("metadata max age must not be greater than 1h")
+	}
+	if c.MetadataMinAge > c.MetadataMaxAge {
+		return fmt.Errorf("metadata min age must not be greater than metadata max age")
+	}
+	if c.DialTimeout <= 0 {
+		return fmt.Errorf("dial timeout must be greater than 0")
+	}
+	if c.RequestTimeoutOverhead <= 0 {
+		return fmt.Errorf("request timeout overhead must be greater than 0")
+	}
+
+	if c.AdminRequestRateLimit < 0 {
+		return fmt.Errorf("admin request rate limit must not be negative")
+	}
+	if c.AdminRequestRateLimit > 0 && c.AdminRequestRateLimitBurst < 1 {
+		return fmt.Errorf("admin request rate limit burst must be at least 1 if an admin request rate limit is configured")
+	}
+
+	err := c.BrokerDiscovery.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate broker discovery config: %w", err)
+	}
+	if c.BrokerDiscovery.SRVRecord != "" && len(c.Brokers) > 0 {
+		return fmt.Errorf("brokers and a broker discovery srv record must not be configured at the same time")
+	}
+
+	err = c.ConnectRetry.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate connect retry config: %w", err)
+	}
+
+	err = c.TLS.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to validate TLS config: %w", err)
 	}
@@ -30,5 +125,18 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("failed to validate SASL config: %w", err)
 	}
 
+	err = c.AzureEventHubs.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate azure event hubs config: %w", err)
+	}
+
+	err = c.ConfluentCloud.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate confluent cloud config: %w", err)
+	}
+	if c.ConfluentCloud.Enabled && c.AzureEventHubs.Enabled {
+		return fmt.Errorf("confluent cloud and azure event hubs mode must not be enabled at the same time")
+	}
+
 	return nil
 }
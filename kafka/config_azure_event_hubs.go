@@ -0,0 +1,25 @@
+package kafka
+
+import "fmt"
+
+// AzureEventHubsConfig enables the compatibility mode for the Kafka endpoint of Azure Event Hubs
+type AzureEventHubsConfig struct {
+	Enabled bool `koanf:"enabled"`
+
+	// ConnectionString of the Event Hubs namespace. It's used as SASL PLAIN password, while the username is always
+	// "$ConnectionString".
+	ConnectionString string `koanf:"connectionString"`
+}
+
+// Validate Azure Event Hubs config input
+func (c *AzureEventHubsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.ConnectionString == "" {
+		return fmt.Errorf("azure event hubs compatibility mode is enabled, but no connection string is configured")
+	}
+
+	return nil
+}
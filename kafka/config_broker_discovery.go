@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// srvRecordExpr matches SRV record names in the form of _service._proto.name, e.g. _kafka._tcp.example.com
+var srvRecordExpr = regexp.MustCompile(`^_[a-zA-Z0-9-]+\._(tcp|udp)\.([a-zA-Z0-9-]+\.)*[a-zA-Z0-9-]+\.?$`)
+
+// BrokerDiscoveryConfig configures the discovery of the seed brokers via DNS SRV records
+type BrokerDiscoveryConfig struct {
+	// SRVRecord is the name of the DNS SRV record (e.g. _kafka._tcp.example.com) whose targets are used as seed
+	// brokers. If empty, the static list of brokers is used.
+	SRVRecord string `koanf:"srvRecord"`
+
+	// RefreshInterval specifies how often the SRV record is resolved again
+	RefreshInterval time.Duration `koanf:"refreshInterval"`
+}
+
+// Validate broker discovery config input
+func (c *BrokerDiscoveryConfig) Validate() error {
+	if c.SRVRecord == "" {
+		return nil
+	}
+
+	if !srvRecordExpr.MatchString(c.SRVRecord) {
+		return fmt.Errorf("srv record '%v' is invalid, expected a name in the form of _service._proto.name", c.SRVRecord)
+	}
+
+	if c.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh interval must be greater than 0")
+	}
+
+	return nil
+}
+
+// SetDefaults for broker discovery config
+func (c *BrokerDiscoveryConfig) SetDefaults() {
+	c.RefreshInterval = 5 * time.Minute
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Connections to Confluent Cloud go through the internet and load balancers, so that establishing them (including
+	// the TLS handshake and SASL authentication) and responses take longer than within a data center
+	confluentCloudDialTimeout            = 30 * time.Second
+	confluentCloudRequestTimeoutOverhead = 30 * time.Second
+	// Confluent Cloud replaces brokers during regular maintenance, which is noticed faster with a lower metadata age
+	confluentCloudMetadataMaxAge = time.Minute
+)
+
+// ConfluentCloudConfig enables the convenience mode for Confluent Cloud clusters, which require SASL_SSL with the
+// PLAIN mechanism and an API key as credentials.
+type ConfluentCloudConfig struct {
+	Enabled bool `koanf:"enabled"`
+
+	// APIKey and APISecret of a Confluent Cloud API key with access to the cluster. They are used as SASL PLAIN
+	// username and password.
+	APIKey    string `koanf:"apiKey"`
+	APISecret string `koanf:"apiSecret"`
+}
+
+// Validate Confluent Cloud config input
+func (c *ConfluentCloudConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.APIKey == "" || c.APISecret == "" {
+		return fmt.Errorf("confluent cloud mode is enabled, but api key or api secret are not configured")
+	}
+
+	return nil
+}
+
+// ApplyConfluentCloudTimeouts sets the dial, request and metadata timeouts that are recommended for Confluent Cloud.
+// Timeouts that have been changed from their defaults are left untouched.
+func (c *Config) ApplyConfluentCloudTimeouts() {
+	if c.DialTimeout == defaultDialTimeout {
+		c.DialTimeout = confluentCloudDialTimeout
+	}
+	if c.RequestTimeoutOverhead == defaultRequestTimeoutOverhead {
+		c.RequestTimeoutOverhead = confluentCloudRequestTimeoutOverhead
+	}
+	if c.MetadataMaxAge == defaultMetadataMaxAge {
+		c.MetadataMaxAge = confluentCloudMetadataMaxAge
+	}
+}
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConnectRetryConfig configures how often the initial connection to the Kafka cluster is retried
+type ConnectRetryConfig struct {
+	// MaxAttempts is the maximum number of connection attempts before KMinion gives up. 0 means unlimited.
+	MaxAttempts int `koanf:"maxAttempts"`
+
+	// InitialBackoff is the duration to wait after the first failed attempt. The backoff is doubled after each
+	// further failed attempt.
+	InitialBackoff time.Duration `koanf:"initialBackoff"`
+
+	// MaxBackoff is the maximum duration to wait between two attempts
+	MaxBackoff time.Duration `koanf:"maxBackoff"`
+}
+
+// Validate connect retry config input
+func (c *ConnectRetryConfig) Validate() error {
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative")
+	}
+	if c.InitialBackoff <= 0 {
+		return fmt.Errorf("initial backoff must be greater than 0")
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("max backoff must not be lower than the initial backoff")
+	}
+
+	return nil
+}
+
+// SetDefaults for connect retry config
+func (c *ConnectRetryConfig) SetDefaults() {
+	c.MaxAttempts = 10
+	c.InitialBackoff = time.Second
+	c.MaxBackoff = 30 * time.Second
+}
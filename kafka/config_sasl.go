@@ -1,6 +1,9 @@
 package kafka
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 const (
 	SASLMechanismPlain       = "PLAIN"
@@ -10,6 +13,13 @@ const (
 	SASLMechanismOAuthBearer = "OAUTHBEARER"
 )
 
+const (
+	SASLCredentialProviderStatic  = "static"
+	SASLCredentialProviderEnv     = "env"
+	SASLCredentialProviderCommand = "command"
+	SASLCredentialProviderVCAP    = "vcap"
+)
+
 // SASLConfig for Kafka Client
 type SASLConfig struct {
 	Enabled   bool   `koanf:"enabled"`
@@ -17,14 +27,42 @@ type SASLConfig struct {
 	Password  string `koanf:"password"`
 	Mechanism string `koanf:"mechanism"`
 
+	// CredentialProvider selects where the username and password for the PLAIN and SCRAM mechanisms come from. It's
+	// either "static" (username and password), "env" (environment variables, see Env), "command" (username and
+	// password command) or "vcap" (access token of the Kafka service bound via VCAP_SERVICES, PLAIN only). If empty,
+	// "command" is used if a password command is configured, otherwise "static".
+	CredentialProvider string        `koanf:"credentialProvider"`
+	Env                SASLEnvConfig `koanf:"env"`
+
+	// PasswordCommand is a command (program and arguments) that is executed at startup and whose stdout is used as
+	// password, e.g. the CLI of a secret manager. It must not be configured together with a password.
+	PasswordCommand []string `koanf:"passwordCommand"`
+	// PasswordCommandRefreshInterval specifies how often the password command is executed again. The new password is
+	// used for all subsequent connections. If set to 0 the command is only executed at startup.
+	PasswordCommandRefreshInterval time.Duration `koanf:"passwordCommandRefreshInterval"`
+
 	// SASL Mechanisms that require more configuration than username & password
-	GSSAPI SASLGSSAPIConfig `koanf:"gssapi"`
+	GSSAPI          SASLGSSAPIConfig          `koanf:"gssapi"`
+	DelegationToken SASLDelegationTokenConfig `koanf:"delegationToken"`
 }
 
 // SetDefaults for SASL Config
 func (c *SASLConfig) SetDefaults() {
 	c.Enabled = false
 	c.Mechanism = SASLMechanismPlain
+	c.Env.SetDefaults()
+}
+
+// effectiveCredentialProvider returns the configured credential provider or the one that is implied by the other
+// settings if none has been configured
+func (c *SASLConfig) effectiveCredentialProvider() string {
+	if c.CredentialProvider != "" {
+		return c.CredentialProvider
+	}
+	if len(c.PasswordCommand) > 0 {
+		return SASLCredentialProviderCommand
+	}
+	return SASLCredentialProviderStatic
 }
 
 // Validate SASL config input
@@ -42,5 +80,50 @@ func (c *SASLConfig) Validate() error {
 		return fmt.Errorf("given sasl mechanism '%v' is invalid", c.Mechanism)
 	}
 
+	credentialProvider := c.effectiveCredentialProvider()
+	switch credentialProvider {
+	case SASLCredentialProviderStatic:
+	case SASLCredentialProviderEnv, SASLCredentialProviderCommand:
+		if c.Password != "" {
+			return fmt.Errorf("password must not be configured together with the credential provider '%v'", credentialProvider)
+		}
+		if c.Mechanism != SASLMechanismPlain && c.Mechanism != SASLMechanismScramSHA256 && c.Mechanism != SASLMechanismScramSHA512 {
+			return fmt.Errorf("credential provider '%v' is only supported for the sasl mechanisms PLAIN and SCRAM", credentialProvider)
+		}
+	case SASLCredentialProviderVCAP:
+		if c.Username != "" || c.Password != "" {
+			return fmt.Errorf("username and password must not be configured together with the credential provider '%v'", credentialProvider)
+		}
+		if c.Mechanism != SASLMechanismPlain {
+			return fmt.Errorf("credential provider '%v' is only supported for the sasl mechanism PLAIN", credentialProvider)
+		}
+	default:
+		return fmt.Errorf("given credential provider '%v' is invalid", credentialProvider)
+	}
+
+	if credentialProvider == SASLCredentialProviderEnv {
+		err := c.Env.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate env credential provider config: %w", err)
+		}
+	}
+
+	if len(c.PasswordCommand) > 0 && credentialProvider != SASLCredentialProviderCommand {
+		return fmt.Errorf("password command is configured, but the credential provider is '%v'", credentialProvider)
+	}
+	if credentialProvider == SASLCredentialProviderCommand {
+		if len(c.PasswordCommand) == 0 || c.PasswordCommand[0] == "" {
+			return fmt.Errorf("password command must start with the program to execute")
+		}
+	}
+	if c.PasswordCommandRefreshInterval < 0 {
+		return fmt.Errorf("password command refresh interval must not be negative")
+	}
+
+	err := c.DelegationToken.Validate(c.Mechanism, credentialProvider)
+	if err != nil {
+		return fmt.Errorf("failed to validate delegation token config: %w", err)
+	}
+
 	return nil
 }
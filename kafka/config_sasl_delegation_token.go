@@ -0,0 +1,50 @@
+package kafka
+
+import "fmt"
+
+// SASLDelegationTokenConfig to authenticate with a Kafka delegation token. Delegation tokens are authenticated via
+// SCRAM, using the token id as username and the token HMAC as password.
+type SASLDelegationTokenConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	TokenID string `koanf:"tokenId"`
+	HMAC    string `koanf:"hmac"`
+}
+
+// Validate delegation token config input. The SASL mechanism must be one of the SCRAM mechanisms. The token id and
+// HMAC are configured statically, unless they are provided by the given credential provider: The env provider
+// provides both, the command provider provides the HMAC.
+func (c *SASLDelegationTokenConfig) Validate(mechanism string, credentialProvider string) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if mechanism != SASLMechanismScramSHA256 && mechanism != SASLMechanismScramSHA512 {
+		return fmt.Errorf("delegation tokens require the sasl mechanism '%v' or '%v', but '%v' is configured",
+			SASLMechanismScramSHA256,
+			SASLMechanismScramSHA512,
+			mechanism)
+	}
+
+	switch credentialProvider {
+	case SASLCredentialProviderEnv:
+		if c.TokenID != "" || c.HMAC != "" {
+			return fmt.Errorf("token id and hmac must not be configured, as they are read from the environment")
+		}
+	case SASLCredentialProviderCommand:
+		if c.TokenID == "" {
+			return fmt.Errorf("delegation token is enabled, but no token id is configured")
+		}
+		if c.HMAC != "" {
+			return fmt.Errorf("token hmac must not be configured, as it is the output of the password command")
+		}
+	default:
+		if c.TokenID == "" {
+			return fmt.Errorf("delegation token is enabled, but no token id is configured")
+		}
+		if c.HMAC == "" {
+			return fmt.Errorf("delegation token is enabled, but no token hmac is configured")
+		}
+	}
+
+	return nil
+}
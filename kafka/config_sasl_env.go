@@ -0,0 +1,27 @@
+package kafka
+
+import "fmt"
+
+// SASLEnvConfig configures the environment variables the env credential provider reads the SASL credentials from
+type SASLEnvConfig struct {
+	UsernameVariable string `koanf:"usernameVariable"`
+	PasswordVariable string `koanf:"passwordVariable"`
+}
+
+// SetDefaults for SASL env config
+func (c *SASLEnvConfig) SetDefaults() {
+	c.UsernameVariable = "KMINION_SASL_USERNAME"
+	c.PasswordVariable = "KMINION_SASL_PASSWORD"
+}
+
+// Validate SASL env config input
+func (c *SASLEnvConfig) Validate() error {
+	if c.UsernameVariable == "" {
+		return fmt.Errorf("username variable must be set")
+	}
+	if c.PasswordVariable == "" {
+		return fmt.Errorf("password variable must be set")
+	}
+
+	return nil
+}
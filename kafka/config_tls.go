@@ -4,6 +4,7 @@ package kafka
 type TLSConfig struct {
 	Enabled               bool   `koanf:"enabled"`
 	CaFilepath            string `koanf:"caFilepath"`
+	CaDirpath             string `koanf:"caDirpath"`
 	CertFilepath          string `koanf:"certFilepath"`
 	KeyFilepath           string `koanf:"keyFilepath"`
 	Passphrase            string `koanf:"passphrase"`
@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+)
+
+// CredentialProvider provides the username and password for the SASL mechanisms PLAIN and SCRAM. If delegation
+// tokens are enabled, these are the token id and HMAC. It's asked for the credentials whenever a new broker
+// connection is authenticated, so that implementations can rotate credentials. Implementations must be safe for
+// concurrent use.
+type CredentialProvider interface {
+	FetchSASLCredentials(ctx context.Context) (user, pass string, err error)
+}
+
+// newCredentialProvider returns the credential provider selected by the SASL config. If delegation tokens are
+// enabled, the provided credentials are the token id and HMAC.
+func newCredentialProvider(cfg SASLConfig, logger *zap.Logger) CredentialProvider {
+	isToken := cfg.DelegationToken.Enabled
+	switch cfg.effectiveCredentialProvider() {
+	case SASLCredentialProviderEnv:
+		return &envCredentialProvider{
+			usernameVariable: cfg.Env.UsernameVariable,
+			passwordVariable: cfg.Env.PasswordVariable,
+		}
+	case SASLCredentialProviderCommand:
+		username := cfg.Username
+		if isToken {
+			username = cfg.DelegationToken.TokenID
+		}
+		return newCommandCredentialProvider(username, cfg.PasswordCommand, cfg.PasswordCommandRefreshInterval, logger)
+	case SASLCredentialProviderVCAP:
+		return &vcapCredentialProvider{httpClient: http.DefaultClient}
+	default:
+		if isToken {
+			return &staticCredentialProvider{username: cfg.DelegationToken.TokenID, password: cfg.DelegationToken.HMAC}
+		}
+		return &staticCredentialProvider{username: cfg.Username, password: cfg.Password}
+	}
+}
+
+// staticCredentialProvider provides the username and password (or delegation token) of the config.
+type staticCredentialProvider struct {
+	username string
+	password string
+}
+
+func (p *staticCredentialProvider) FetchSASLCredentials(_ context.Context) (string, string, error) {
+	return p.username, p.password, nil
+}
+
+// envCredentialProvider reads the credentials from the configured environment variables.
+type envCredentialProvider struct {
+	usernameVariable string
+	passwordVariable string
+}
+
+func (p *envCredentialProvider) FetchSASLCredentials(_ context.Context) (string, string, error) {
+	username, exists := os.LookupEnv(p.usernameVariable)
+	if !exists || username == "" {
+		return "", "", fmt.Errorf("environment variable '%v' for the sasl username is not set", p.usernameVariable)
+	}
+	password, exists := os.LookupEnv(p.passwordVariable)
+	if !exists || password == "" {
+		return "", "", fmt.Errorf("environment variable '%v' for the sasl password is not set", p.passwordVariable)
+	}
+
+	return username, password, nil
+}
@@ -0,0 +1,229 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTokenServer returns a token endpoint that issues the given access token for the given client credentials
+func newTokenServer(username string, password string, accessToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != username || pass != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"access_token": "%v"}`, accessToken)
+	}))
+}
+
+// setVCAPServices binds a kafka service with the given token endpoint via VCAP_SERVICES until the test has finished
+func setVCAPServices(t *testing.T, tokenURL string) {
+	os.Setenv(VCAPServicesVariable, fmt.Sprintf(`{"kafka": [{"name": "kafka", "credentials": {
+		"username": "client-id", "password": "client-secret", "cluster": {"brokers": "broker-1:9093,broker-2:9093"},
+		"urls": {"cert_current": "https://example.com/ca.cer", "token": "%v"}}}]}`, tokenURL))
+	t.Cleanup(func() { os.Unsetenv(VCAPServicesVariable) })
+}
+
+func TestCredentialProviderSelection(t *testing.T) {
+	os.Setenv("KMINION_TEST_USERNAME", "token-id")
+	os.Setenv("KMINION_TEST_PASSWORD", "hmac")
+	defer os.Unsetenv("KMINION_TEST_USERNAME")
+	defer os.Unsetenv("KMINION_TEST_PASSWORD")
+	tokenServer := newTokenServer("client-id", "client-secret", "access-token")
+	defer tokenServer.Close()
+	setVCAPServices(t, tokenServer.URL)
+
+	tt := []struct {
+		name         string
+		cfg          SASLConfig
+		expectedType CredentialProvider
+		expectedUser string
+		expectedPass string
+	}{
+		{
+			name:         "static",
+			cfg:          SASLConfig{Mechanism: SASLMechanismPlain, Username: "kminion", Password: "secret"},
+			expectedType: &staticCredentialProvider{},
+			expectedUser: "kminion",
+			expectedPass: "secret",
+		},
+		{
+			name: "static delegation token",
+			cfg: SASLConfig{
+				Mechanism:       SASLMechanismScramSHA256,
+				DelegationToken: SASLDelegationTokenConfig{Enabled: true, TokenID: "token-id", HMAC: "hmac"},
+			},
+			expectedType: &staticCredentialProvider{},
+			expectedUser: "token-id",
+			expectedPass: "hmac",
+		},
+		{
+			name: "env delegation token",
+			cfg: SASLConfig{
+				Mechanism:          SASLMechanismScramSHA512,
+				CredentialProvider: SASLCredentialProviderEnv,
+				Env:                SASLEnvConfig{UsernameVariable: "KMINION_TEST_USERNAME", PasswordVariable: "KMINION_TEST_PASSWORD"},
+				DelegationToken:    SASLDelegationTokenConfig{Enabled: true},
+			},
+			expectedType: &envCredentialProvider{},
+			expectedUser: "token-id",
+			expectedPass: "hmac",
+		},
+		{
+			name: "command delegation token",
+			cfg: SASLConfig{
+				Mechanism:       SASLMechanismScramSHA256,
+				PasswordCommand: []string{"echo", "hmac"},
+				DelegationToken: SASLDelegationTokenConfig{Enabled: true, TokenID: "token-id"},
+			},
+			expectedType: &commandCredentialProvider{},
+			expectedUser: "token-id",
+			expectedPass: "hmac",
+		},
+		{
+			name: "command",
+			cfg: SASLConfig{
+				Mechanism:       SASLMechanismScramSHA256,
+				Username:        "kminion",
+				PasswordCommand: []string{"echo", "secret"},
+			},
+			expectedType: &commandCredentialProvider{},
+			expectedUser: "kminion",
+			expectedPass: "secret",
+		},
+		{
+			name:         "vcap",
+			cfg:          SASLConfig{Mechanism: SASLMechanismPlain, CredentialProvider: SASLCredentialProviderVCAP},
+			expectedType: &vcapCredentialProvider{},
+			expectedUser: "client-id",
+			expectedPass: "access-token",
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			test.cfg.Enabled = true
+			if err := test.cfg.Validate(); err != nil {
+				t.Fatalf("expected config to be valid, got %v", err)
+			}
+
+			provider := newCredentialProvider(test.cfg, zap.NewNop())
+			if reflect.TypeOf(provider) != reflect.TypeOf(test.expectedType) {
+				t.Fatalf("expected a %T, got %T", test.expectedType, provider)
+			}
+			user, pass, err := provider.FetchSASLCredentials(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != test.expectedUser || pass != test.expectedPass {
+				t.Fatalf("expected credentials %v:%v, got %v:%v", test.expectedUser, test.expectedPass, user, pass)
+			}
+		})
+	}
+}
+
+func TestCommandCredentialProviderRefresh(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	writePassword := func(password string) {
+		if err := ioutil.WriteFile(passwordFile, []byte(password+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fetchPassword := func(provider CredentialProvider) string {
+		_, pass, err := provider.FetchSASLCredentials(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pass
+	}
+
+	writePassword("first")
+	provider := newCommandCredentialProvider("kminion", []string{"cat", passwordFile}, 100*time.Millisecond, zap.NewNop())
+	if pass := fetchPassword(provider); pass != "first" {
+		t.Fatalf("expected the password of the command, got %v", pass)
+	}
+
+	// The password is cached until the refresh interval has passed
+	writePassword("second")
+	if pass := fetchPassword(provider); pass != "first" {
+		t.Fatalf("expected the cached password within the refresh interval, got %v", pass)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if pass := fetchPassword(provider); pass != "second" {
+		t.Fatalf("expected the refreshed password after the refresh interval, got %v", pass)
+	}
+
+	// If the refresh fails, the previous password is still used
+	if err := os.Remove(passwordFile); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if pass := fetchPassword(provider); pass != "second" {
+		t.Fatalf("expected the previous password if the refresh fails, got %v", pass)
+	}
+}
+
+func TestVCAPCredentialProvider(t *testing.T) {
+	tokenServer := newTokenServer("client-id", "other-secret", "access-token")
+	defer tokenServer.Close()
+	setVCAPServices(t, tokenServer.URL)
+
+	provider := &vcapCredentialProvider{httpClient: tokenServer.Client()}
+	if _, _, err := provider.FetchSASLCredentials(context.Background()); err == nil {
+		t.Fatal("expected an error if the token endpoint rejects the client credentials")
+	}
+
+	service, exists, err := LookupVCAPKafkaService()
+	if err != nil || !exists {
+		t.Fatalf("expected the bound kafka service to be found, got %v (exists: %v)", err, exists)
+	}
+	expected := VCAPKafkaService{
+		Brokers:   []string{"broker-1:9093", "broker-2:9093"},
+		Username:  "client-id",
+		Password:  "client-secret",
+		CACertURL: "https://example.com/ca.cer",
+		TokenURL:  tokenServer.URL,
+	}
+	if !reflect.DeepEqual(service, expected) {
+		t.Fatalf("expected service %+v, got %+v", expected, service)
+	}
+
+	cfg := SASLConfig{Enabled: true, Mechanism: SASLMechanismScramSHA256, CredentialProvider: SASLCredentialProviderVCAP}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error for the vcap credential provider with a SCRAM mechanism")
+	}
+	cfg = SASLConfig{Enabled: true, Mechanism: SASLMechanismPlain, CredentialProvider: SASLCredentialProviderVCAP, Password: "secret"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an error if a password is configured together with the vcap credential provider")
+	}
+}
+
+func TestDelegationTokenConfigWithCredentialProvider(t *testing.T) {
+	cfg := SASLDelegationTokenConfig{Enabled: true, TokenID: "token-id", HMAC: "hmac"}
+	if err := cfg.Validate(SASLMechanismScramSHA256, SASLCredentialProviderStatic); err != nil {
+		t.Errorf("expected static delegation token to be valid, got %v", err)
+	}
+	if err := cfg.Validate(SASLMechanismScramSHA256, SASLCredentialProviderEnv); err == nil {
+		t.Errorf("expected an error if the token is configured statically and read from the environment")
+	}
+	if err := cfg.Validate(SASLMechanismScramSHA256, SASLCredentialProviderCommand); err == nil {
+		t.Errorf("expected an error if the hmac is configured statically and provided by the command")
+	}
+	if err := cfg.Validate(SASLMechanismPlain, SASLCredentialProviderStatic); err == nil {
+		t.Errorf("expected an error for delegation tokens with the PLAIN mechanism")
+	}
+}
@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// passwordCommandTimeout is the maximum duration the password command may take
+const passwordCommandTimeout = 30 * time.Second
+
+// commandCredentialProvider runs the configured password command and caches its output. If a refresh interval is
+// configured the command is run again once the cached password is older than the interval. It is safe for concurrent
+// use.
+type commandCredentialProvider struct {
+	username        string
+	command         []string
+	refreshInterval time.Duration
+	logger          *zap.Logger
+
+	mutex     sync.Mutex
+	password  string
+	fetchedAt time.Time
+}
+
+func newCommandCredentialProvider(username string, command []string, refreshInterval time.Duration, logger *zap.Logger) *commandCredentialProvider {
+	return &commandCredentialProvider{
+		username:        username,
+		command:         command,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+	}
+}
+
+// FetchSASLCredentials returns the configured username along with the cached password. The password command is run
+// if there is no cached password yet or the cached password is due for a refresh. If the refresh fails, the previous
+// password is still used.
+func (p *commandCredentialProvider) FetchSASLCredentials(ctx context.Context) (string, string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	isDue := p.refreshInterval > 0 && time.Since(p.fetchedAt) > p.refreshInterval
+	if p.password != "" && !isDue {
+		return p.username, p.password, nil
+	}
+
+	password, err := runPasswordCommand(ctx, p.command)
+	if err != nil {
+		if p.password == "" {
+			return "", "", err
+		}
+		p.logger.Warn("failed to refresh sasl password, using the previous password", zap.Error(err))
+		return p.username, p.password, nil
+	}
+	p.password = password
+	p.fetchedAt = time.Now()
+
+	return p.username, p.password, nil
+}
+
+// runPasswordCommand executes the command and returns its stdout without the trailing line break. The command must
+// exit with code 0 and print a non-empty password.
+func runPasswordCommand(ctx context.Context, command []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, passwordCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to run sasl password command '%v': %w (stderr: %v)",
+			command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	password := strings.TrimRight(stdout.String(), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("sasl password command '%v' did not print a password", command[0])
+	}
+
+	return password, nil
+}
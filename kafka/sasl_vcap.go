@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// VCAPServicesVariable is the environment variable in which Cloud Foundry describes the services bound to the app
+const VCAPServicesVariable = "VCAP_SERVICES"
+
+// VCAPKafkaService is the first Kafka service that is bound via VCAP_SERVICES
+type VCAPKafkaService struct {
+	Brokers []string
+	// Username and Password authenticate against the token endpoint. The username is also the SASL username.
+	Username  string
+	Password  string
+	CACertURL string
+	TokenURL  string
+}
+
+// LookupVCAPKafkaService parses the Kafka service from VCAP_SERVICES. It returns false if the variable is not set.
+func LookupVCAPKafkaService() (VCAPKafkaService, bool, error) {
+	vcap, exists := os.LookupEnv(VCAPServicesVariable)
+	if !exists {
+		return VCAPKafkaService{}, false, nil
+	}
+
+	var services struct {
+		Kafka []struct {
+			Credentials struct {
+				Username string
+				Password string
+				Cluster  struct {
+					Brokers string
+				}
+				Urls struct {
+					CertCurrent string `json:"cert_current"`
+					Token       string `json:"token"`
+				}
+			}
+		}
+	}
+	err := json.Unmarshal([]byte(vcap), &services)
+	if err != nil {
+		return VCAPKafkaService{}, true, fmt.Errorf("failed to parse %v: %w", VCAPServicesVariable, err)
+	}
+	if len(services.Kafka) == 0 {
+		return VCAPKafkaService{}, true, fmt.Errorf("%v does not contain a kafka service", VCAPServicesVariable)
+	}
+
+	credentials := services.Kafka[0].Credentials
+	return VCAPKafkaService{
+		Brokers:   strings.Split(credentials.Cluster.Brokers, ","),
+		Username:  credentials.Username,
+		Password:  credentials.Password,
+		CACertURL: credentials.Urls.CertCurrent,
+		TokenURL:  credentials.Urls.Token,
+	}, true, nil
+}
+
+// vcapCredentialProvider authenticates with the access token of the Kafka service that is bound via VCAP_SERVICES.
+// A new token is requested from the service's token endpoint whenever a broker connection is authenticated.
+type vcapCredentialProvider struct {
+	httpClient *http.Client
+}
+
+func (p *vcapCredentialProvider) FetchSASLCredentials(ctx context.Context) (string, string, error) {
+	service, exists, err := LookupVCAPKafkaService()
+	if err != nil {
+		return "", "", err
+	}
+	if !exists {
+		return "", "", fmt.Errorf("environment variable '%v' is not set", VCAPServicesVariable)
+	}
+
+	token, err := p.fetchToken(ctx, service)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch sasl token for the kafka service bound via %v: %w",
+			VCAPServicesVariable, err)
+	}
+
+	return service.Username, token, nil
+}
+
+// fetchToken requests an access token via the OAuth client credentials grant
+func (p *vcapCredentialProvider) fetchToken(ctx context.Context, service VCAPKafkaService) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, service.TokenURL,
+		strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(service.Username, service.Password)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("token endpoint responded with status code %v", res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token response does not contain an access token")
+	}
+
+	return token.AccessToken, nil
+}
@@ -3,24 +3,66 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/kversion"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"net"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type Service struct {
 	cfg    Config
 	Client *kgo.Client
 	logger *zap.Logger
+	hooks  *clientHooks
+
+	// adminRequestLimiter throttles the admin requests that are issued via Request and RequestSharded. Requests which
+	// the Kafka clients issue internally (e.g. fetches or metadata refreshes) are not throttled. It is nil if no admin
+	// request rate limit is configured.
+	adminRequestLimiter *rate.Limiter
+	rateLimitedCount    prometheus.Counter
+
+	connectAttempts prometheus.Counter
+
+	// brokerDiscovery is nil if the static list of seed brokers is used
+	brokerDiscovery *brokerDiscovery
 }
 
-func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
+func NewService(cfg Config, logger *zap.Logger, metricsNamespace string, registerer prometheus.Registerer) (*Service, error) {
+	// Resolve seed brokers from the SRV record if configured
+	var discovery *brokerDiscovery
+	if cfg.BrokerDiscovery.SRVRecord != "" {
+		discovery = newBrokerDiscovery(cfg.BrokerDiscovery, logger, net.DefaultResolver)
+		resolveCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		brokers, err := discovery.resolveSeeds(resolveCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover seed brokers: %w", err)
+		}
+		cfg.Brokers = brokers
+		cfg.brokerDiscovery = discovery
+	}
+
+	// Fetch the SASL credentials once, so that KMinion fails right away if no credentials can be retrieved
+	if cfg.SASL.Enabled {
+		provider := newCredentialProvider(cfg.SASL, logger)
+		_, _, err := provider.FetchSASLCredentials(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sasl credentials: %w", err)
+		}
+		cfg.saslCredentialProvider = provider
+	}
+
 	// Create Kafka Client
 	hooksChildLogger := logger.With(zap.String("source", "kafka_client_hooks"))
-	clientHooks := newClientHooks(hooksChildLogger, "")
+	clientHooks := newClientHooks(hooksChildLogger, metricsNamespace, registerer)
 
 	kgoOpts, err := NewKgoConfig(cfg, logger, clientHooks)
 	if err != nil {
@@ -32,13 +74,145 @@ func NewService(cfg Config, logger *zap.Logger) (*Service, error) {
 		return nil, fmt.Errorf("failed to create kafka Client: %w", err)
 	}
 
+	var adminRequestLimiter *rate.Limiter
+	if cfg.AdminRequestRateLimit > 0 {
+		adminRequestLimiter = rate.NewLimiter(rate.Limit(cfg.AdminRequestRateLimit), cfg.AdminRequestRateLimitBurst)
+	}
+	rateLimitedCount := promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "admin_requests_rate_limited_total",
+		Help:      "Total number of Kafka admin requests that had to wait because of the admin request rate limit.",
+	})
+	connectAttempts := promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "connect_attempts_total",
+		Help:      "Total number of attempts to establish the initial connection to the Kafka cluster.",
+	})
+
 	return &Service{
 		cfg:    cfg,
 		Client: kafkaClient,
 		logger: logger,
+		hooks:  clientHooks,
+
+		brokerDiscovery:     discovery,
+		adminRequestLimiter: adminRequestLimiter,
+		rateLimitedCount:    rateLimitedCount,
+		connectAttempts:     connectAttempts,
 	}, nil
 }
 
+// SeedBrokers returns the seed brokers the Kafka client has been created with. These are the brokers resolved from
+// the SRV record if broker discovery is configured.
+func (s *Service) SeedBrokers() []string {
+	return s.cfg.Brokers
+}
+
+// SuccessfulConnects returns the number of broker connections that have been established successfully by all clients
+// of the service. It's used to detect reconnects.
+func (s *Service) SuccessfulConnects() uint64 {
+	return atomic.LoadUint64(s.hooks.successfulConnects)
+}
+
+// StartBrokerDiscovery periodically resolves the seed brokers from the configured SRV record until the context is
+// done. It returns immediately if no SRV record is configured.
+func (s *Service) StartBrokerDiscovery(ctx context.Context) {
+	if s.brokerDiscovery == nil {
+		return
+	}
+	s.brokerDiscovery.refresh(ctx)
+}
+
+// NewClient creates an additional Kafka client with the same config and hooks as the service's client. This is
+// required for features which consume topics independently of the offsets topic consumer.
+func (s *Service) NewClient(opts ...kgo.Opt) (*kgo.Client, error) {
+	kgoOpts, err := NewKgoConfig(s.cfg, s.logger, s.hooks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a valid kafka Client config: %w", err)
+	}
+	kgoOpts = append(kgoOpts, opts...)
+
+	return kgo.NewClient(kgoOpts...)
+}
+
+// Request implements the kmsg.Requestor interface. It issues the request using the Kafka client once the request
+// fits into the configured admin request rate limit. Requests over the limit wait rather than fail.
+func (s *Service) Request(ctx context.Context, req kmsg.Request) (kmsg.Response, error) {
+	err := s.waitForRequestBudget(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Client.Request(ctx, req)
+}
+
+// RequestSharded issues a sharded request using the Kafka client once the request fits into the configured admin
+// request rate limit.
+func (s *Service) RequestSharded(ctx context.Context, req kmsg.Request) []kgo.ResponseShard {
+	err := s.waitForRequestBudget(ctx)
+	if err != nil {
+		return []kgo.ResponseShard{{Req: req, Err: err}}
+	}
+
+	return s.Client.RequestSharded(ctx, req)
+}
+
+// waitForRequestBudget blocks until the admin request rate limit allows the next request or the context is done.
+func (s *Service) waitForRequestBudget(ctx context.Context) error {
+	if s.adminRequestLimiter == nil || s.adminRequestLimiter.Allow() {
+		return nil
+	}
+
+	s.rateLimitedCount.Inc()
+	err := s.adminRequestLimiter.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for admin request rate limit: %w", err)
+	}
+
+	return nil
+}
+
+// ConnectWithRetry tests the connection to the Kafka cluster until it succeeds, using an exponential backoff between
+// the attempts. An error is returned once the configured max attempts are exhausted or the context is done.
+func (s *Service) ConnectWithRetry(ctx context.Context) error {
+	return s.retryConnect(ctx, s.TestConnection)
+}
+
+// retryConnect calls connect until it succeeds, the max attempts are exhausted or the context is done
+func (s *Service) retryConnect(ctx context.Context, connect func(ctx context.Context) error) error {
+	retryCfg := s.cfg.ConnectRetry
+	backoff := retryCfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		s.connectAttempts.Inc()
+		connectCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		err := connect(connectCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if retryCfg.MaxAttempts > 0 && attempt >= retryCfg.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		s.logger.Warn("failed to connect to Kafka cluster, retrying after backoff",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context done while waiting for the next connection attempt: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > retryCfg.MaxBackoff {
+			backoff = retryCfg.MaxBackoff
+		}
+	}
+}
+
 // TestConnection tries to fetch Broker metadata and prints some information if connection succeeds. An error will be
 // returned if connecting fails.
 func (s *Service) TestConnection(ctx context.Context) error {
@@ -48,14 +222,14 @@ func (s *Service) TestConnection(ctx context.Context) error {
 	req := kmsg.MetadataRequest{
 		Topics: nil,
 	}
-	res, err := req.RequestWith(ctx, s.Client)
+	res, err := req.RequestWith(ctx, s)
 	if err != nil {
 		return fmt.Errorf("failed to request metadata: %w", err)
 	}
 
 	// Request versions in order to guess Kafka Cluster version
 	versionsReq := kmsg.NewApiVersionsRequest()
-	versionsRes, err := versionsReq.RequestWith(ctx, s.Client)
+	versionsRes, err := versionsReq.RequestWith(ctx, s)
 	if err != nil {
 		return fmt.Errorf("failed to request api versions: %w", err)
 	}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"testing"
+	"time"
+)
+
+func TestWaitForRequestBudget(t *testing.T) {
+	svc := &Service{
+		adminRequestLimiter: rate.NewLimiter(rate.Limit(20), 2),
+		rateLimitedCount:    prometheus.NewCounter(prometheus.CounterOpts{Name: "admin_requests_rate_limited_total"}),
+	}
+
+	// The burst is issued right away, the following requests wait for the rate limit
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := svc.waitForRequestBudget(context.Background()); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected the requests beyond the burst to be throttled, took %v", elapsed)
+	}
+	if count := testutil.ToFloat64(svc.rateLimitedCount); count != 2 {
+		t.Errorf("expected 2 rate limited requests, got %v", count)
+	}
+
+	// Waiting requests fail once their context is done
+	svc.adminRequestLimiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+	if err := svc.waitForRequestBudget(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := svc.waitForRequestBudget(ctx); err == nil {
+		t.Errorf("expected an error for a request whose context is done")
+	}
+}
+
+func TestWaitForRequestBudgetWithoutLimit(t *testing.T) {
+	svc := &Service{
+		rateLimitedCount: prometheus.NewCounter(prometheus.CounterOpts{Name: "admin_requests_rate_limited_total"}),
+	}
+	for i := 0; i < 100; i++ {
+		if err := svc.waitForRequestBudget(context.Background()); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+	if count := testutil.ToFloat64(svc.rateLimitedCount); count != 0 {
+		t.Errorf("expected no rate limited requests, got %v", count)
+	}
+}
+
+func TestRetryConnect(t *testing.T) {
+	newRetryService := func(maxAttempts int) *Service {
+		cfg := Config{}
+		cfg.ConnectRetry = ConnectRetryConfig{
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     25 * time.Millisecond,
+		}
+		return &Service{
+			cfg:             cfg,
+			logger:          zap.NewNop(),
+			connectAttempts: prometheus.NewCounter(prometheus.CounterOpts{Name: "connect_attempts_total"}),
+		}
+	}
+	// failingConnect fails the given number of times before it succeeds and records when it has been called
+	failingConnect := func(failures int, calls *[]time.Time) func(ctx context.Context) error {
+		return func(_ context.Context) error {
+			*calls = append(*calls, time.Now())
+			if len(*calls) <= failures {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+	}
+
+	// The backoff doubles after every failed attempt, but is capped at the max backoff: 10ms, 20ms, 25ms
+	svc := newRetryService(5)
+	calls := make([]time.Time, 0)
+	if err := svc.retryConnect(context.Background(), failingConnect(3, &calls)); err != nil {
+		t.Fatalf("expected to connect after 3 failures, got: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(calls))
+	}
+	for i, minBackoff := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond} {
+		if backoff := calls[i+1].Sub(calls[i]); backoff < minBackoff {
+			t.Errorf("expected a backoff of at least %v before attempt %d, got %v", minBackoff, i+2, backoff)
+		}
+	}
+	if attempts := testutil.ToFloat64(svc.connectAttempts); attempts != 4 {
+		t.Errorf("expected 4 counted attempts, got %v", attempts)
+	}
+
+	// Gives up once the max attempts are exhausted
+	svc = newRetryService(2)
+	calls = make([]time.Time, 0)
+	if err := svc.retryConnect(context.Background(), failingConnect(3, &calls)); err == nil || len(calls) != 2 {
+		t.Errorf("expected to give up after 2 attempts, got %d attempts and error %v", len(calls), err)
+	}
+
+	// Stops waiting for the next attempt once the context is done
+	svc = newRetryService(0)
+	svc.cfg.ConnectRetry.InitialBackoff = time.Hour
+	svc.cfg.ConnectRetry.MaxBackoff = time.Hour
+	calls = make([]time.Time, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := svc.retryConnect(ctx, failingConnect(3, &calls)); err == nil || len(calls) != 1 {
+		t.Errorf("expected to stop after the context is done, got %d attempts and error %v", len(calls), err)
+	}
+}
@@ -10,8 +10,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// NewLogger creates a preconfigured global logger and configures the global zap logger
-func NewLogger(cfg Config, metricsNamespace string) *zap.Logger {
+// NewLogger creates a preconfigured global logger and configures the global zap logger. The log message counters are
+// registered with the given registerer.
+func NewLogger(cfg Config, metricsNamespace string, registerer prometheus.Registerer) *zap.Logger {
 	encoderCfg := zap.NewProductionEncoderConfig()
 	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
 
@@ -24,7 +25,7 @@ func NewLogger(cfg Config, metricsNamespace string) *zap.Logger {
 		zapcore.Lock(os.Stdout),
 		level,
 	)
-	core = zapcore.RegisterHooks(core, prometheusHook(metricsNamespace))
+	core = zapcore.RegisterHooks(core, prometheusHook(metricsNamespace, registerer))
 	logger := zap.New(core)
 	zap.ReplaceGlobals(logger)
 
@@ -32,8 +33,8 @@ func NewLogger(cfg Config, metricsNamespace string) *zap.Logger {
 }
 
 // prometheusHook is a hook for the zap library which exposes Prometheus counters for various log levels.
-func prometheusHook(metricsNamespace string) func(zapcore.Entry) error {
-	messageCounterVec := promauto.NewCounterVec(prometheus.CounterOpts{
+func prometheusHook(metricsNamespace string, registerer prometheus.Registerer) func(zapcore.Entry) error {
+	messageCounterVec := promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Name:      "log_messages_total",
 		Help:      "Total number of log messages by log level emitted by KMinion.",
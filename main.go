@@ -2,20 +2,19 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"github.com/cloudhut/kminion/v2/kafka"
 	"github.com/cloudhut/kminion/v2/logging"
 	"github.com/cloudhut/kminion/v2/minion"
 	"github.com/cloudhut/kminion/v2/prometheus"
 	promclient "github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
-	"time"
 )
 
 func main() {
@@ -24,12 +23,19 @@ func main() {
 		panic(fmt.Errorf("failed to create startup logger: %w", err))
 	}
 
-	cfg, err := newConfig(startupLogger)
+	strictConfig := flag.Bool("strict-config", true, "fail to start if the YAML config contains unknown keys, "+
+		"otherwise unknown keys are logged as warnings")
+	flag.Parse()
+
+	cfg, err := newConfig(startupLogger, *strictConfig)
 	if err != nil {
 		startupLogger.Fatal("failed to parse config", zap.Error(err))
 	}
 
-	logger := logging.NewLogger(cfg.Logger, cfg.Exporter.Namespace)
+	// The metrics of the logger and the services are held back until the Kafka cluster id is known, so that they can be
+	// labelled with it like the exporter's metrics
+	serviceRegisterer := &deferredRegisterer{}
+	logger := logging.NewLogger(cfg.Logger, cfg.Exporter.Namespace, serviceRegisterer)
 	if err != nil {
 		startupLogger.Fatal("failed to create new logger", zap.Error(err))
 	}
@@ -53,21 +59,43 @@ func main() {
 		}
 	}()
 
+	// Start HTTP server right away, so that the health endpoints can be probed while connecting to Kafka. All other
+	// handlers are registered once the connection has been established.
+	mux := http.NewServeMux()
+	ready := &readiness{}
+	mux.HandleFunc("/healthy", ready.handleHealthy)
+	mux.HandleFunc("/ready", ready.handleReady)
+	address := net.JoinHostPort(cfg.Exporter.Host, strconv.Itoa(cfg.Exporter.Port))
+	logger.Info("listening on address", zap.String("listen_address", address))
+	server := &http.Server{
+		Addr:         address,
+		Handler:      mux,
+		ReadTimeout:  cfg.Exporter.HTTP.ReadTimeout,
+		WriteTimeout: cfg.Exporter.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.Exporter.HTTP.IdleTimeout,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			logger.Error("error starting HTTP server", zap.Error(err))
+			os.Exit(1)
+		}
+	}()
+
 	// Create kafka service and check if client can successfully connect to Kafka cluster
-	kafkaSvc, err := kafka.NewService(cfg.Kafka, logger)
+	kafkaSvc, err := kafka.NewService(cfg.Kafka, logger, cfg.Exporter.Namespace, serviceRegisterer)
 	if err != nil {
 		logger.Fatal("failed to setup kafka service", zap.Error(err))
 	}
-	connectCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-	err = kafkaSvc.TestConnection(connectCtx)
+	err = kafkaSvc.ConnectWithRetry(ctx)
 	if err != nil {
 		logger.Fatal("failed to test connectivity to Kafka cluster", zap.Error(err))
 	}
+	go kafkaSvc.StartBrokerDiscovery(ctx)
+	logStartupSummary(logger, cfg, len(kafkaSvc.SeedBrokers()))
 
 	// Create minion service that does most of the work. The Prometheus exporter only talks to the minion service
 	// which issues all the requests to Kafka and wraps the interface accordingly.
-	minionSvc, err := minion.NewService(cfg.Minion, logger, kafkaSvc)
+	minionSvc, err := minion.NewService(cfg.Minion, logger, kafkaSvc, cfg.Exporter.Namespace, serviceRegisterer)
 	if err != nil {
 		logger.Fatal("failed to setup minion service", zap.Error(err))
 	}
@@ -83,22 +111,62 @@ func main() {
 	}
 	exporter.InitializeMetrics()
 
-	promclient.MustRegister(exporter)
-	http.Handle("/metrics",
-		promhttp.InstrumentMetricHandler(
-			promclient.DefaultRegisterer,
-			promhttp.HandlerFor(
-				promclient.DefaultGatherer,
-				promhttp.HandlerOpts{},
-			),
+	// The default registry comes with the Go runtime and process collectors, which can be disabled
+	unregisterRuntimeCollectors(promclient.DefaultRegisterer, cfg.Exporter)
+
+	registerer := promclient.DefaultRegisterer
+	if cfg.Exporter.IncludeClusterID {
+		clusterID, err := minionSvc.GetClusterID(ctx)
+		if err != nil {
+			logger.Fatal("failed to get kafka cluster id", zap.Error(err))
+		}
+		if clusterID == "" {
+			if cfg.Exporter.ClusterName == "" {
+				logger.Fatal("kafka cluster does not report a cluster id and no cluster name is configured as fallback")
+			}
+			logger.Info("kafka cluster does not report a cluster id, using the configured cluster name instead",
+				zap.String("cluster_name", cfg.Exporter.ClusterName))
+			clusterID = cfg.Exporter.ClusterName
+		}
+		registerer = promclient.WrapRegistererWith(promclient.Labels{"kafka_cluster_id": clusterID}, registerer)
+	}
+	registerer.MustRegister(exporter)
+	err = serviceRegisterer.registerWith(registerer)
+	if err != nil {
+		logger.Fatal("failed to register service metrics", zap.Error(err))
+	}
+	scrapesInFlight := promclient.NewGauge(promclient.GaugeOpts{
+		Namespace: cfg.Exporter.Namespace,
+		Name:      "scrapes_in_flight",
+		Help:      "The number of scrapes that are currently being served",
+	})
+	registerer.MustRegister(scrapesInFlight)
+
+	mux.Handle("/metrics",
+		prometheus.LimitConcurrentScrapes(
+			newMetricsHandler(promclient.DefaultRegisterer, promclient.DefaultGatherer, cfg.Exporter),
+			cfg.Exporter.MaxConcurrentScrapes,
+			cfg.Exporter.ScrapeLimitMode,
+			scrapesInFlight,
 		),
 	)
 
-	// Start HTTP server
-	address := net.JoinHostPort(cfg.Exporter.Host, strconv.Itoa(cfg.Exporter.Port))
-	logger.Info("listening on address", zap.String("listen_address", address))
-	if err := http.ListenAndServe(address, nil); err != nil {
-		logger.Error("error starting HTTP server", zap.Error(err))
-		os.Exit(1)
+	registerPprofHandlers(mux, cfg.Exporter, logger)
+
+	if cfg.Exporter.Mode == prometheus.ExporterModePush {
+		go exporter.StartPushing(ctx, promclient.DefaultGatherer)
+	} else if cfg.Exporter.WarmupScrapes > 0 {
+		go exporter.WarmUp(ctx)
+	}
+
+	if cfg.Exporter.DebugScope {
+		logger.Info("scope debug endpoint is enabled and served under /debug/scope")
+		mux.HandleFunc("/debug/scope", minionSvc.HandleScope)
 	}
+
+	ready.setReady(exporter.IsWarmedUp)
+	logger.Info("kminion is ready to serve metrics")
+
+	// The HTTP server keeps serving until KMinion shuts down
+	<-ctx.Done()
 }
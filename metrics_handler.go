@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// newMetricsHandler returns the handler that serves the gathered metrics. The OpenMetrics format, which is the only
+// format that contains exemplars, is negotiated via the Accept header if it's enabled in the exporter config.
+func newMetricsHandler(registerer promclient.Registerer, gatherer promclient.Gatherer, cfg prometheus.Config) http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		registerer,
+		promhttp.HandlerFor(
+			gatherer,
+			promhttp.HandlerOpts{
+				EnableOpenMetrics:  cfg.OpenMetrics,
+				DisableCompression: !cfg.CompressResponses,
+			},
+		),
+	)
+}
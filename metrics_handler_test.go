@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandlerNegotiatesOpenMetrics(t *testing.T) {
+	tests := []struct {
+		name                string
+		openMetrics         bool
+		expectedContentType string
+		expectExemplar      bool
+	}{
+		{"disabled by default", false, "text/plain", false},
+		{"enabled", true, "application/openmetrics-text", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			registry := promclient.NewRegistry()
+			latency := promclient.NewHistogram(promclient.HistogramOpts{
+				Name:    "kminion_end_to_end_roundtrip_latency_seconds",
+				Help:    "The duration of successful roundtrips",
+				Buckets: []float64{0.05, 0.1},
+			})
+			registry.MustRegister(latency)
+			latency.(promclient.ExemplarObserver).ObserveWithExemplar(0.042, promclient.Labels{"roundtrip_id": "1602000000"})
+
+			cfg := prometheus.Config{OpenMetrics: test.openMetrics}
+			handler := newMetricsHandler(registry, registry, cfg)
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, test.expectedContentType) {
+				t.Errorf("expected content type %v, got %v", test.expectedContentType, contentType)
+			}
+			body, _ := ioutil.ReadAll(rec.Body)
+			hasExemplar := strings.Contains(string(body), `# {roundtrip_id="1602000000"} 0.042`)
+			if hasExemplar != test.expectExemplar {
+				t.Errorf("expected exemplar to be exposed: %v, got body:\n%s", test.expectExemplar, body)
+			}
+		})
+	}
+}
@@ -1,20 +1,43 @@
 package minion
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Config struct {
+	// Profile is a named preset of collector toggles and filters which is applied as base for the remaining config
+	Profile string `koanf:"profile"`
+
+	// CollectionInterval specifies how often the metrics are collected in the background, which is the case in push
+	// mode. In pull mode the metrics are collected whenever they are scraped.
+	CollectionInterval time.Duration `koanf:"collectionInterval"`
+
 	ConsumerGroups ConsumerGroupConfig `koanf:"consumerGroups"`
 	Topics         TopicConfig         `koanf:"topics"`
 	LogDirs        LogDirsConfig       `koanf:"logDirs"`
+	Metadata       MetadataConfig      `koanf:"metadata"`
+	IngestDelay    IngestDelayConfig   `koanf:"ingestDelay"`
+	BrokerConfigs  BrokerConfigsConfig `koanf:"brokerConfigs"`
+	Roundtrip      RoundtripConfig     `koanf:"roundtrip"`
+	Transactions   TransactionsConfig  `koanf:"transactions"`
 }
 
 func (c *Config) SetDefaults() {
+	c.CollectionInterval = 30 * time.Second
 	c.ConsumerGroups.SetDefaults()
 	c.Topics.SetDefaults()
 	c.LogDirs.SetDefaults()
+	c.Metadata.SetDefaults()
+	c.IngestDelay.SetDefaults()
+	c.Roundtrip.SetDefaults()
 }
 
 func (c *Config) Validate() error {
+	if c.CollectionInterval <= 0 {
+		return fmt.Errorf("collection interval must be a positive duration")
+	}
+
 	err := c.ConsumerGroups.Validate()
 	if err != nil {
 		return fmt.Errorf("failed to consumer group config: %w", err)
@@ -29,6 +52,34 @@ func (c *Config) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to validate log dirs config: %w", err)
 	}
+	if c.ConsumerGroups.IncludeLagBytes && !c.LogDirs.Enabled {
+		return fmt.Errorf("consumer group lags in bytes require log dirs to be enabled")
+	}
+
+	err = c.Metadata.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate metadata config: %w", err)
+	}
+
+	err = c.BrokerConfigs.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate broker configs config: %w", err)
+	}
+
+	err = c.IngestDelay.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate ingest delay config: %w", err)
+	}
+
+	err = c.Roundtrip.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate roundtrip config: %w", err)
+	}
+
+	err = c.Transactions.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate transactions config: %w", err)
+	}
 
 	return nil
 }
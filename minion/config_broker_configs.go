@@ -0,0 +1,21 @@
+package minion
+
+import "fmt"
+
+type BrokerConfigsConfig struct {
+	// AllowedConfigs are the names of the broker configs (e.g. "num.io.threads") that shall be exported. The config
+	// values are exported as label, hence only configs with a bounded number of values should be added. If empty, no
+	// broker configs are exported.
+	AllowedConfigs []string `koanf:"allowedConfigs"`
+}
+
+// Validate if provided BrokerConfigsConfig is valid.
+func (c *BrokerConfigsConfig) Validate() error {
+	for _, configName := range c.AllowedConfigs {
+		if configName == "" {
+			return fmt.Errorf("allowed broker config names must not be empty")
+		}
+	}
+
+	return nil
+}
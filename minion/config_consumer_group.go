@@ -2,6 +2,8 @@ package minion
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 const (
@@ -10,8 +12,15 @@ const (
 
 	ConsumerGroupGranularityTopic     string = "topic"
 	ConsumerGroupGranularityPartition string = "partition"
+
+	ConsumerGroupLagUnitOffset string = "offset"
+	ConsumerGroupLagUnitTime   string = "time"
 )
 
+// consumerGroupStates are all states a consumer group can be in, as reported by Kafka. "AwaitingSync" is reported by
+// brokers prior to Kafka v2.0 instead of "CompletingRebalance".
+var consumerGroupStates = []string{"Stable", "Empty", "Dead", "PreparingRebalance", "CompletingRebalance", "AwaitingSync"}
+
 type ConsumerGroupConfig struct {
 	// Enabled specifies whether consumer groups shall be scraped and exported or not.
 	Enabled bool `koanf:"enabled"`
@@ -20,6 +29,10 @@ type ConsumerGroupConfig struct {
 	// __consumer_offsets topic.
 	ScrapeMode string `koanf:"scrapeMode"`
 
+	// Interval specifies how often the consumer group metrics and lags are collected. In between, the previously
+	// collected metrics are exported. If set to 0 they are collected on every scrape.
+	Interval time.Duration `koanf:"interval"`
+
 	// Granularity can be per topic or per partition. If you want to reduce the number of exported metric series and
 	// you aren't interested in per partition lags you could choose "topic" where all partition lags will be summed
 	// and only topic lags will be exported.
@@ -31,13 +44,106 @@ type ConsumerGroupConfig struct {
 	// IgnoredGroups are regex strings of group ids that shall be ignored/skipped when exporting metrics. Ignored groups
 	// take precedence over allowed groups.
 	IgnoredGroupIDs []string `koanf:"ignoredGroups"`
+
+	// PrimaryLagUnit selects whether the lags are primarily exported as number of messages ("offset") or as estimated
+	// number of seconds ("time"). Lags in the primary unit are always exported.
+	PrimaryLagUnit string `koanf:"primaryLagUnit"`
+
+	// EmitSecondaryLag specifies whether the lags shall additionally be exported in the unit which is not the
+	// primary lag unit.
+	EmitSecondaryLag bool `koanf:"emitSecondaryLag"`
+
+	// EmitZeroLag specifies whether partition lag series shall also be exported for partitions with a lag of 0. If
+	// disabled, caught up partitions are only accounted for in the topic lags, which reduces the number of exported
+	// series on clusters where most partitions are caught up.
+	EmitZeroLag bool `koanf:"emitZeroLag"`
+
+	// LaggingPartitionThreshold is the lag above which a partition is counted as lagging partition of a group
+	LaggingPartitionThreshold int64 `koanf:"laggingPartitionThreshold"`
+
+	// AllowedGroupPrefixes are group id prefixes. If set, only groups whose id starts with one of the prefixes are
+	// exported. Unlike the allowed groups, groups that don't match are skipped right after listing the groups, so
+	// that they are never described and their offsets are never fetched.
+	AllowedGroupPrefixes []string `koanf:"allowedGroupPrefixes"`
+
+	// MaxConcurrentFetches is the maximum number of OffsetFetch and DescribeGroups requests that are in flight at the
+	// same time. Requests over the limit wait for a free slot. 0 means unlimited.
+	MaxConcurrentFetches int `koanf:"maxConcurrentFetches"`
+
+	// ListGroupsStatesFilter specifies whether IncludeStates shall be passed as states filter to the ListGroups
+	// request, so that groups in other states are not listed by the brokers at all. This also removes these groups
+	// from all other consumer group metrics. Brokers prior to Kafka v2.6 ignore the filter.
+	ListGroupsStatesFilter bool `koanf:"listGroupsStatesFilter"`
+
+	// ListGroups specifies whether the consumer groups shall be listed via ListGroups. If disabled, only the literal
+	// group ids configured in AllowedGroupIDs are monitored, which is required if the cluster doesn't support listing
+	// consumer groups.
+	ListGroups bool `koanf:"listGroups"`
+
+	// IncludeUncommittedPartitions specifies whether the lag shall also be exported for partitions which are assigned
+	// to a group member, but on which the group has not committed an offset yet. These lags are reported in a
+	// separate metric and are equal to the number of messages in the partition.
+	IncludeUncommittedPartitions bool `koanf:"includeUncommittedPartitions"`
+
+	// IncludeOffsetResets specifies whether the number of times the committed offset of a partition went backwards
+	// shall be exported per partition. Like the partition lags, partitions without any resets are only exported if
+	// EmitZeroLag is enabled.
+	IncludeOffsetResets bool `koanf:"includeOffsetResets"`
+
+	// IncludeLagBytes specifies whether the topic lags shall additionally be exported as an estimated number of bytes.
+	// The estimate is based on the average record size of each partition, derived from its log dir size and number of
+	// messages. It requires log dirs to be enabled.
+	IncludeLagBytes bool `koanf:"includeLagBytes"`
+
+	// PerMember specifies whether the topic lags shall additionally be exported per client id and host of the group
+	// members the partitions are assigned to. This can largely increase the number of exported series.
+	PerMember bool `koanf:"perMember"`
+
+	// RequireTopics are regex strings of topic names. If set, lags are only exported for groups which have committed
+	// offsets on at least one of the matching topics.
+	RequireTopics []string `koanf:"requireTopics"`
+
+	// IncludeStates are the consumer group states for which lags shall be exported. If empty, lags are exported for
+	// groups in any state.
+	IncludeStates []string `koanf:"includeStates"`
+
+	// ExpiredOffsetsGracePeriod is the duration for which the lags of a group are still reported based on its
+	// last-known offsets after Kafka has expired the group's offsets, while the group itself still exists. If set to 0
+	// the lags vanish as soon as the offsets have expired.
+	ExpiredOffsetsGracePeriod time.Duration `koanf:"expiredOffsetsGracePeriod"`
+
+	// StaleGroupAge is the age of the most recent offset commit after which a group without members is considered
+	// stale. If set to 0 stale groups are not detected.
+	StaleGroupAge time.Duration `koanf:"staleGroupAge"`
+
+	// Smoothing configures whether smoothed lags shall be exported in addition to the raw lags.
+	Smoothing ConsumerGroupSmoothingConfig `koanf:"smoothing"`
+}
+
+type ConsumerGroupSmoothingConfig struct {
+	// Samples is the number of recent scrapes whose lags are averaged in order to calculate the smoothed lag. If set
+	// to 0 no smoothed lags are exported.
+	Samples int `koanf:"samples"`
 }
 
 func (c *ConsumerGroupConfig) SetDefaults() {
 	c.Enabled = true
 	c.ScrapeMode = ConsumerGroupScrapeModeAdminAPI
 	c.Granularity = ConsumerGroupGranularityPartition
+	c.PrimaryLagUnit = ConsumerGroupLagUnitOffset
+	c.EmitZeroLag = true
 	c.AllowedGroupIDs = []string{"/.*/"}
+	c.ListGroups = true
+}
+
+// ExplicitGroupIDs returns the allowed group ids if all of them are literal group ids
+func (c *ConsumerGroupConfig) ExplicitGroupIDs() ([]string, bool) {
+	return literalGroupIDs(c.AllowedGroupIDs)
+}
+
+// IsLagUnitExported returns whether lags shall be exported in the given unit
+func (c *ConsumerGroupConfig) IsLagUnitExported(unit string) bool {
+	return c.PrimaryLagUnit == unit || c.EmitSecondaryLag
 }
 
 func (c *ConsumerGroupConfig) Validate() error {
@@ -59,6 +165,76 @@ func (c *ConsumerGroupConfig) Validate() error {
 			ConsumerGroupGranularityPartition)
 	}
 
+	switch c.PrimaryLagUnit {
+	case ConsumerGroupLagUnitOffset, ConsumerGroupLagUnitTime:
+	default:
+		return fmt.Errorf("invalid primary lag unit '%v' specified. Valid units are '%v' or '%v'",
+			c.PrimaryLagUnit,
+			ConsumerGroupLagUnitOffset,
+			ConsumerGroupLagUnitTime)
+	}
+
+	for _, topic := range c.RequireTopics {
+		_, err := compileRegex(topic)
+		if err != nil {
+			return fmt.Errorf("required topic string '%v' is not valid regex", topic)
+		}
+	}
+
+	for _, state := range c.IncludeStates {
+		isValid := false
+		for _, knownState := range consumerGroupStates {
+			if strings.EqualFold(state, knownState) {
+				isValid = true
+				break
+			}
+		}
+		if !isValid {
+			return fmt.Errorf("invalid consumer group state '%v' specified. Valid states are: %v",
+				state,
+				strings.Join(consumerGroupStates, ", "))
+		}
+	}
+
+	if c.LaggingPartitionThreshold < 0 {
+		return fmt.Errorf("lagging partition threshold must not be negative")
+	}
+
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+
+	if c.MaxConcurrentFetches < 0 {
+		return fmt.Errorf("max concurrent fetches must not be negative")
+	}
+
+	if c.ListGroupsStatesFilter && len(c.IncludeStates) == 0 {
+		return fmt.Errorf("the list groups states filter requires the included states to be configured")
+	}
+
+	if _, isExplicit := c.ExplicitGroupIDs(); c.Enabled && !c.ListGroups && !isExplicit {
+		return fmt.Errorf("consumer groups can only be monitored without listing them if the allowed groups are " +
+			"literal group ids")
+	}
+
+	for _, prefix := range c.AllowedGroupPrefixes {
+		if prefix == "" {
+			return fmt.Errorf("allowed group prefixes must not be empty")
+		}
+	}
+
+	if c.ExpiredOffsetsGracePeriod < 0 {
+		return fmt.Errorf("expired offsets grace period must not be negative")
+	}
+
+	if c.StaleGroupAge < 0 {
+		return fmt.Errorf("stale group age must not be negative")
+	}
+
+	if c.Smoothing.Samples < 0 {
+		return fmt.Errorf("number of smoothing samples must not be negative")
+	}
+
 	// Check if all group strings are valid regex or literals
 	for _, groupID := range c.AllowedGroupIDs {
 		_, err := compileRegex(groupID)
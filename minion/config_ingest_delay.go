@@ -0,0 +1,50 @@
+package minion
+
+import "fmt"
+
+const (
+	TimestampTypeCreateTime    string = "CreateTime"
+	TimestampTypeLogAppendTime string = "LogAppendTime"
+)
+
+type IngestDelayConfig struct {
+	// Enabled specifies whether the ingest delay of existing topics shall be measured. KMinion only consumes these
+	// topics and never produces to them.
+	Enabled bool `koanf:"enabled"`
+
+	// Topics whose records shall be consumed to measure the delay between the record timestamp and the time KMinion
+	// reads them.
+	Topics []string `koanf:"topics"`
+
+	// TimestampType specifies which record timestamps are used. Records with a different timestamp type are skipped.
+	// CreateTime is set by the producer, whereas LogAppendTime is set by the broker if the topic is configured so.
+	TimestampType string `koanf:"timestampType"`
+}
+
+// Validate if provided IngestDelayConfig is valid.
+func (c *IngestDelayConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if len(c.Topics) == 0 {
+		return fmt.Errorf("ingest delay is enabled, but no topics are configured")
+	}
+
+	switch c.TimestampType {
+	case TimestampTypeCreateTime, TimestampTypeLogAppendTime:
+	default:
+		return fmt.Errorf("invalid timestamp type '%v' specified. Valid types are '%v' or '%v'",
+			c.TimestampType,
+			TimestampTypeCreateTime,
+			TimestampTypeLogAppendTime)
+	}
+
+	return nil
+}
+
+// SetDefaults for ingest delay config
+func (c *IngestDelayConfig) SetDefaults() {
+	c.Enabled = false
+	c.TimestampType = TimestampTypeCreateTime
+}
@@ -1,13 +1,26 @@
 package minion
 
+import (
+	"fmt"
+	"time"
+)
+
 type LogDirsConfig struct {
 	// Enabled specifies whether log dirs shall be scraped and exported or not. This should be disabled for clusters prior
 	// to version 1.0.0 as describing log dirs was not supported back then.
 	Enabled bool `koanf:"enabled"`
+
+	// Interval specifies how often the log dirs are described. In between, the previously collected log dir metrics
+	// are exported. If set to 0 the log dirs are described on every scrape.
+	Interval time.Duration `koanf:"interval"`
 }
 
 // Validate if provided LogDirsConfig is valid.
 func (c *LogDirsConfig) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+
 	return nil
 }
 
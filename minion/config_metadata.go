@@ -0,0 +1,27 @@
+package minion
+
+import (
+	"fmt"
+	"time"
+)
+
+type MetadataConfig struct {
+	// RefreshInterval specifies how long the fetched cluster metadata (brokers, topics and partitions) shall be
+	// reused across scrapes. Watermarks and group offsets are still fetched on each scrape. If set to 0 the metadata
+	// is fetched on each scrape.
+	RefreshInterval time.Duration `koanf:"refreshInterval"`
+}
+
+// Validate if provided MetadataConfig is valid.
+func (c *MetadataConfig) Validate() error {
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("metadata refresh interval must not be negative")
+	}
+
+	return nil
+}
+
+// SetDefaults for metadata config
+func (c *MetadataConfig) SetDefaults() {
+	c.RefreshInterval = 0
+}
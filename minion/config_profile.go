@@ -0,0 +1,47 @@
+package minion
+
+import "fmt"
+
+const (
+	// ProfileLagOnly only exports consumer group lags, topic metrics and log dirs are disabled
+	ProfileLagOnly string = "lag-only"
+	// ProfileTopics only exports topic metrics (including log dirs), consumer groups are disabled
+	ProfileTopics string = "topics"
+	// ProfileFull enables all collectors, including the optional ones
+	ProfileFull string = "full"
+)
+
+// ApplyProfile applies the collector toggles and filters of the given profile. Profiles are applied on top of the
+// defaults, but before the user provided config is unmarshalled, so that explicitly configured options still take
+// precedence. An empty profile name leaves the config untouched.
+func (c *Config) ApplyProfile(profile string) error {
+	switch profile {
+	case "":
+	case ProfileLagOnly:
+		c.ConsumerGroups.Enabled = true
+		c.Topics.Enabled = false
+		c.Topics.IncludeAge = false
+		c.LogDirs.Enabled = false
+	case ProfileTopics:
+		c.ConsumerGroups.Enabled = false
+		c.Topics.Enabled = true
+		c.Topics.AllowedTopics = []string{"/.*/"}
+		c.Topics.IncludeAge = true
+		c.LogDirs.Enabled = true
+	case ProfileFull:
+		c.ConsumerGroups.Enabled = true
+		c.ConsumerGroups.IncludeUncommittedPartitions = true
+		c.Topics.Enabled = true
+		c.Topics.AllowedTopics = []string{"/.*/"}
+		c.Topics.IncludeAge = true
+		c.LogDirs.Enabled = true
+	default:
+		return fmt.Errorf("invalid profile '%v' specified. Valid profiles are '%v', '%v' or '%v'",
+			profile,
+			ProfileLagOnly,
+			ProfileTopics,
+			ProfileFull)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package minion
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RoundtripPartitionerDefault    string = "default"
+	RoundtripPartitionerRoundRobin string = "roundRobin"
+)
+
+type RoundtripConfig struct {
+	// Enabled specifies whether KMinion shall periodically produce a single record to the roundtrip topic and consume
+	// it again, in order to check whether producing and consuming works right now.
+	Enabled bool `koanf:"enabled"`
+
+	// Topic is the existing topic the roundtrip records are produced to. KMinion does not create this topic.
+	Topic string `koanf:"topic"`
+
+	// Interval specifies how often a roundtrip is started
+	Interval time.Duration `koanf:"interval"`
+
+	// Timeout is the maximum duration for producing and consuming the record, after which the roundtrip fails
+	Timeout time.Duration `koanf:"timeout"`
+
+	// SLA is the duration within which a roundtrip is expected to complete. Roundtrips that take longer or fail are
+	// counted as over SLA, so that error budget burn rates can be calculated.
+	SLA time.Duration `koanf:"sla"`
+
+	// Partitioner is either "default" or "roundRobin". The default partitioner hashes the record key, which differs
+	// for each roundtrip. Round robin produces each roundtrip to the next partition, so that all partitions are checked
+	// in a fixed order.
+	Partitioner string `koanf:"partitioner"`
+
+	// Partitions are the partition ids the round robin partitioner cycles through. If empty, all partitions of the
+	// topic are used.
+	Partitions []int32 `koanf:"partitions"`
+}
+
+// Validate if provided RoundtripConfig is valid.
+func (c *RoundtripConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Topic == "" {
+		return fmt.Errorf("roundtrip is enabled, but no topic is configured")
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if c.Timeout > c.Interval {
+		return fmt.Errorf("timeout must not be greater than the interval")
+	}
+	if c.SLA <= 0 {
+		return fmt.Errorf("sla must be greater than 0")
+	}
+	if c.SLA > c.Timeout {
+		return fmt.Errorf("sla must not be greater than the timeout")
+	}
+
+	switch c.Partitioner {
+	case RoundtripPartitionerDefault, RoundtripPartitionerRoundRobin:
+	default:
+		return fmt.Errorf("given partitioner '%v' is invalid", c.Partitioner)
+	}
+	for _, partitionID := range c.Partitions {
+		if partitionID < 0 {
+			return fmt.Errorf("partition ids must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// SetDefaults for roundtrip config
+func (c *RoundtripConfig) SetDefaults() {
+	c.Enabled = false
+	c.Interval = 30 * time.Second
+	c.Timeout = 10 * time.Second
+	c.SLA = 5 * time.Second
+	c.Partitioner = RoundtripPartitionerDefault
+}
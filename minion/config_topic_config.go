@@ -1,6 +1,9 @@
 package minion
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 const (
 	TopicGranularityTopic     string = "topic"
@@ -8,16 +11,47 @@ const (
 )
 
 type TopicConfig struct {
+	// Enabled specifies whether topic metrics (topic and partition info, water marks and topic age) shall be
+	// exported. Consumer group lags and cluster metrics are exported regardless of this setting.
+	Enabled bool `koanf:"enabled"`
+
 	// Granularity can be per topic or per partition. If you want to reduce the number of exported metric series and
 	// you aren't interested in per partition metrics you could choose "topic".
 	Granularity string `koanf:"granularity"`
 
-	// AllowedTopics are regex strings of topic names whose topic metrics that shall be exported.
+	// AllowedTopics are regex strings of topic names whose topic metrics that shall be exported. Each string may be
+	// suffixed with a partition selection (e.g. "orders:0-3,7"), so that watermarks and lags are only collected for
+	// these partitions.
 	AllowedTopics []string `koanf:"allowedTopics"`
 
+	// FailOnMissingTopics makes KMinion fail at startup if one of the allowed topics that are given by their literal
+	// name (rather than a regex) does not exist. Otherwise missing topics are only logged and exported.
+	FailOnMissingTopics bool `koanf:"failOnMissingTopics"`
+
 	// IgnoredTopics are regex strings of topic names that shall be ignored/skipped when exporting metrics. Ignored topics
 	// take precedence over allowed topics.
 	IgnoredTopics []string `koanf:"ignoredTopics"`
+
+	// Overrides change the scrape settings for specific topics. If multiple overrides match a topic, the first one
+	// is used.
+	Overrides []TopicOverrideConfig `koanf:"overrides"`
+
+	// IncludeAge specifies whether the topic age shall be exported. The age is derived from the timestamp of the
+	// oldest message and therefore only exported for non-compacted topics which have not deleted any data yet.
+	IncludeAge bool `koanf:"includeAge"`
+
+	// MinReplicationFactor is the replication factor topics are expected to have at least. Topics with a lower
+	// replication factor are flagged. If set to 0 the replication factor is not checked.
+	MinReplicationFactor int `koanf:"minReplicationFactor"`
+
+	// CreatedAfter is a RFC3339 timestamp. If set, only topics created after this timestamp are monitored. As Kafka
+	// does not expose the creation time of topics, it's derived from the timestamp of the oldest message. Topics
+	// whose creation time can't be derived this way (e.g. compacted topics) are monitored regardless of their age.
+	CreatedAfter string `koanf:"createdAfter"`
+
+	// PartitionCountPolicy are rules the partition count of each topic is expected to follow. Topics breaking any of
+	// these rules are flagged.
+	PartitionCountPolicy TopicPartitionCountPolicyConfig `koanf:"partitionCountPolicy"`
 }
 
 // Validate if provided TopicConfig is valid.
@@ -28,9 +62,36 @@ func (c *TopicConfig) Validate() error {
 		return fmt.Errorf("given granularity '%v' is invalid", c.Granularity)
 	}
 
+	if c.MinReplicationFactor < 0 {
+		return fmt.Errorf("min replication factor must not be negative")
+	}
+
+	if c.CreatedAfter != "" {
+		_, err := time.Parse(time.RFC3339, c.CreatedAfter)
+		if err != nil {
+			return fmt.Errorf("created after '%v' is not a valid RFC3339 timestamp: %w", c.CreatedAfter, err)
+		}
+	}
+
+	err := c.PartitionCountPolicy.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate partition count policy: %w", err)
+	}
+
+	for i, override := range c.Overrides {
+		err := override.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate topic override at index %d: %w", i, err)
+		}
+	}
+
 	// Check whether each provided string is valid regex
 	for _, topic := range c.AllowedTopics {
-		_, err := compileRegex(topic)
+		topicExpr, _, err := splitTopicPartitionFilter(topic)
+		if err != nil {
+			return fmt.Errorf("allowed topic string '%v' has an invalid partition selection: %w", topic, err)
+		}
+		_, err = compileRegex(topicExpr)
 		if err != nil {
 			return fmt.Errorf("allowed topic string '%v' is not valid regex", topic)
 		}
@@ -48,6 +109,7 @@ func (c *TopicConfig) Validate() error {
 
 // SetDefaults for topic config
 func (c *TopicConfig) SetDefaults() {
+	c.Enabled = true
 	c.Granularity = TopicGranularityPartition
 	c.AllowedTopics = []string{"/.*/"}
 }
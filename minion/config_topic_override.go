@@ -0,0 +1,36 @@
+package minion
+
+import "fmt"
+
+// TopicOverrideConfig overrides the scrape settings for all topics whose name matches the given expression
+type TopicOverrideConfig struct {
+	// Match is a regex string (or literal topic name) of the topics this override applies to
+	Match string `koanf:"match"`
+
+	// Granularity can be per topic or per partition. It takes precedence over the topic granularity as well as the
+	// consumer group granularity for the matching topics. If empty, the configured granularities are used.
+	Granularity string `koanf:"granularity"`
+
+	// CollectConfig specifies whether the topic configs (e.g. the cleanup policy) of the matching topics shall be
+	// described. Defaults to true if not set.
+	CollectConfig *bool `koanf:"collectConfig"`
+}
+
+// Validate if provided TopicOverrideConfig is valid.
+func (c *TopicOverrideConfig) Validate() error {
+	if c.Match == "" {
+		return fmt.Errorf("match must be set")
+	}
+	_, err := compileRegex(c.Match)
+	if err != nil {
+		return fmt.Errorf("match string '%v' is not valid regex", c.Match)
+	}
+
+	switch c.Granularity {
+	case "", TopicGranularityPartition, TopicGranularityTopic:
+	default:
+		return fmt.Errorf("given granularity '%v' is invalid", c.Granularity)
+	}
+
+	return nil
+}
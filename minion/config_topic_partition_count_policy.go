@@ -0,0 +1,51 @@
+package minion
+
+import "fmt"
+
+// TopicPartitionCountPolicyConfig describes the rules the partition count of each topic is expected to follow. Topics
+// breaking any of the rules are reported by kminion_kafka_topic_partition_count_policy_violation.
+type TopicPartitionCountPolicyConfig struct {
+	// Min is the lowest partition count a topic is expected to have. If set to 0 the minimum is not checked.
+	Min int `koanf:"min"`
+
+	// MultipleOf requires the partition count to be a multiple of the given number. If set to 0 it's not checked.
+	MultipleOf int `koanf:"multipleOf"`
+
+	// PowerOfTwo requires the partition count to be a power of two.
+	PowerOfTwo bool `koanf:"powerOfTwo"`
+}
+
+// Validate if provided TopicPartitionCountPolicyConfig is valid.
+func (c *TopicPartitionCountPolicyConfig) Validate() error {
+	if c.Min < 0 {
+		return fmt.Errorf("min must not be negative")
+	}
+
+	if c.MultipleOf < 0 {
+		return fmt.Errorf("multipleOf must not be negative")
+	}
+
+	return nil
+}
+
+// IsEnabled returns whether any partition count rule has been configured.
+func (c *TopicPartitionCountPolicyConfig) IsEnabled() bool {
+	return c.Min > 0 || c.MultipleOf > 0 || c.PowerOfTwo
+}
+
+// IsViolatedBy returns whether the given partition count breaks at least one of the configured rules.
+func (c *TopicPartitionCountPolicyConfig) IsViolatedBy(partitionCount int) bool {
+	if c.Min > 0 && partitionCount < c.Min {
+		return true
+	}
+
+	if c.MultipleOf > 0 && partitionCount%c.MultipleOf != 0 {
+		return true
+	}
+
+	if c.PowerOfTwo && (partitionCount <= 0 || partitionCount&(partitionCount-1) != 0) {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,12 @@
+package minion
+
+type TransactionsConfig struct {
+	// Enabled specifies whether the __transaction_state topic shall be consumed, in order to export the age of the
+	// oldest open transaction. This requires permissions to read the internal topic.
+	Enabled bool `koanf:"enabled"`
+}
+
+// Validate if provided TransactionsConfig is valid.
+func (c *TransactionsConfig) Validate() error {
+	return nil
+}
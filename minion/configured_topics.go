@@ -0,0 +1,86 @@
+package minion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkConfiguredTopics verifies that all topics which are allowed by their literal name (rather than a regex) exist.
+// Missing topics are logged and remembered, so that they can be exported. An error is only returned if KMinion shall
+// fail on missing topics.
+func (s *Service) checkConfiguredTopics(ctx context.Context) error {
+	configuredTopics := s.literalAllowedTopics()
+	if len(configuredTopics) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req := kmsg.NewMetadataRequest()
+	req.Topics = nil
+	res, err := req.RequestWith(ctx, s.kafkaSvc)
+	if err != nil {
+		s.logger.Warn("failed to check whether the configured topics exist", zap.Error(err))
+		return nil
+	}
+
+	missingTopics := findMissingTopics(configuredTopics, res)
+	s.missingConfiguredTopics = missingTopics
+	if len(missingTopics) == 0 {
+		return nil
+	}
+
+	if s.Cfg.Topics.FailOnMissingTopics {
+		return fmt.Errorf("the following configured topics do not exist: %v", strings.Join(missingTopics, ", "))
+	}
+	s.logger.Warn("some of the configured topics do not exist, no metrics will be exported for them",
+		zap.Strings("missing_topics", missingTopics))
+	return nil
+}
+
+// findMissingTopics returns the sorted names of all configured topics that are not part of the metadata response
+func findMissingTopics(configuredTopics []string, metadata *kmsg.MetadataResponse) []string {
+	existingTopics := make(map[string]struct{}, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
+		// Topics we are not authorized to describe may still exist
+		if err := kerr.ErrorForCode(topic.ErrorCode); err == nil || errors.Is(err, kerr.TopicAuthorizationFailed) {
+			existingTopics[topic.Topic] = struct{}{}
+		}
+	}
+
+	missing := make([]string, 0)
+	for _, topicName := range configuredTopics {
+		if _, exists := existingTopics[topicName]; !exists {
+			missing = append(missing, topicName)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// literalAllowedTopics returns the topic names of all allowed topics expressions that are not regex
+func (s *Service) literalAllowedTopics() []string {
+	topicNames := make([]string, 0)
+	for _, allowedTopic := range s.Cfg.Topics.AllowedTopics {
+		// The expression has already been validated
+		topicExpr, _, _ := splitTopicPartitionFilter(allowedTopic)
+		if strings.HasPrefix(topicExpr, "/") && strings.HasSuffix(topicExpr, "/") {
+			continue
+		}
+		topicNames = append(topicNames, topicExpr)
+	}
+	return topicNames
+}
+
+// GetMissingConfiguredTopics returns the configured topic names that did not exist at startup.
+func (s *Service) GetMissingConfiguredTopics() []string {
+	return s.missingConfiguredTopics
+}
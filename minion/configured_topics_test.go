@@ -0,0 +1,48 @@
+package minion
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"reflect"
+	"testing"
+)
+
+func TestLiteralAllowedTopics(t *testing.T) {
+	svc := &Service{Cfg: Config{Topics: TopicConfig{
+		AllowedTopics: []string{"orders", "/payments-.*/", "clickstream:0-3,7", "/audit/:1"},
+	}}}
+
+	expected := []string{"orders", "clickstream"}
+	if topicNames := svc.literalAllowedTopics(); !reflect.DeepEqual(topicNames, expected) {
+		t.Errorf("expected literal topics %v, got %v", expected, topicNames)
+	}
+}
+
+func TestFindMissingTopics(t *testing.T) {
+	metadata := &kmsg.MetadataResponse{
+		Topics: []kmsg.MetadataResponseTopic{
+			{Topic: "orders"},
+			{Topic: "secret", ErrorCode: kerr.TopicAuthorizationFailed.Code},
+			{Topic: "deleted", ErrorCode: kerr.UnknownTopicOrPartition.Code},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		configuredTopics []string
+		expected         []string
+	}{
+		{"all topics exist", []string{"orders"}, []string{}},
+		{"unauthorized topics count as existing", []string{"orders", "secret"}, []string{}},
+		{"missing topics are sorted", []string{"orders", "zebra", "deleted", "archive"},
+			[]string{"archive", "deleted", "zebra"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			missing := findMissingTopics(test.configuredTopics, metadata)
+			if !reflect.DeepEqual(missing, test.expected) {
+				t.Errorf("expected missing topics %v, got %v", test.expected, missing)
+			}
+		})
+	}
+}
@@ -15,16 +15,19 @@ func (s *Service) ListAllConsumerGroupOffsetsInternal() map[string]map[string]ma
 	return s.storage.getGroupOffsets()
 }
 
+// ListConsumerGroupGenerationsInternal returns the latest generation id of each consumer group, indexed by group id.
+// Generations are only known when consuming the __consumer_offsets topic, as DescribeGroups does not report them.
+func (s *Service) ListConsumerGroupGenerationsInternal() map[string]int32 {
+	return s.storage.getGroupGenerations()
+}
+
 // ListAllConsumerGroupOffsetsAdminAPI return all consumer group offsets using Kafka's Admin API.
 func (s *Service) ListAllConsumerGroupOffsetsAdminAPI(ctx context.Context) (map[string]*kmsg.OffsetFetchResponse, error) {
 	groupsRes, err := s.listConsumerGroups(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list groupsRes: %w", err)
 	}
-	groupIDs := make([]string, len(groupsRes.Groups))
-	for i, group := range groupsRes.Groups {
-		groupIDs[i] = group.Group
-	}
+	groupIDs := s.allowedGroupIDs(groupsRes)
 
 	return s.listConsumerGroupOffsetsBulk(ctx, groupIDs)
 }
@@ -66,10 +69,16 @@ func (s *Service) listConsumerGroupOffsetsBulk(ctx context.Context, groups []str
 
 // listConsumerGroupOffsets returns the committed group offsets for a single group
 func (s *Service) listConsumerGroupOffsets(ctx context.Context, group string) (*kmsg.OffsetFetchResponse, error) {
+	release, err := s.groupRequestLimiter.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for a free request slot for group '%v': %w", group, err)
+	}
+	defer release()
+
 	req := kmsg.NewOffsetFetchRequest()
 	req.Group = group
 	req.Topics = nil
-	res, err := req.RequestWith(ctx, s.kafkaSvc.Client)
+	res, err := req.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request group offsets for group '%v': %w", group, err)
 	}
@@ -0,0 +1,76 @@
+package minion
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"strconv"
+	"time"
+)
+
+type BrokerConfigsResponseShard struct {
+	Err     error
+	Broker  kgo.BrokerMetadata
+	Configs *kmsg.DescribeConfigsResponse
+}
+
+func (s *Service) DescribeBrokerConfigsCached(ctx context.Context) ([]BrokerConfigsResponseShard, error) {
+	reqId := ctx.Value("requestId").(string)
+	key := "broker-configs-" + reqId
+
+	if cachedRes, exists := s.getCachedItem(key); exists {
+		return cachedRes.([]BrokerConfigsResponseShard), nil
+	}
+
+	res, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		brokerConfigs, err := s.DescribeBrokerConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.setCachedItem(key, brokerConfigs, 120*time.Second)
+
+		return brokerConfigs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]BrokerConfigsResponseShard), nil
+}
+
+// DescribeBrokerConfigs describes the allowed broker configs of all brokers. The request is sharded, so that each
+// broker is asked for its own configs and brokers may fail individually.
+func (s *Service) DescribeBrokerConfigs(ctx context.Context) ([]BrokerConfigsResponseShard, error) {
+	metadata, err := s.GetMetadataCached(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	req := kmsg.NewDescribeConfigsRequest()
+	for _, broker := range metadata.Brokers {
+		resourceReq := kmsg.NewDescribeConfigsRequestResource()
+		resourceReq.ResourceType = kmsg.ConfigResourceTypeBroker
+		resourceReq.ResourceName = strconv.Itoa(int(broker.NodeID))
+		resourceReq.ConfigNames = s.Cfg.BrokerConfigs.AllowedConfigs
+		req.Resources = append(req.Resources, resourceReq)
+	}
+	responses := s.kafkaSvc.RequestSharded(ctx, &req)
+
+	res := make([]BrokerConfigsResponseShard, len(responses))
+	for i, responseShard := range responses {
+		configs, ok := responseShard.Resp.(*kmsg.DescribeConfigsResponse)
+		if !ok {
+			configs = &kmsg.DescribeConfigsResponse{}
+		}
+
+		res[i] = BrokerConfigsResponseShard{
+			Err:     responseShard.Err,
+			Broker:  responseShard.Meta,
+			Configs: configs,
+		}
+	}
+
+	return res, nil
+}
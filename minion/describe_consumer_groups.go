@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-func (s *Service) listConsumerGroupsCached(ctx context.Context) (*kmsg.ListGroupsResponse, error) {
+func (s *Service) ListConsumerGroupsCached(ctx context.Context) (*kmsg.ListGroupsResponse, error) {
 	reqId := ctx.Value("requestId").(string)
 	key := "list-consumer-groups-" + reqId
 
@@ -32,8 +32,15 @@ func (s *Service) listConsumerGroupsCached(ctx context.Context) (*kmsg.ListGroup
 }
 
 func (s *Service) listConsumerGroups(ctx context.Context) (*kmsg.ListGroupsResponse, error) {
+	if !s.Cfg.ConsumerGroups.ListGroups {
+		return s.explicitConsumerGroups(), nil
+	}
+
 	listReq := kmsg.NewListGroupsRequest()
-	res, err := listReq.RequestWith(ctx, s.kafkaSvc.Client)
+	if s.Cfg.ConsumerGroups.ListGroupsStatesFilter {
+		listReq.StatesFilter = s.Cfg.ConsumerGroups.IncludeStates
+	}
+	res, err := listReq.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
 	}
@@ -45,23 +52,98 @@ func (s *Service) listConsumerGroups(ctx context.Context) (*kmsg.ListGroupsRespo
 	return res, nil
 }
 
+// explicitConsumerGroups returns the explicitly allowed groups in the form of a list groups response, so that groups
+// can be monitored on clusters which don't support listing them. Groups that don't exist have no offsets.
+func (s *Service) explicitConsumerGroups() *kmsg.ListGroupsResponse {
+	groupIDs, _ := s.Cfg.ConsumerGroups.ExplicitGroupIDs()
+	res := kmsg.NewPtrListGroupsResponse()
+	for _, groupID := range groupIDs {
+		group := kmsg.NewListGroupsResponseGroup()
+		group.Group = groupID
+		res.Groups = append(res.Groups, group)
+	}
+
+	return res
+}
+
+func (s *Service) DescribeConsumerGroupsCached(ctx context.Context) (*kmsg.DescribeGroupsResponse, error) {
+	reqId := ctx.Value("requestId").(string)
+	key := "describe-consumer-groups-" + reqId
+
+	if cachedRes, exists := s.getCachedItem(key); exists {
+		return cachedRes.(*kmsg.DescribeGroupsResponse), nil
+	}
+	res, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		res, err := s.DescribeConsumerGroups(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.setCachedItem(key, res, 120*time.Second)
+
+		return res, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*kmsg.DescribeGroupsResponse), nil
+}
+
 func (s *Service) DescribeConsumerGroups(ctx context.Context) (*kmsg.DescribeGroupsResponse, error) {
-	listRes, err := s.listConsumerGroupsCached(ctx)
+	listRes, err := s.ListConsumerGroupsCached(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	groupIDs := make([]string, len(listRes.Groups))
-	for i, group := range listRes.Groups {
-		groupIDs[i] = group.Group
+	// Only describe the groups that are exported, describing all groups is expensive on clusters with many groups
+	groupIDs := s.allowedGroupIDs(listRes)
+	if len(groupIDs) == 0 {
+		return &kmsg.DescribeGroupsResponse{}, nil
 	}
 
+	release, err := s.groupRequestLimiter.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for a free request slot: %w", err)
+	}
+	defer release()
+
 	describeReq := kmsg.NewDescribeGroupsRequest()
 	describeReq.Groups = groupIDs
-	describeRes, err := describeReq.RequestWith(ctx, s.kafkaSvc.Client)
+	describeRes, err := describeReq.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
 	}
 
 	return describeRes, err
 }
+
+// allowedGroupIDs returns the ids of all listed groups which are allowed to be exported
+func (s *Service) allowedGroupIDs(listRes *kmsg.ListGroupsResponse) []string {
+	groupIDs := make([]string, 0, len(listRes.Groups))
+	for _, group := range listRes.Groups {
+		if s.IsGroupAllowed(group.Group) {
+			groupIDs = append(groupIDs, group.Group)
+		}
+	}
+	return groupIDs
+}
+
+// DecodeMemberAssignment decodes the partition assignment of a group member. Only groups using the "consumer" protocol
+// type are known to use the standard assignment format, for all other groups an error is returned.
+func DecodeMemberAssignment(protocolType string, member kmsg.DescribeGroupsResponseGroupMember) (*kmsg.GroupMemberAssignment, error) {
+	if protocolType != "consumer" {
+		return nil, fmt.Errorf("unsupported protocol type '%v'", protocolType)
+	}
+
+	assignment := kmsg.NewGroupMemberAssignment()
+	if len(member.MemberAssignment) == 0 {
+		// Members do not have an assignment while the group is rebalancing
+		return &assignment, nil
+	}
+	err := assignment.ReadFrom(member.MemberAssignment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode member assignment: %w", err)
+	}
+
+	return &assignment, nil
+}
@@ -0,0 +1,37 @@
+package minion
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"reflect"
+	"testing"
+)
+
+func TestListConsumerGroupsWithoutListGroups(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.ConsumerGroups.ListGroups = false
+	cfg.ConsumerGroups.AllowedGroupIDs = []string{"orders-consumer", "bigquery-sink"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a valid config, got: %v", err)
+	}
+	// The service isn't connected to any cluster, so that a ListGroups request would fail
+	svc, err := NewService(cfg, zap.NewNop(), nil, "kminion", prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	res, err := svc.listConsumerGroups(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list consumer groups: %v", err)
+	}
+	if groupIDs := svc.allowedGroupIDs(res); !reflect.DeepEqual(groupIDs, cfg.ConsumerGroups.AllowedGroupIDs) {
+		t.Errorf("expected the explicitly allowed groups, got %v", groupIDs)
+	}
+
+	cfg.ConsumerGroups.AllowedGroupIDs = []string{"orders-consumer", "/bigquery-.*/"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("expected regex groups to be rejected if consumer groups are not listed")
+	}
+}
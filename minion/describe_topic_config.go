@@ -5,8 +5,34 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/twmb/franz-go/pkg/kmsg"
+	"time"
 )
 
+func (s *Service) GetTopicConfigsCached(ctx context.Context) (*kmsg.DescribeConfigsResponse, error) {
+	reqId := ctx.Value("requestId").(string)
+	key := "topic-configs-" + reqId
+
+	if cachedRes, exists := s.getCachedItem(key); exists {
+		return cachedRes.(*kmsg.DescribeConfigsResponse), nil
+	}
+
+	res, err, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		topicConfigs, err := s.GetTopicConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.setCachedItem(key, topicConfigs, 120*time.Second)
+
+		return topicConfigs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*kmsg.DescribeConfigsResponse), nil
+}
+
 func (s *Service) GetTopicConfigs(ctx context.Context) (*kmsg.DescribeConfigsResponse, error) {
 	metadata, err := s.GetMetadataCached(ctx)
 	if err != nil {
@@ -16,14 +42,17 @@ func (s *Service) GetTopicConfigs(ctx context.Context) (*kmsg.DescribeConfigsRes
 	req := kmsg.NewDescribeConfigsRequest()
 
 	for _, topic := range metadata.Topics {
+		if !s.IsTopicConfigCollected(topic.Topic) {
+			continue
+		}
 		resourceReq := kmsg.NewDescribeConfigsRequestResource()
 		resourceReq.ResourceType = kmsg.ConfigResourceTypeTopic
 		resourceReq.ResourceName = topic.Topic
-		resourceReq.ConfigNames = []string{"cleanup.policy"}
+		resourceReq.ConfigNames = []string{"cleanup.policy", "min.insync.replicas"}
 		req.Resources = append(req.Resources, resourceReq)
 	}
 
-	res, err := req.RequestWith(ctx, s.kafkaSvc.Client)
+	res, err := req.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request metadata: %w", err)
 	}
@@ -0,0 +1,43 @@
+package minion
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// groupRequestLimiter bounds the number of concurrent OffsetFetch and DescribeGroups requests, so that the group
+// coordinators are not overwhelmed on clusters with many groups. Requests over the limit wait for a free slot.
+type groupRequestLimiter struct {
+	// slots is nil if the number of concurrent requests is unlimited
+	slots    chan struct{}
+	inFlight prometheus.Gauge
+}
+
+func newGroupRequestLimiter(maxConcurrent int, inFlight prometheus.Gauge) *groupRequestLimiter {
+	var slots chan struct{}
+	if maxConcurrent > 0 {
+		slots = make(chan struct{}, maxConcurrent)
+	}
+
+	return &groupRequestLimiter{slots: slots, inFlight: inFlight}
+}
+
+// acquire blocks until a request slot is free or the context is done. The returned function must be called once
+// the request has finished.
+func (l *groupRequestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l.inFlight.Inc()
+
+	return func() {
+		l.inFlight.Dec()
+		if l.slots != nil {
+			<-l.slots
+		}
+	}, nil
+}
@@ -0,0 +1,120 @@
+package minion
+
+import (
+	"context"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// IngestDelayBuckets are the upper bounds (in seconds) of the ingest delay histogram buckets
+var IngestDelayBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// IngestDelayHistogram contains all observed ingest delays of a topic. Buckets are indexed by their upper bound and
+// contain cumulative counts.
+type IngestDelayHistogram struct {
+	Count   uint64
+	Sum     float64
+	Buckets map[float64]uint64
+}
+
+type ingestDelayStorage struct {
+	mutex      sync.RWMutex
+	histograms map[string]*IngestDelayHistogram
+}
+
+func newIngestDelayStorage() *ingestDelayStorage {
+	return &ingestDelayStorage{histograms: make(map[string]*IngestDelayHistogram)}
+}
+
+func (s *ingestDelayStorage) observe(topicName string, delaySeconds float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	histogram, exists := s.histograms[topicName]
+	if !exists {
+		histogram = &IngestDelayHistogram{Buckets: make(map[float64]uint64, len(IngestDelayBuckets))}
+		for _, bound := range IngestDelayBuckets {
+			histogram.Buckets[bound] = 0
+		}
+		s.histograms[topicName] = histogram
+	}
+
+	histogram.Count++
+	histogram.Sum += delaySeconds
+	for _, bound := range IngestDelayBuckets {
+		if delaySeconds <= bound {
+			histogram.Buckets[bound]++
+		}
+	}
+}
+
+// startMeasuringIngestDelay consumes the configured topics from their end and observes the delay between each
+// record's timestamp and the time it has been consumed. It uses a separate client, as the shared client may already
+// be consuming the offsets topic.
+func (s *Service) startMeasuringIngestDelay(ctx context.Context) {
+	cfg := s.Cfg.IngestDelay
+	client, err := s.kafkaSvc.NewClient()
+	if err != nil {
+		s.logger.Error("failed to create kafka client for measuring the ingest delay", zap.Error(err))
+		return
+	}
+	defer client.Close()
+
+	client.AssignPartitions(kgo.ConsumeTopics(kgo.NewOffset().AtEnd(), cfg.Topics...))
+	s.logger.Info("starting to measure the ingest delay", zap.Strings("topics", cfg.Topics))
+
+	useLogAppendTime := cfg.TimestampType == TimestampTypeLogAppendTime
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			fetches := client.PollFetches(ctx)
+			for _, err := range fetches.Errors() {
+				s.logger.Warn("failed to fetch records for measuring the ingest delay",
+					zap.String("topic", err.Topic),
+					zap.Int32("partition", err.Partition),
+					zap.Error(err.Err))
+			}
+
+			consumedAt := time.Now()
+			iter := fetches.RecordIter()
+			for !iter.Done() {
+				record := iter.Next()
+				if record.Attrs.IsControl() {
+					continue
+				}
+				isLogAppendTime := record.Attrs.TimestampType() > 0
+				if isLogAppendTime != useLogAppendTime {
+					continue
+				}
+
+				delay := consumedAt.Sub(record.Timestamp).Seconds()
+				if delay < 0 {
+					// Clocks of producers may be skewed
+					delay = 0
+				}
+				s.ingestDelays.observe(record.Topic, delay)
+			}
+		}
+	}
+}
+
+// GetIngestDelayHistograms returns a copy of the ingest delay histograms indexed by topic name.
+func (s *Service) GetIngestDelayHistograms() map[string]IngestDelayHistogram {
+	s.ingestDelays.mutex.RLock()
+	defer s.ingestDelays.mutex.RUnlock()
+
+	histograms := make(map[string]IngestDelayHistogram, len(s.ingestDelays.histograms))
+	for topicName, histogram := range s.ingestDelays.histograms {
+		buckets := make(map[float64]uint64, len(histogram.Buckets))
+		for bound, count := range histogram.Buckets {
+			buckets[bound] = count
+		}
+		histograms[topicName] = IngestDelayHistogram{Count: histogram.Count, Sum: histogram.Sum, Buckets: buckets}
+	}
+
+	return histograms
+}
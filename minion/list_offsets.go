@@ -2,8 +2,12 @@ package minion
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
 	"strconv"
 	"time"
 )
@@ -33,31 +37,95 @@ func (s *Service) ListOffsetsCached(ctx context.Context, timestamp int64) (*kmsg
 	return res.(*kmsg.ListOffsetsResponse), nil
 }
 
-// ListOffsets fetches the low (timestamp: -2) or high water mark (timestamp: -1) for all topic partitions
+// ListOffsets fetches the low (timestamp: -2) or high water mark (timestamp: -1) for all topic partitions. For all
+// other timestamps the earliest offset whose timestamp is equal or greater than the given timestamp is returned.
 func (s *Service) ListOffsets(ctx context.Context, timestamp int64) (*kmsg.ListOffsetsResponse, error) {
 	metadata, err := s.GetMetadataCached(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
 	}
 
-	topicReqs := make([]kmsg.ListOffsetsRequestTopic, len(metadata.Topics))
-	for i, topic := range metadata.Topics {
+	topicReqs := make([]kmsg.ListOffsetsRequestTopic, 0, len(metadata.Topics))
+	for _, topic := range metadata.Topics {
 		req := kmsg.NewListOffsetsRequestTopic()
 		req.Topic = topic.Topic
 
-		partitionReqs := make([]kmsg.ListOffsetsRequestTopicPartition, len(topic.Partitions))
-		for j, partition := range topic.Partitions {
-			partitionReqs[j] = kmsg.NewListOffsetsRequestTopicPartition()
-			partitionReqs[j].Partition = partition.Partition
-			partitionReqs[j].Timestamp = timestamp
+		partitionReqs := make([]kmsg.ListOffsetsRequestTopicPartition, 0, len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			if !s.IsPartitionSelected(topic.Topic, partition.Partition) {
+				continue
+			}
+			partitionReq := kmsg.NewListOffsetsRequestTopicPartition()
+			partitionReq.Partition = partition.Partition
+			partitionReq.Timestamp = timestamp
+			partitionReqs = append(partitionReqs, partitionReq)
 		}
 		req.Partitions = partitionReqs
 
-		topicReqs[i] = req
+		topicReqs = append(topicReqs, req)
 	}
 
 	req := kmsg.NewListOffsetsRequest()
 	req.Topics = topicReqs
 
-	return req.RequestWith(ctx, s.kafkaSvc.Client)
+	// The request is sharded to the partition leaders. If a single broker fails, the offsets of the partitions led
+	// by all other brokers are still returned.
+	responses := s.kafkaSvc.RequestSharded(ctx, &req)
+	return mergeListOffsetsShards(responses, s.logger)
+}
+
+// mergeListOffsetsShards merges the responses of all ListOffsets shards into a single response. The partitions of
+// failed shards are added with an error code, so that consumers of the response handle them like any other
+// partition error. An error is only returned if all shards have failed.
+func mergeListOffsetsShards(responses []kgo.ResponseShard, logger *zap.Logger) (*kmsg.ListOffsetsResponse, error) {
+	merged := kmsg.NewPtrListOffsetsResponse()
+	partitionsByTopic := make(map[string][]kmsg.ListOffsetsResponseTopicPartition)
+	var lastErr error
+	failedShards := 0
+	for _, shard := range responses {
+		if shard.Err == nil {
+			res := shard.Resp.(*kmsg.ListOffsetsResponse)
+			merged.Version = res.Version
+			for _, topic := range res.Topics {
+				partitionsByTopic[topic.Topic] = append(partitionsByTopic[topic.Topic], topic.Partitions...)
+			}
+			continue
+		}
+
+		lastErr = shard.Err
+		failedShards++
+		logger.Warn("failed to list offsets on a broker, offsets of its partitions are missing",
+			zap.Int32("broker_id", shard.Meta.NodeID),
+			zap.Error(shard.Err))
+
+		errorCode := kerr.UnknownServerError.Code
+		var kafkaErr *kerr.Error
+		if errors.As(shard.Err, &kafkaErr) {
+			errorCode = kafkaErr.Code
+		}
+		req, ok := shard.Req.(*kmsg.ListOffsetsRequest)
+		if !ok {
+			continue
+		}
+		for _, topic := range req.Topics {
+			for _, partition := range topic.Partitions {
+				partitionRes := kmsg.NewListOffsetsResponseTopicPartition()
+				partitionRes.Partition = partition.Partition
+				partitionRes.ErrorCode = errorCode
+				partitionsByTopic[topic.Topic] = append(partitionsByTopic[topic.Topic], partitionRes)
+			}
+		}
+	}
+	if len(responses) > 0 && failedShards == len(responses) {
+		return nil, fmt.Errorf("failed to list offsets on all brokers: %w", lastErr)
+	}
+
+	for topicName, partitions := range partitionsByTopic {
+		topic := kmsg.NewListOffsetsResponseTopic()
+		topic.Topic = topicName
+		topic.Partitions = partitions
+		merged.Topics = append(merged.Topics, topic)
+	}
+
+	return merged, nil
 }
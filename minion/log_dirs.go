@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
+	"time"
 )
 
 type LogDirResponseShard struct {
@@ -12,10 +13,28 @@ type LogDirResponseShard struct {
 	LogDirs *kmsg.DescribeLogDirsResponse
 }
 
+func (s *Service) DescribeLogDirsCached(ctx context.Context) []LogDirResponseShard {
+	reqId := ctx.Value("requestId").(string)
+	key := "describe-log-dirs-" + reqId
+
+	if cachedRes, exists := s.getCachedItem(key); exists {
+		return cachedRes.([]LogDirResponseShard)
+	}
+
+	res, _, _ := s.requestGroup.Do(key, func() (interface{}, error) {
+		logDirs := s.DescribeLogDirs(ctx)
+		s.setCachedItem(key, logDirs, 120*time.Second)
+
+		return logDirs, nil
+	})
+
+	return res.([]LogDirResponseShard)
+}
+
 func (s *Service) DescribeLogDirs(ctx context.Context) []LogDirResponseShard {
 	req := kmsg.NewDescribeLogDirsRequest()
 	req.Topics = nil // Describe all topics
-	responses := s.kafkaSvc.Client.RequestSharded(ctx, &req)
+	responses := s.kafkaSvc.RequestSharded(ctx, &req)
 
 	res := make([]LogDirResponseShard, len(responses))
 	for i, responseShard := range responses {
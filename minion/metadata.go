@@ -10,6 +10,13 @@ import (
 func (s *Service) GetMetadataCached(ctx context.Context) (*kmsg.MetadataResponse, error) {
 	reqId := ctx.Value("requestId").(string)
 	key := "metadata-" + reqId
+	timeout := 120 * time.Second
+
+	// If a refresh interval is configured the metadata is shared across all requests until the interval has passed
+	if s.Cfg.Metadata.RefreshInterval > 0 {
+		key = "metadata"
+		timeout = s.Cfg.Metadata.RefreshInterval
+	}
 
 	if cachedRes, exists := s.getCachedItem(key); exists {
 		return cachedRes.(*kmsg.MetadataResponse), nil
@@ -21,7 +28,7 @@ func (s *Service) GetMetadataCached(ctx context.Context) (*kmsg.MetadataResponse
 			return nil, err
 		}
 
-		s.setCachedItem(key, metadata, 120*time.Second)
+		s.setCachedItem(key, metadata, timeout)
 
 		return metadata, nil
 	})
@@ -36,10 +43,24 @@ func (s *Service) GetMetadata(ctx context.Context) (*kmsg.MetadataResponse, erro
 	req := kmsg.NewMetadataRequest()
 	req.Topics = nil
 
-	res, err := req.RequestWith(ctx, s.kafkaSvc.Client)
+	res, err := req.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request metadata: %w", err)
 	}
 
 	return res, nil
 }
+
+// GetClusterID returns the cluster id as reported in the cluster metadata. An empty string is returned if the cluster
+// does not report a cluster id (e.g. because it runs a Kafka version prior to v0.10.1).
+func (s *Service) GetClusterID(ctx context.Context) (string, error) {
+	metadata, err := s.GetMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	if metadata.ClusterID == nil {
+		return "", nil
+	}
+
+	return *metadata.ClusterID, nil
+}
@@ -15,8 +15,17 @@ import (
 // methods where they'll be decoded and further processed.
 func (s *Service) startConsumingOffsets(ctx context.Context) {
 	client := s.kafkaSvc.Client
-	topic := kgo.ConsumeTopics(kgo.NewOffset().AtStart(), "__consumer_offsets")
-	client.AssignPartitions(topic)
+	s.offsetsTopicPartitions = s.selectOffsetsTopicPartitions(ctx)
+	if s.offsetsTopicPartitions == nil {
+		topic := kgo.ConsumeTopics(kgo.NewOffset().AtStart(), "__consumer_offsets")
+		client.AssignPartitions(topic)
+	} else {
+		partitions := make(map[int32]kgo.Offset, len(s.offsetsTopicPartitions))
+		for partitionID := range s.offsetsTopicPartitions {
+			partitions[partitionID] = kgo.NewOffset().AtStart()
+		}
+		client.AssignPartitions(kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{"__consumer_offsets": partitions}))
+	}
 
 	s.logger.Info("starting to consume messages from offsets topic")
 	go s.checkIfConsumerLagIsCaughtUp(ctx)
@@ -66,7 +75,7 @@ func (s *Service) checkIfConsumerLagIsCaughtUp(ctx context.Context) {
 		topic.Topic = &topicName
 		req.Topics = []kmsg.MetadataRequestTopic{topic}
 
-		res, err := req.RequestWith(ctx, s.kafkaSvc.Client)
+		res, err := req.RequestWith(ctx, s.kafkaSvc)
 		if err != nil {
 			s.logger.Warn("failed to check if consumer lag on offsets topic is caught up because metadata request failed",
 				zap.Error(err))
@@ -91,7 +100,7 @@ func (s *Service) checkIfConsumerLagIsCaughtUp(ctx context.Context) {
 		}
 		offsetReq := kmsg.NewListOffsetsRequest()
 		offsetReq.Topics = topicReqs
-		highMarksRes, err := offsetReq.RequestWith(ctx, s.kafkaSvc.Client)
+		highMarksRes, err := offsetReq.RequestWith(ctx, s.kafkaSvc)
 		if err != nil {
 			s.logger.Warn("failed to check if consumer lag on offsets topic is caught up because high watermark request failed",
 				zap.Error(err))
@@ -111,6 +120,11 @@ func (s *Service) checkIfConsumerLagIsCaughtUp(ctx context.Context) {
 		partitionsLagging := 0
 		totalLag := int64(0)
 		for _, partition := range topicRes.Partitions {
+			if s.offsetsTopicPartitions != nil {
+				if _, isConsumed := s.offsetsTopicPartitions[partition.Partition]; !isConsumed {
+					continue
+				}
+			}
 			err := kerr.ErrorForCode(partition.ErrorCode)
 			if err != nil {
 				s.logger.Warn("failed to check if consumer lag on offsets topic is caught up because high "+
@@ -194,6 +208,8 @@ func (s *Service) decodeOffsetMetadata(record *kgo.Record) error {
 	}
 
 	if record.Value == nil {
+		// Tombstone - The group has been deleted
+		s.storage.deleteGroupGeneration(metadataKey.Group)
 		return nil
 	}
 	metadataValue := kmsg.NewGroupMetadataValue()
@@ -202,6 +218,7 @@ func (s *Service) decodeOffsetMetadata(record *kgo.Record) error {
 		childLogger.Warn("failed to decode offset metadata value", zap.Error(err))
 		return fmt.Errorf("failed to decode offset metadata value: %w", err)
 	}
+	s.storage.setGroupGeneration(metadataKey.Group, metadataValue.Generation)
 
 	return nil
 }
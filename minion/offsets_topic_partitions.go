@@ -0,0 +1,119 @@
+package minion
+
+import (
+	"context"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// selectOffsetsTopicPartitions returns the partitions of the __consumer_offsets topic which contain the offsets of
+// the monitored consumer groups. Kafka assigns each group to a partition by hashing its group id, therefore we only
+// need to consume these partitions if all allowed groups are given as literal group ids. Nil is returned if all
+// partitions must be consumed.
+func (s *Service) selectOffsetsTopicPartitions(ctx context.Context) map[int32]struct{} {
+	groupIDs, isLiteral := literalGroupIDs(s.Cfg.ConsumerGroups.AllowedGroupIDs)
+	if !isLiteral {
+		return nil
+	}
+
+	partitionCount, err := s.getOffsetsTopicPartitionCount(ctx)
+	if err != nil {
+		s.logger.Warn("failed to get partition count of offsets topic, falling back to consuming all partitions",
+			zap.Error(err))
+		return nil
+	}
+
+	partitions := offsetsTopicPartitionsForGroups(groupIDs, partitionCount)
+	s.logger.Info("consuming only the offsets topic partitions of the allowed consumer groups",
+		zap.Int("consumed_partitions", len(partitions)),
+		zap.Int32("total_partitions", partitionCount))
+
+	return partitions
+}
+
+func (s *Service) getOffsetsTopicPartitionCount(ctx context.Context) (int32, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req := kmsg.NewMetadataRequest()
+	topic := kmsg.NewMetadataRequestTopic()
+	topicName := "__consumer_offsets"
+	topic.Topic = &topicName
+	req.Topics = []kmsg.MetadataRequestTopic{topic}
+
+	res, err := req.RequestWith(ctx, s.kafkaSvc)
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Topics) != 1 {
+		return 0, kerr.UnknownTopicOrPartition
+	}
+	err = kerr.ErrorForCode(res.Topics[0].ErrorCode)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res.Topics[0].Partitions) == 0 {
+		return 0, kerr.UnknownTopicOrPartition
+	}
+
+	return int32(len(res.Topics[0].Partitions)), nil
+}
+
+// literalGroupIDs returns the given group id expressions if all of them are literal group ids. The returned bool is
+// false if at least one of them is a regex.
+func literalGroupIDs(expressions []string) ([]string, bool) {
+	if len(expressions) == 0 {
+		return nil, false
+	}
+
+	for _, expr := range expressions {
+		isRegex := strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/")
+		if isRegex || regexp.QuoteMeta(expr) != expr {
+			return nil, false
+		}
+	}
+
+	return expressions, true
+}
+
+// offsetsTopicPartitionsForGroups returns the __consumer_offsets partitions the given groups are assigned to. This
+// mirrors the broker's partitioning: Utils.abs(groupId.hashCode()) % partitionCount.
+func offsetsTopicPartitionsForGroups(groupIDs []string, partitionCount int32) map[int32]struct{} {
+	partitions := make(map[int32]struct{})
+	for _, groupID := range groupIDs {
+		partitionID := kafkaAbs(javaStringHashCode(groupID)) % partitionCount
+		partitions[partitionID] = struct{}{}
+	}
+
+	return partitions
+}
+
+// kafkaAbs computes the same absolute value as Kafka's Utils.abs(), which maps math.MinInt32 to 0 as its absolute
+// value can't be represented as int32.
+func kafkaAbs(n int32) int32 {
+	if n == math.MinInt32 {
+		return 0
+	}
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// javaStringHashCode computes the same hash as Java's String.hashCode(), which is computed over UTF-16 code units.
+func javaStringHashCode(str string) int32 {
+	hash := int32(0)
+	for _, char := range utf16.Encode([]rune(str)) {
+		hash = 31*hash + int32(char)
+	}
+
+	return hash
+}
@@ -0,0 +1,83 @@
+package minion
+
+import (
+	"reflect"
+	"testing"
+)
+
+// javaHashCodes are the results of Java's String.hashCode() for the given strings. Non-ASCII strings are hashed
+// over their UTF-16 code units, so characters outside of the basic multilingual plane count as two chars.
+var javaHashCodes = []struct {
+	str      string
+	hashCode int32
+}{
+	{"", 0},
+	{"a", 97},
+	{"hello", 99162322},
+	{"Aa", 2112},
+	{"BB", 2112},
+	// The hash code is math.MinInt32, whose absolute value can't be represented as int32
+	{"polygenelubricants", -2147483648},
+	{"console-consumer-12345", 158455506},
+	{"bigquery-sink", 1363304824},
+	{"orders-consumer", -1204742690},
+	{"ü", 252},
+	{"grüße", 98768023},
+	{"日本", 835047},
+	{"😀", 1772899},
+	{"Ｇroup-😀", 1907336369},
+}
+
+func TestJavaStringHashCode(t *testing.T) {
+	for _, test := range javaHashCodes {
+		if hashCode := javaStringHashCode(test.str); hashCode != test.hashCode {
+			t.Errorf("expected hash code of '%v' to be %d, got %d", test.str, test.hashCode, hashCode)
+		}
+	}
+}
+
+func TestOffsetsTopicPartitionsForGroups(t *testing.T) {
+	// Partitions as assigned by the broker's GroupMetadataManager.partitionFor with the default of 50 partitions
+	expectedPartitions := map[string]int32{
+		"":                       0,
+		"a":                      47,
+		"hello":                  22,
+		"Aa":                     12,
+		"BB":                     12,
+		"polygenelubricants":     0,
+		"console-consumer-12345": 6,
+		"bigquery-sink":          24,
+		"orders-consumer":        40,
+		"kafka-connect-sink-1":   5,
+		"ü":                      2,
+		"grüße":                  23,
+		"日本":                     47,
+		"😀":                      49,
+		"Ｇroup-😀":                19,
+	}
+	for groupID, partitionID := range expectedPartitions {
+		partitions := offsetsTopicPartitionsForGroups([]string{groupID}, 50)
+		expected := map[int32]struct{}{partitionID: {}}
+		if !reflect.DeepEqual(partitions, expected) {
+			t.Errorf("expected group '%v' to be assigned to partition %d, got %v", groupID, partitionID, partitions)
+		}
+	}
+
+	// Groups that are assigned to the same partition are only reported once
+	partitions := offsetsTopicPartitionsForGroups([]string{"Aa", "BB", "hello", "ü"}, 3)
+	expected := map[int32]struct{}{0: {}, 1: {}}
+	if !reflect.DeepEqual(partitions, expected) {
+		t.Errorf("expected partitions %v, got %v", expected, partitions)
+	}
+}
+
+func TestLiteralGroupIDs(t *testing.T) {
+	if groupIDs, isLiteral := literalGroupIDs([]string{"bigquery-sink", "grüße"}); !isLiteral || len(groupIDs) != 2 {
+		t.Errorf("expected literal group ids, got %v", groupIDs)
+	}
+	for _, expressions := range [][]string{{}, {"bigquery-sink", "/.*/"}, {"bigquery.*"}} {
+		if _, isLiteral := literalGroupIDs(expressions); isLiteral {
+			t.Errorf("expected %v not to be literal group ids", expressions)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package minion
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+	"time"
+)
+
+// OldestMessages contains the timestamp of the oldest message of each topic, as far as it is reliable. It's the base
+// for both the topic age and the topic creation times, as Kafka does not expose the creation time of topics.
+type OldestMessages struct {
+	// Timestamps of the oldest message of all topics whose oldest message is still the first one ever written
+	Timestamps map[string]time.Time
+
+	// Unknown are all topics whose oldest message is unknown or not reliable. This is the case for compacted topics,
+	// topics whose low water marks have advanced due to retention, empty topics and topics whose configs or earliest
+	// offsets could not be fetched.
+	Unknown map[string]struct{}
+
+	// HasErrors is true if the configs or the earliest offsets of at least one topic could not be fetched
+	HasErrors bool
+}
+
+// GetOldestMessages returns the timestamp of the oldest message of each topic. Errors of single topics are logged and
+// the topics are reported as unknown, an error is only returned if the requests themselves have failed.
+func (s *Service) GetOldestMessages(ctx context.Context) (OldestMessages, error) {
+	lowWaterMarks, err := s.ListOffsetsCached(ctx, -2)
+	if err != nil {
+		return OldestMessages{}, fmt.Errorf("failed to fetch low water marks: %w", err)
+	}
+	// Timestamp 0 returns the first message whose timestamp is >= 0 along with its timestamp
+	earliestOffsets, err := s.ListOffsetsCached(ctx, 0)
+	if err != nil {
+		return OldestMessages{}, fmt.Errorf("failed to fetch earliest message timestamps: %w", err)
+	}
+	topicConfigs, err := s.GetTopicConfigsCached(ctx)
+	if err != nil {
+		return OldestMessages{}, fmt.Errorf("failed to get topic configs: %w", err)
+	}
+
+	return deriveOldestMessages(s.logger, lowWaterMarks, earliestOffsets, topicConfigs), nil
+}
+
+// deriveOldestMessages derives the oldest messages from the low water marks, the earliest offsets (ListOffsets with
+// timestamp 0) and the topic configs
+func deriveOldestMessages(logger *zap.Logger, lowWaterMarks *kmsg.ListOffsetsResponse, earliestOffsets *kmsg.ListOffsetsResponse, topicConfigs *kmsg.DescribeConfigsResponse) OldestMessages {
+	result := OldestMessages{
+		Timestamps: make(map[string]time.Time),
+		Unknown:    make(map[string]struct{}),
+	}
+
+	// Without the cleanup policy we can't tell whether the oldest message is reliable, hence topics are only
+	// considered if their config could be described successfully
+	hasCleanupPolicy := make(map[string]struct{})
+	for _, resource := range topicConfigs.Resources {
+		if err := kerr.ErrorForCode(resource.ErrorCode); err != nil {
+			logger.Warn("failed to describe topic config, skipping topic for its oldest message",
+				zap.String("topic_name", resource.ResourceName),
+				zap.Error(err))
+			result.Unknown[resource.ResourceName] = struct{}{}
+			result.HasErrors = true
+			continue
+		}
+		for _, config := range resource.Configs {
+			if config.Name != "cleanup.policy" || config.Value == nil {
+				continue
+			}
+			hasCleanupPolicy[resource.ResourceName] = struct{}{}
+			if strings.Contains(*config.Value, "compact") {
+				result.Unknown[resource.ResourceName] = struct{}{}
+			}
+		}
+	}
+
+	// Topics whose data has partially been removed already are unknown
+	for _, topic := range lowWaterMarks.Topics {
+		for _, partition := range topic.Partitions {
+			if kerr.ErrorForCode(partition.ErrorCode) != nil || partition.Offset != 0 {
+				result.Unknown[topic.Topic] = struct{}{}
+				break
+			}
+		}
+	}
+
+	for _, topic := range earliestOffsets.Topics {
+		if _, isUnknown := result.Unknown[topic.Topic]; isUnknown {
+			continue
+		}
+		if _, exists := hasCleanupPolicy[topic.Topic]; !exists {
+			result.Unknown[topic.Topic] = struct{}{}
+			continue
+		}
+
+		oldestTimestamp := int64(math.MaxInt64)
+		for _, partition := range topic.Partitions {
+			if err := kerr.ErrorForCode(partition.ErrorCode); err != nil {
+				logger.Warn("failed to get earliest message timestamp of partition, inner kafka error",
+					zap.String("topic_name", topic.Topic),
+					zap.Int32("partition_id", partition.Partition),
+					zap.Error(err))
+				result.HasErrors = true
+				oldestTimestamp = math.MaxInt64
+				break
+			}
+			// Empty partitions report a timestamp of -1
+			if partition.Timestamp >= 0 && partition.Timestamp < oldestTimestamp {
+				oldestTimestamp = partition.Timestamp
+			}
+		}
+		if oldestTimestamp == math.MaxInt64 {
+			result.Unknown[topic.Topic] = struct{}{}
+			continue
+		}
+		result.Timestamps[topic.Topic] = time.Unix(0, oldestTimestamp*int64(time.Millisecond))
+	}
+
+	return result
+}
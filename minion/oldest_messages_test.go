@@ -0,0 +1,108 @@
+package minion
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestDeriveOldestMessages(t *testing.T) {
+	// Partition timestamps by topic, the index is the partition id. -1 means empty partition.
+	earliestTimestamps := map[string][]int64{
+		"orders":     {1600000300000, 1600000100000, -1},
+		"compacted":  {1600000100000},
+		"truncated":  {1600000100000},
+		"empty":      {-1, -1},
+		"no-config":  {1600000100000},
+		"config-err": {1600000100000},
+	}
+	lowWaterMarks := map[string]int64{"truncated": 52}
+	cleanupPolicies := map[string]string{
+		"orders":    "delete",
+		"compacted": "compact,delete",
+		"truncated": "delete",
+		"empty":     "delete",
+	}
+
+	lowWaterMarksRes := &kmsg.ListOffsetsResponse{}
+	earliestOffsetsRes := &kmsg.ListOffsetsResponse{}
+	for topicName, timestamps := range earliestTimestamps {
+		lowTopic := kmsg.ListOffsetsResponseTopic{Topic: topicName}
+		earliestTopic := kmsg.ListOffsetsResponseTopic{Topic: topicName}
+		for partitionID, timestamp := range timestamps {
+			lowTopic.Partitions = append(lowTopic.Partitions, kmsg.ListOffsetsResponseTopicPartition{
+				Partition: int32(partitionID),
+				Offset:    lowWaterMarks[topicName],
+			})
+			earliestTopic.Partitions = append(earliestTopic.Partitions, kmsg.ListOffsetsResponseTopicPartition{
+				Partition: int32(partitionID),
+				Timestamp: timestamp,
+			})
+		}
+		lowWaterMarksRes.Topics = append(lowWaterMarksRes.Topics, lowTopic)
+		earliestOffsetsRes.Topics = append(earliestOffsetsRes.Topics, earliestTopic)
+	}
+
+	topicConfigsRes := &kmsg.DescribeConfigsResponse{}
+	for topicName, policy := range cleanupPolicies {
+		policy := policy
+		topicConfigsRes.Resources = append(topicConfigsRes.Resources, kmsg.DescribeConfigsResponseResource{
+			ResourceName: topicName,
+			Configs:      []kmsg.DescribeConfigsResponseResourceConfig{{Name: "cleanup.policy", Value: &policy}},
+		})
+	}
+	topicConfigsRes.Resources = append(topicConfigsRes.Resources, kmsg.DescribeConfigsResponseResource{
+		ResourceName: "config-err",
+		ErrorCode:    kerr.TopicAuthorizationFailed.Code,
+	})
+
+	result := deriveOldestMessages(zap.NewNop(), lowWaterMarksRes, earliestOffsetsRes, topicConfigsRes)
+
+	// Derivation path: the oldest message across all non-empty partitions
+	expected := time.Unix(1600000100, 0)
+	if len(result.Timestamps) != 1 || !result.Timestamps["orders"].Equal(expected) {
+		t.Fatalf("expected only topic orders with oldest message at %v, got %v", expected, result.Timestamps)
+	}
+
+	// Omission path: the oldest message of all other topics is not reliable
+	for _, topicName := range []string{"compacted", "truncated", "empty", "no-config", "config-err"} {
+		if _, isUnknown := result.Unknown[topicName]; !isUnknown {
+			t.Errorf("expected oldest message of topic %v to be unknown", topicName)
+		}
+	}
+	if _, isUnknown := result.Unknown["orders"]; isUnknown {
+		t.Errorf("expected oldest message of topic orders to be known")
+	}
+	if !result.HasErrors {
+		t.Errorf("expected the failed topic config to be reported as error")
+	}
+}
+
+func TestDeriveOldestMessagesPartitionError(t *testing.T) {
+	policy := "delete"
+	lowWaterMarksRes := &kmsg.ListOffsetsResponse{Topics: []kmsg.ListOffsetsResponseTopic{{
+		Topic:      "orders",
+		Partitions: []kmsg.ListOffsetsResponseTopicPartition{{Partition: 0}, {Partition: 1}},
+	}}}
+	earliestOffsetsRes := &kmsg.ListOffsetsResponse{Topics: []kmsg.ListOffsetsResponseTopic{{
+		Topic: "orders",
+		Partitions: []kmsg.ListOffsetsResponseTopicPartition{
+			{Partition: 0, Timestamp: 1600000100000},
+			{Partition: 1, ErrorCode: kerr.NotLeaderForPartition.Code},
+		},
+	}}}
+	topicConfigsRes := &kmsg.DescribeConfigsResponse{Resources: []kmsg.DescribeConfigsResponseResource{{
+		ResourceName: "orders",
+		Configs:      []kmsg.DescribeConfigsResponseResourceConfig{{Name: "cleanup.policy", Value: &policy}},
+	}}}
+
+	result := deriveOldestMessages(zap.NewNop(), lowWaterMarksRes, earliestOffsetsRes, topicConfigsRes)
+	if _, isUnknown := result.Unknown["orders"]; !isUnknown || len(result.Timestamps) != 0 {
+		t.Fatalf("expected oldest message of topic orders to be unknown, got %v", result.Timestamps)
+	}
+	if !result.HasErrors {
+		t.Errorf("expected the failed partition to be reported as error")
+	}
+}
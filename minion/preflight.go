@@ -0,0 +1,65 @@
+package minion
+
+import (
+	"context"
+	"errors"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// runPreflight issues a few cheap requests in order to verify that the configured credentials have the permissions
+// required by kminion. Missing permissions are logged in a single summary. kminion continues to run in a degraded
+// mode, so that the metrics which can be gathered are still exported.
+func (s *Service) runPreflight(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	missingPermissions := make([]string, 0)
+
+	metadataReq := kmsg.NewMetadataRequest()
+	metadataReq.Topics = nil
+	metadataRes, err := metadataReq.RequestWith(ctx, s.kafkaSvc)
+	if err != nil {
+		s.logger.Warn("startup preflight failed to request metadata", zap.Error(err))
+		missingPermissions = append(missingPermissions, "metadata (Describe on Cluster)")
+	} else {
+		unauthorizedTopics := 0
+		for _, topic := range metadataRes.Topics {
+			if errors.Is(kerr.ErrorForCode(topic.ErrorCode), kerr.TopicAuthorizationFailed) {
+				unauthorizedTopics++
+			}
+		}
+		if unauthorizedTopics > 0 {
+			missingPermissions = append(missingPermissions, "Describe on one or more topics")
+		}
+	}
+
+	// Consumer groups are only listed if the cluster supports it
+	if s.Cfg.ConsumerGroups.Enabled && s.Cfg.ConsumerGroups.ListGroups {
+		listReq := kmsg.NewListGroupsRequest()
+		listRes, err := listReq.RequestWith(ctx, s.kafkaSvc)
+		if err != nil {
+			s.logger.Warn("startup preflight failed to list consumer groups", zap.Error(err))
+			missingPermissions = append(missingPermissions, "list consumer groups (Describe on Group)")
+		} else if err := kerr.ErrorForCode(listRes.ErrorCode); err != nil {
+			s.logger.Warn("startup preflight failed to list consumer groups, inner kafka error", zap.Error(err))
+			missingPermissions = append(missingPermissions, "list consumer groups (Describe on Group)")
+		}
+	}
+
+	s.preflightOk = len(missingPermissions) == 0
+	if !s.preflightOk {
+		s.logger.Warn("startup preflight detected missing permissions, kminion will continue in a degraded mode",
+			zap.String("missing_permissions", strings.Join(missingPermissions, ", ")))
+		return
+	}
+	s.logger.Info("startup preflight successfully verified the required permissions")
+}
+
+// IsPreflightOk returns whether the startup preflight verified all required permissions.
+func (s *Service) IsPreflightOk() bool {
+	return s.preflightOk
+}
@@ -0,0 +1,281 @@
+package minion
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// roundtripStatus is the result of the most recent roundtrip and the number of roundtrips since startup
+type roundtripStatus struct {
+	mutex     sync.RWMutex
+	hasResult bool
+	ok        bool
+
+	// total is the number of completed roundtrips, overSLA the number of roundtrips that failed or took longer
+	// than the SLA
+	total   uint64
+	overSLA uint64
+
+	// underReplicated is the number of successful produces whose partition had fewer in sync replicas than replicas
+	underReplicated uint64
+}
+
+func (r *roundtripStatus) set(ok bool, isOverSLA bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.hasResult = true
+	r.ok = ok
+	r.total++
+	if isOverSLA {
+		r.overSLA++
+	}
+}
+
+func (r *roundtripStatus) addUnderReplicated() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.underReplicated++
+}
+
+// startRoundtrips produces a single record to the roundtrip topic on each interval and consumes it again. It uses a
+// separate client, as the shared client may already be consuming the offsets topic.
+func (s *Service) startRoundtrips(ctx context.Context) {
+	cfg := s.Cfg.Roundtrip
+	var opts []kgo.Opt
+	if cfg.Partitioner == RoundtripPartitionerRoundRobin {
+		opts = append(opts, kgo.RecordPartitioner(recordPartitionPartitioner{}))
+	}
+	client, err := s.kafkaSvc.NewClient(opts...)
+	if err != nil {
+		s.logger.Error("failed to create kafka client for roundtrips", zap.Error(err))
+		return
+	}
+	defer client.Close()
+
+	s.logger.Info("starting roundtrips", zap.String("topic", cfg.Topic), zap.Duration("interval", cfg.Interval))
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	// roundtripNumber is used by the round robin partitioner to pick the next partition
+	roundtripNumber := 0
+	for {
+		roundtripCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		startedAt := time.Now()
+		produced, err := s.roundtrip(roundtripCtx, client, roundtripNumber)
+		roundtripNumber++
+		duration := time.Since(startedAt)
+		cancel()
+		if ctx.Err() != nil {
+			// Roundtrips that have been aborted because of the shutdown must not be counted
+			return
+		}
+		if err != nil {
+			s.logger.Warn("roundtrip failed", zap.String("topic", cfg.Topic), zap.Error(err))
+		}
+		s.roundtripStatus.set(err == nil, err != nil || duration > cfg.SLA)
+		if err == nil {
+			s.observeRoundtripLatency(duration, produced)
+		}
+		if produced != nil {
+			// The metadata request is not part of the roundtrip, hence it's issued after the duration has been taken
+			checkCtx, cancelCheck := context.WithTimeout(ctx, cfg.Timeout)
+			s.checkRoundtripUnderReplicated(checkCtx, client, produced)
+			cancelCheck()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// observeRoundtripLatency records the duration of a successful roundtrip. The key of the roundtrip record, which is
+// unique per roundtrip, is attached as exemplar so that slow roundtrips can be traced back to their record.
+func (s *Service) observeRoundtripLatency(duration time.Duration, record *kgo.Record) {
+	exemplar := prometheus.Labels{"roundtrip_id": string(record.Key)}
+	s.roundtripLatency.(prometheus.ExemplarObserver).ObserveWithExemplar(duration.Seconds(), exemplar)
+}
+
+// roundtrip produces a record and consumes it from the partition and offset it has been produced to. The record is
+// returned if it has been produced successfully, even if it could not be consumed.
+func (s *Service) roundtrip(ctx context.Context, client *kgo.Client, roundtripNumber int) (*kgo.Record, error) {
+	key := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	record := &kgo.Record{Topic: s.Cfg.Roundtrip.Topic, Key: key, Value: []byte("kminion roundtrip")}
+	if s.Cfg.Roundtrip.Partitioner == RoundtripPartitionerRoundRobin {
+		partitionID, err := s.nextRoundtripPartition(ctx, client, roundtripNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pick partition: %w", err)
+		}
+		record.Partition = partitionID
+	}
+
+	produceErrCh := make(chan error, 1)
+	err := client.Produce(ctx, record, func(_ *kgo.Record, err error) {
+		produceErrCh <- err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to produce record: %w", err)
+	}
+	select {
+	case err := <-produceErrCh:
+		if err != nil {
+			return nil, fmt.Errorf("failed to produce record: %w", err)
+		}
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to produce record: %w", ctx.Err())
+	}
+
+	// The record's partition and offset have been set once the produce promise has been called
+	client.AssignPartitions(kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
+		record.Topic: {record.Partition: kgo.NewOffset().At(record.Offset)},
+	}))
+	defer client.AssignPartitions()
+
+	for {
+		fetches := client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			return record, fmt.Errorf("failed to consume record: %w", ctx.Err())
+		}
+		for _, err := range fetches.Errors() {
+			return record, fmt.Errorf("failed to consume record: %w", err.Err)
+		}
+
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			consumed := iter.Next()
+			if consumed.Partition == record.Partition && consumed.Offset == record.Offset {
+				if string(consumed.Key) != string(key) {
+					return record, fmt.Errorf("consumed record at offset %d has an unexpected key", record.Offset)
+				}
+				return record, nil
+			}
+		}
+	}
+}
+
+// nextRoundtripPartition returns the partition the given roundtrip is produced to by the round robin partitioner.
+func (s *Service) nextRoundtripPartition(ctx context.Context, client *kgo.Client, roundtripNumber int) (int32, error) {
+	partitions := s.Cfg.Roundtrip.Partitions
+	if len(partitions) > 0 {
+		return partitions[roundtripNumber%len(partitions)], nil
+	}
+
+	topic, err := s.requestRoundtripTopicMetadata(ctx, client, s.Cfg.Roundtrip.Topic)
+	if err != nil {
+		return 0, err
+	}
+	if len(topic.Partitions) == 0 {
+		return 0, fmt.Errorf("topic has no partitions")
+	}
+	return int32(roundtripNumber % len(topic.Partitions)), nil
+}
+
+// checkRoundtripUnderReplicated counts the produced roundtrip record as under replicated if its partition currently
+// has fewer in sync replicas than replicas. The produce has been acknowledged by all in sync replicas, which may still
+// be fewer than the topic's replicas.
+func (s *Service) checkRoundtripUnderReplicated(ctx context.Context, client *kgo.Client, record *kgo.Record) {
+	underReplicated, err := s.isPartitionUnderReplicated(ctx, client, record.Topic, record.Partition)
+	if err != nil {
+		s.logger.Warn("failed to check whether the roundtrip partition is under replicated",
+			zap.String("topic", record.Topic),
+			zap.Int32("partition_id", record.Partition),
+			zap.Error(err))
+		return
+	}
+	if underReplicated {
+		s.roundtripStatus.addUnderReplicated()
+	}
+}
+
+// isPartitionUnderReplicated returns whether the given partition currently has fewer in sync replicas than replicas.
+func (s *Service) isPartitionUnderReplicated(ctx context.Context, client *kgo.Client, topic string, partitionID int32) (bool, error) {
+	resTopic, err := s.requestRoundtripTopicMetadata(ctx, client, topic)
+	if err != nil {
+		return false, err
+	}
+
+	for _, partition := range resTopic.Partitions {
+		if partition.Partition == partitionID {
+			return len(partition.ISR) < len(partition.Replicas), nil
+		}
+	}
+
+	return false, fmt.Errorf("partition is missing in the metadata response")
+}
+
+// requestRoundtripTopicMetadata requests the metadata of a single topic using the roundtrip client.
+func (s *Service) requestRoundtripTopicMetadata(ctx context.Context, client *kgo.Client, topic string) (kmsg.MetadataResponseTopic, error) {
+	req := kmsg.NewMetadataRequest()
+	reqTopic := kmsg.NewMetadataRequestTopic()
+	reqTopic.Topic = &topic
+	req.Topics = []kmsg.MetadataRequestTopic{reqTopic}
+
+	res, err := req.RequestWith(ctx, client)
+	if err != nil {
+		return kmsg.MetadataResponseTopic{}, fmt.Errorf("failed to request metadata: %w", err)
+	}
+
+	for _, resTopic := range res.Topics {
+		err := kerr.ErrorForCode(resTopic.ErrorCode)
+		if err != nil {
+			return kmsg.MetadataResponseTopic{}, fmt.Errorf("failed to get metadata of topic: %w", err)
+		}
+		return resTopic, nil
+	}
+
+	return kmsg.MetadataResponseTopic{}, fmt.Errorf("topic is missing in the metadata response")
+}
+
+// recordPartitionPartitioner produces each record to the partition that has already been set on the record, which
+// is used by the round robin roundtrip partitioner.
+type recordPartitionPartitioner struct{}
+
+func (recordPartitionPartitioner) ForTopic(string) kgo.TopicPartitioner {
+	return recordPartitionPartitioner{}
+}
+func (recordPartitionPartitioner) OnNewBatch() {}
+
+// RequiresConsistency is true, so that the record is never moved to another partition if its partition is down.
+func (recordPartitionPartitioner) RequiresConsistency(*kgo.Record) bool { return true }
+func (recordPartitionPartitioner) Partition(r *kgo.Record, n int) int {
+	// The partition may have been picked before the topic's partition count has decreased in the client's metadata
+	return int(r.Partition) % n
+}
+
+// GetRoundtripUnderReplicatedProduces returns the number of roundtrip records that have been produced successfully to
+// a partition with fewer in sync replicas than replicas.
+func (s *Service) GetRoundtripUnderReplicatedProduces() uint64 {
+	s.roundtripStatus.mutex.RLock()
+	defer s.roundtripStatus.mutex.RUnlock()
+
+	return s.roundtripStatus.underReplicated
+}
+
+// GetRoundtripCounts returns the number of roundtrips since startup and the number of roundtrips which have failed or
+// taken longer than the SLA.
+func (s *Service) GetRoundtripCounts() (uint64, uint64) {
+	s.roundtripStatus.mutex.RLock()
+	defer s.roundtripStatus.mutex.RUnlock()
+
+	return s.roundtripStatus.total, s.roundtripStatus.overSLA
+}
+
+// GetRoundtripStatus returns whether the most recent roundtrip has succeeded. The second return value is false if no
+// roundtrip has completed yet.
+func (s *Service) GetRoundtripStatus() (bool, bool) {
+	s.roundtripStatus.mutex.RLock()
+	defer s.roundtripStatus.mutex.RUnlock()
+
+	return s.roundtripStatus.ok, s.roundtripStatus.hasResult
+}
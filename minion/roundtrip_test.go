@@ -0,0 +1,77 @@
+package minion
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// gatherRoundtripLatency returns the roundtrip latency histogram of the given registry, or nil if it's not registered
+func gatherRoundtripLatency(t *testing.T, registry *prometheus.Registry) *dto.Histogram {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "kminion_end_to_end_roundtrip_latency_seconds" {
+			return family.Metric[0].GetHistogram()
+		}
+	}
+	return nil
+}
+
+func TestObserveRoundtripLatency(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.Roundtrip.Enabled = true
+	registry := prometheus.NewRegistry()
+	svc, err := NewService(cfg, zap.NewNop(), nil, "kminion", registry)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	svc.observeRoundtripLatency(42*time.Millisecond, &kgo.Record{Key: []byte("1602000000000000000")})
+
+	histogram := gatherRoundtripLatency(t, registry)
+	if histogram == nil {
+		t.Fatalf("expected the roundtrip latency to be registered")
+	}
+	if histogram.GetSampleCount() != 1 {
+		t.Errorf("expected 1 observation, got %d", histogram.GetSampleCount())
+	}
+	exemplars := 0
+	for _, bucket := range histogram.Bucket {
+		exemplar := bucket.GetExemplar()
+		if exemplar == nil {
+			continue
+		}
+		exemplars++
+		if bucket.GetUpperBound() != 0.08 {
+			t.Errorf("expected the exemplar in the 0.08 bucket, got %v", bucket.GetUpperBound())
+		}
+		if len(exemplar.Label) != 1 || exemplar.Label[0].GetName() != "roundtrip_id" ||
+			exemplar.Label[0].GetValue() != "1602000000000000000" {
+			t.Errorf("expected the roundtrip id as exemplar label, got %v", exemplar.Label)
+		}
+	}
+	if exemplars != 1 {
+		t.Errorf("expected 1 exemplar, got %d", exemplars)
+	}
+}
+
+func TestRoundtripLatencyIsOnlyRegisteredIfEnabled(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	registry := prometheus.NewRegistry()
+	_, err := NewService(cfg, zap.NewNop(), nil, "kminion", registry)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	if histogram := gatherRoundtripLatency(t, registry); histogram != nil {
+		t.Errorf("expected no roundtrip latency if roundtrips are disabled")
+	}
+}
@@ -0,0 +1,89 @@
+package minion
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Scope describes the topics and consumer groups that are monitored after all filters have been applied.
+type Scope struct {
+	Topics         []ScopeTopic `json:"topics"`
+	ConsumerGroups []string     `json:"consumerGroups"`
+}
+
+type ScopeTopic struct {
+	TopicName      string `json:"topicName"`
+	PartitionCount int    `json:"partitionCount"`
+}
+
+// GetScope returns the currently monitored topics and consumer groups.
+func (s *Service) GetScope(ctx context.Context) (*Scope, error) {
+	metadata, err := s.GetMetadataCached(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups *kmsg.ListGroupsResponse
+	if s.Cfg.ConsumerGroups.Enabled {
+		groups, err = s.ListConsumerGroupsCached(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.scopeFromResponses(metadata, groups), nil
+}
+
+// scopeFromResponses applies the topic and consumer group filters to the given responses. Groups may be nil if
+// consumer groups are not monitored.
+func (s *Service) scopeFromResponses(metadata *kmsg.MetadataResponse, groups *kmsg.ListGroupsResponse) *Scope {
+	scope := &Scope{
+		Topics:         make([]ScopeTopic, 0),
+		ConsumerGroups: make([]string, 0),
+	}
+	for _, topic := range metadata.Topics {
+		if kerr.ErrorForCode(topic.ErrorCode) != nil || !s.IsTopicAllowed(topic.Topic) {
+			continue
+		}
+		scope.Topics = append(scope.Topics, ScopeTopic{TopicName: topic.Topic, PartitionCount: len(topic.Partitions)})
+	}
+	sort.Slice(scope.Topics, func(i, j int) bool { return scope.Topics[i].TopicName < scope.Topics[j].TopicName })
+
+	if groups != nil {
+		for _, group := range groups.Groups {
+			if s.IsGroupAllowed(group.Group) {
+				scope.ConsumerGroups = append(scope.ConsumerGroups, group.Group)
+			}
+		}
+		sort.Strings(scope.ConsumerGroups)
+	}
+
+	return scope
+}
+
+// HandleScope responds with the currently monitored topics and consumer groups as JSON.
+func (s *Service) HandleScope(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	ctx = context.WithValue(ctx, "requestId", uuid.New().String())
+
+	scope, err := s.GetScope(ctx)
+	if err != nil {
+		s.logger.Warn("failed to get monitored scope", zap.Error(err))
+		http.Error(w, "failed to get monitored scope: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(scope)
+	if err != nil {
+		s.logger.Warn("failed to encode monitored scope", zap.Error(err))
+	}
+}
@@ -0,0 +1,98 @@
+package minion
+
+import (
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"reflect"
+	"testing"
+)
+
+func TestScopeFromResponses(t *testing.T) {
+	metadata := &kmsg.MetadataResponse{
+		Topics: []kmsg.MetadataResponseTopic{
+			{Topic: "orders", Partitions: make([]kmsg.MetadataResponseTopicPartition, 6)},
+			{Topic: "orders-dlq", Partitions: make([]kmsg.MetadataResponseTopicPartition, 1)},
+			{Topic: "_schemas", Partitions: make([]kmsg.MetadataResponseTopicPartition, 1)},
+			{Topic: "invoices", Partitions: make([]kmsg.MetadataResponseTopicPartition, 3)},
+			{Topic: "secret", ErrorCode: kerr.TopicAuthorizationFailed.Code},
+		},
+	}
+	groups := &kmsg.ListGroupsResponse{
+		Groups: []kmsg.ListGroupsResponseGroup{
+			{Group: "orders-service"},
+			{Group: "console-consumer-123"},
+			{Group: "billing"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		cfg            Config
+		groups         *kmsg.ListGroupsResponse
+		expectedTopics []ScopeTopic
+		expectedGroups []string
+	}{
+		{
+			name: "all topics and groups",
+			cfg: Config{
+				Topics:         TopicConfig{AllowedTopics: []string{"/.*/"}},
+				ConsumerGroups: ConsumerGroupConfig{AllowedGroupIDs: []string{"/.*/"}},
+			},
+			groups: groups,
+			expectedTopics: []ScopeTopic{
+				{"_schemas", 1}, {"invoices", 3}, {"orders", 6}, {"orders-dlq", 1},
+			},
+			expectedGroups: []string{"billing", "console-consumer-123", "orders-service"},
+		},
+		{
+			name: "allowed and ignored filters",
+			cfg: Config{
+				Topics: TopicConfig{
+					AllowedTopics: []string{"/orders.*/", "invoices"},
+					IgnoredTopics: []string{"/.*-dlq/"},
+				},
+				ConsumerGroups: ConsumerGroupConfig{
+					AllowedGroupIDs: []string{"/.*/"},
+					IgnoredGroupIDs: []string{"/console-consumer-.*/"},
+				},
+			},
+			groups:         groups,
+			expectedTopics: []ScopeTopic{{"invoices", 3}, {"orders", 6}},
+			expectedGroups: []string{"billing", "orders-service"},
+		},
+		{
+			name: "consumer groups not monitored",
+			cfg: Config{
+				Topics:         TopicConfig{AllowedTopics: []string{"orders"}},
+				ConsumerGroups: ConsumerGroupConfig{AllowedGroupIDs: []string{"/.*/"}},
+			},
+			groups:         nil,
+			expectedTopics: []ScopeTopic{{"orders", 6}},
+			expectedGroups: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allowedTopicsExpr, _ := compileRegexes(test.cfg.Topics.AllowedTopics)
+			ignoredTopicsExpr, _ := compileRegexes(test.cfg.Topics.IgnoredTopics)
+			allowedGroupIDsExpr, _ := compileRegexes(test.cfg.ConsumerGroups.AllowedGroupIDs)
+			ignoredGroupIDsExpr, _ := compileRegexes(test.cfg.ConsumerGroups.IgnoredGroupIDs)
+			svc := &Service{
+				Cfg:                 test.cfg,
+				AllowedTopicsExpr:   allowedTopicsExpr,
+				IgnoredTopicsExpr:   ignoredTopicsExpr,
+				AllowedGroupIDsExpr: allowedGroupIDsExpr,
+				IgnoredGroupIDsExpr: ignoredGroupIDsExpr,
+			}
+
+			scope := svc.scopeFromResponses(metadata, test.groups)
+			if !reflect.DeepEqual(scope.Topics, test.expectedTopics) {
+				t.Errorf("expected topics %v, got %v", test.expectedTopics, scope.Topics)
+			}
+			if !reflect.DeepEqual(scope.ConsumerGroups, test.expectedGroups) {
+				t.Errorf("expected consumer groups %v, got %v", test.expectedGroups, scope.ConsumerGroups)
+			}
+		})
+	}
+}
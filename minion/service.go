@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/cloudhut/kminion/v2/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/kversion"
 	"go.uber.org/zap"
@@ -24,14 +26,49 @@ type Service struct {
 
 	AllowedGroupIDsExpr []*regexp.Regexp
 	IgnoredGroupIDsExpr []*regexp.Regexp
+	RequiredTopicsExpr  []*regexp.Regexp
 	AllowedTopicsExpr   []*regexp.Regexp
-	IgnoredTopicsExpr   []*regexp.Regexp
-
-	kafkaSvc *kafka.Service
-	storage  *Storage
+	// allowedTopicPartitions are the selected partitions for each of the allowed topics expressions. It's nil for
+	// expressions which select all partitions.
+	allowedTopicPartitions []map[int32]struct{}
+	IgnoredTopicsExpr      []*regexp.Regexp
+	TopicOverridesExpr     []*regexp.Regexp
+
+	// topicsCreatedAfter is the parsed minion.topics.createdAfter cutoff. It's the zero time if no cutoff is set.
+	topicsCreatedAfter time.Time
+	topicCreationTimes *topicCreationTimes
+
+	kafkaSvc     *kafka.Service
+	storage      *Storage
+	ingestDelays *ingestDelayStorage
+	transactions *transactionStorage
+
+	// apiVersions are fetched at startup and again after brokers have been reconnected. apiVersionsConnects is the
+	// number of successful broker connections at the time the api versions have been fetched.
+	apiVersionsLock     sync.Mutex
+	apiVersions         *kmsg.ApiVersionsResponse
+	apiVersionsConnects uint64
+
+	// groupRequestLimiter bounds the number of concurrent OffsetFetch and DescribeGroups requests
+	groupRequestLimiter *groupRequestLimiter
+
+	// roundtripStatus is the result of the most recent produce and consume roundtrip
+	roundtripStatus *roundtripStatus
+	// roundtripLatency observes the duration of successful roundtrips, with the roundtrip record's id as exemplar
+	roundtripLatency prometheus.Histogram
+
+	// offsetsTopicPartitions are the partitions of the __consumer_offsets topic that are consumed. All partitions are
+	// consumed if this is nil.
+	offsetsTopicPartitions map[int32]struct{}
+
+	// preflightOk is true if the startup preflight has verified all required permissions
+	preflightOk bool
+
+	// missingConfiguredTopics are the topics allowed by their literal name that did not exist at startup
+	missingConfiguredTopics []string
 }
 
-func NewService(cfg Config, logger *zap.Logger, kafkaSvc *kafka.Service) (*Service, error) {
+func NewService(cfg Config, logger *zap.Logger, kafkaSvc *kafka.Service, metricsNamespace string, registerer prometheus.Registerer) (*Service, error) {
 	storage, err := newStorage(logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage: %w", err)
@@ -40,8 +77,46 @@ func NewService(cfg Config, logger *zap.Logger, kafkaSvc *kafka.Service) (*Servi
 	// Compile regexes. We can ignore the errors because valid compilation has been validated already
 	allowedGroupIDsExpr, _ := compileRegexes(cfg.ConsumerGroups.AllowedGroupIDs)
 	ignoredGroupIDsExpr, _ := compileRegexes(cfg.ConsumerGroups.IgnoredGroupIDs)
-	allowedTopicsExpr, _ := compileRegexes(cfg.Topics.AllowedTopics)
+	requiredTopicsExpr, _ := compileRegexes(cfg.ConsumerGroups.RequireTopics)
+	allowedTopicsExpr := make([]*regexp.Regexp, len(cfg.Topics.AllowedTopics))
+	allowedTopicPartitions := make([]map[int32]struct{}, len(cfg.Topics.AllowedTopics))
+	for i, allowedTopic := range cfg.Topics.AllowedTopics {
+		topicExpr, partitions, _ := splitTopicPartitionFilter(allowedTopic)
+		allowedTopicsExpr[i], _ = compileRegex(topicExpr)
+		allowedTopicPartitions[i] = partitions
+	}
 	ignoredTopicsExpr, _ := compileRegexes(cfg.Topics.IgnoredTopics)
+	topicOverridesExpr := make([]*regexp.Regexp, len(cfg.Topics.Overrides))
+	for i, override := range cfg.Topics.Overrides {
+		topicOverridesExpr[i], _ = compileRegex(override.Match)
+	}
+
+	// The timestamp has been validated already
+	var topicsCreatedAfter time.Time
+	if cfg.Topics.CreatedAfter != "" {
+		topicsCreatedAfter, _ = time.Parse(time.RFC3339, cfg.Topics.CreatedAfter)
+	}
+
+	groupRequestsInFlight := promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "kafka",
+		Name:      "consumer_group_requests_in_flight",
+		Help:      "The number of OffsetFetch and DescribeGroups requests that are currently in flight",
+	})
+
+	// The roundtrip latency is only registered if roundtrips are enabled, so that no empty histogram is exported
+	roundtripRegisterer := registerer
+	if !cfg.Roundtrip.Enabled {
+		roundtripRegisterer = nil
+	}
+	roundtripLatency := promauto.With(roundtripRegisterer).NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "end_to_end",
+		Name:      "roundtrip_latency_seconds",
+		Help: "The duration of successful roundtrips from producing a record until it has been consumed. The id of " +
+			"the roundtrip record is attached as exemplar.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+	})
 
 	return &Service{
 		Cfg:    cfg,
@@ -51,13 +126,25 @@ func NewService(cfg Config, logger *zap.Logger, kafkaSvc *kafka.Service) (*Servi
 		cache:        make(map[string]interface{}),
 		cacheLock:    sync.RWMutex{},
 
-		AllowedGroupIDsExpr: allowedGroupIDsExpr,
-		IgnoredGroupIDsExpr: ignoredGroupIDsExpr,
-		AllowedTopicsExpr:   allowedTopicsExpr,
-		IgnoredTopicsExpr:   ignoredTopicsExpr,
-
-		kafkaSvc: kafkaSvc,
-		storage:  storage,
+		AllowedGroupIDsExpr:    allowedGroupIDsExpr,
+		IgnoredGroupIDsExpr:    ignoredGroupIDsExpr,
+		RequiredTopicsExpr:     requiredTopicsExpr,
+		AllowedTopicsExpr:      allowedTopicsExpr,
+		allowedTopicPartitions: allowedTopicPartitions,
+		IgnoredTopicsExpr:      ignoredTopicsExpr,
+		TopicOverridesExpr:     topicOverridesExpr,
+
+		topicsCreatedAfter: topicsCreatedAfter,
+		topicCreationTimes: newTopicCreationTimes(),
+
+		kafkaSvc:     kafkaSvc,
+		storage:      storage,
+		ingestDelays: newIngestDelayStorage(),
+		transactions: newTransactionStorage(),
+
+		groupRequestLimiter: newGroupRequestLimiter(cfg.ConsumerGroups.MaxConcurrentFetches, groupRequestsInFlight),
+		roundtripStatus:     &roundtripStatus{},
+		roundtripLatency:    roundtripLatency,
 	}, nil
 }
 
@@ -66,18 +153,40 @@ func (s *Service) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to check feature compatibility against Kafka: %w", err)
 	}
+	s.runPreflight(ctx)
+
+	err = s.checkConfiguredTopics(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify configured topics: %w", err)
+	}
 
-	if s.Cfg.ConsumerGroups.ScrapeMode == ConsumerGroupScrapeModeOffsetsTopic {
+	if s.Cfg.ConsumerGroups.Enabled && s.Cfg.ConsumerGroups.ScrapeMode == ConsumerGroupScrapeModeOffsetsTopic {
 		go s.startConsumingOffsets(ctx)
 	}
 
+	if s.Cfg.IngestDelay.Enabled {
+		go s.startMeasuringIngestDelay(ctx)
+	}
+
+	if s.Cfg.Roundtrip.Enabled {
+		go s.startRoundtrips(ctx)
+	}
+
+	if s.Cfg.Transactions.Enabled {
+		go s.startConsumingTransactionState(ctx)
+	}
+
+	if !s.topicsCreatedAfter.IsZero() {
+		go s.startTrackingTopicCreationTimes(ctx)
+	}
+
 	return nil
 }
 
 func (s *Service) ensureCompatibility(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
-	versionsRes, err := s.GetAPIVersions(ctx)
+	versionsRes, err := s.GetAPIVersionsCached(ctx)
 	if err != nil {
 		return fmt.Errorf("kafka api versions couldn't be fetched: %w", err)
 	}
@@ -117,6 +226,20 @@ func (s *Service) setCachedItem(key string, val interface{}, timeout time.Durati
 	s.cache[key] = val
 }
 
+// GetCacheEntryCounts returns the number of entries in each of the service's internal caches, indexed by cache name.
+func (s *Service) GetCacheEntryCounts() map[string]int {
+	s.cacheLock.RLock()
+	requestCacheEntries := len(s.cache)
+	s.cacheLock.RUnlock()
+
+	return map[string]int{
+		"requests":          requestCacheEntries,
+		"offset_commits":    s.storage.offsetCommits.Count(),
+		"group_generations": s.storage.groupGenerations.Count(),
+		"offsets_progress":  s.storage.progressTracker.Count(),
+	}
+}
+
 func (s *Service) deleteCachedItem(key string) {
 	s.cacheLock.Lock()
 	defer s.cacheLock.Unlock()
@@ -18,6 +18,9 @@ type Storage struct {
 	// offsetCommits is a map of all consumer offsets. A unique key in the format "group:topic:partition" is used as map key.
 	offsetCommits cmap.ConcurrentMap
 
+	// groupGenerations is a map of the latest generation id of each consumer group, indexed by group id
+	groupGenerations cmap.ConcurrentMap
+
 	// progressTracker is a map that tracks what offsets in each partition have already been consumed
 	progressTracker cmap.ConcurrentMap
 
@@ -41,11 +44,12 @@ type OffsetCommit struct {
 
 func newStorage(logger *zap.Logger) (*Storage, error) {
 	return &Storage{
-		logger:          logger,
-		offsetCommits:   cmap.New(),
-		progressTracker: cmap.New(),
-		isReadyBool:     atomic.NewBool(false),
-		consumedRecords: atomic.NewFloat64(0),
+		logger:           logger,
+		offsetCommits:    cmap.New(),
+		groupGenerations: cmap.New(),
+		progressTracker:  cmap.New(),
+		isReadyBool:      atomic.NewBool(false),
+		consumedRecords:  atomic.NewFloat64(0),
 	}, nil
 }
 
@@ -132,6 +136,28 @@ func (s *Storage) getGroupOffsets() map[string]map[string]map[int32]OffsetCommit
 	return offsetsByGroup
 }
 
+func (s *Storage) setGroupGeneration(group string, generation int32) {
+	s.groupGenerations.Set(group, generation)
+}
+
+func (s *Storage) deleteGroupGeneration(group string) {
+	s.groupGenerations.Remove(group)
+}
+
+func (s *Storage) getGroupGenerations() map[string]int32 {
+	generationsByGroup := make(map[string]int32)
+
+	if !s.isReady() {
+		return generationsByGroup
+	}
+
+	for group, generation := range s.groupGenerations.Items() {
+		generationsByGroup[group] = generation.(int32)
+	}
+
+	return generationsByGroup
+}
+
 func (s *Storage) deleteOffsetCommit(key kmsg.OffsetCommitKey) {
 	uniqueKey := encodeOffsetCommitKey(key)
 	s.offsetCommits.Remove(uniqueKey)
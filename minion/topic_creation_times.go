@@ -0,0 +1,101 @@
+package minion
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// topicCreationTimesRefreshInterval specifies how often the creation times of all topics are derived again
+const topicCreationTimesRefreshInterval = time.Minute
+
+// topicCreationTimes contains the creation time of each topic, as far as it could be derived. Kafka does not expose
+// the creation time of topics, therefore it's derived from the timestamp of the oldest message. This is only
+// reliable as long as no data has been removed from the topic, so that the creation time is unknown for compacted
+// topics, topics whose low water marks have advanced due to retention and empty topics.
+type topicCreationTimes struct {
+	mutex     sync.RWMutex
+	hasResult bool
+	createdAt map[string]time.Time
+	unknown   map[string]struct{}
+}
+
+func newTopicCreationTimes() *topicCreationTimes {
+	return &topicCreationTimes{
+		createdAt: make(map[string]time.Time),
+		unknown:   make(map[string]struct{}),
+	}
+}
+
+// isCreatedAfter returns whether the topic has been created after the given cutoff. Topics whose creation time is
+// unknown are considered to be created after the cutoff, so that they are still monitored.
+func (t *topicCreationTimes) isCreatedAfter(topicName string, cutoff time.Time) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	createdAt, exists := t.createdAt[topicName]
+	if !t.hasResult || !exists {
+		return true
+	}
+	return createdAt.After(cutoff)
+}
+
+// set replaces all creation times and returns the topics whose creation time has not been unknown before
+func (t *topicCreationTimes) set(createdAt map[string]time.Time, unknown map[string]struct{}) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	newlyUnknown := make([]string, 0)
+	for topicName := range unknown {
+		if _, wasUnknown := t.unknown[topicName]; !wasUnknown {
+			newlyUnknown = append(newlyUnknown, topicName)
+		}
+	}
+
+	t.hasResult = true
+	t.createdAt = createdAt
+	t.unknown = unknown
+	return newlyUnknown
+}
+
+// startTrackingTopicCreationTimes derives the creation times of all topics on each refresh interval, so that topics
+// created before the configured cutoff can be excluded.
+func (s *Service) startTrackingTopicCreationTimes(ctx context.Context) {
+	s.logger.Info("only topics created after the cutoff will be monitored",
+		zap.Time("created_after", s.topicsCreatedAfter))
+	ticker := time.NewTicker(topicCreationTimesRefreshInterval)
+	defer ticker.Stop()
+	for {
+		refreshCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		refreshCtx = context.WithValue(refreshCtx, "requestId", uuid.New().String())
+		createdAt, unknown, err := s.deriveTopicCreationTimes(refreshCtx)
+		cancel()
+		if err != nil {
+			s.logger.Warn("failed to derive topic creation times", zap.Error(err))
+		} else {
+			for _, topicName := range s.topicCreationTimes.set(createdAt, unknown) {
+				s.logger.Warn("creation time of topic is unknown, it will be monitored regardless of its age",
+					zap.String("topic_name", topicName))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deriveTopicCreationTimes returns the creation times of all topics whose creation time can be derived from their
+// oldest message, along with all topics whose creation time is unknown.
+func (s *Service) deriveTopicCreationTimes(ctx context.Context) (map[string]time.Time, map[string]struct{}, error) {
+	oldestMessages, err := s.GetOldestMessages(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return oldestMessages.Timestamps, oldestMessages.Unknown, nil
+}
@@ -0,0 +1,76 @@
+package minion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// partitionRangeExpr matches a partition selection such as "0-3,7"
+var partitionRangeExpr = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+
+// splitTopicPartitionFilter splits an allowed topic string in the format "topic:partitionRange" (e.g. "orders:0-3,7"
+// or "/orders-.*/:0-3") into the topic expression and the selected partitions. Topic names can't contain colons,
+// hence only a suffix that looks like a partition range is considered a partition selection. The returned partitions
+// are nil if all partitions are selected.
+func splitTopicPartitionFilter(expr string) (string, map[int32]struct{}, error) {
+	separatorIndex := strings.LastIndex(expr, ":")
+	if separatorIndex < 0 || !partitionRangeExpr.MatchString(expr[separatorIndex+1:]) {
+		return expr, nil, nil
+	}
+
+	partitions := make(map[int32]struct{})
+	for _, partitionRange := range strings.Split(expr[separatorIndex+1:], ",") {
+		bounds := strings.SplitN(partitionRange, "-", 2)
+		start, err := strconv.ParseInt(bounds[0], 10, 32)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid partition id '%v': %w", bounds[0], err)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseInt(bounds[1], 10, 32)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid partition id '%v': %w", bounds[1], err)
+			}
+		}
+		if end < start {
+			return "", nil, fmt.Errorf("invalid partition range '%v', the end must not be lower than the start", partitionRange)
+		}
+		for partitionID := start; partitionID <= end; partitionID++ {
+			partitions[int32(partitionID)] = struct{}{}
+		}
+	}
+
+	return expr[:separatorIndex], partitions, nil
+}
+
+// HasPartitionSelection returns whether any of the allowed topic strings selects specific partitions.
+func (s *Service) HasPartitionSelection() bool {
+	for _, partitions := range s.allowedTopicPartitions {
+		if partitions != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPartitionSelected returns whether the watermarks and lags of the given partition shall be collected. Partitions
+// are only deselected if all allowed topic strings that match the topic select specific partitions.
+func (s *Service) IsPartitionSelected(topicName string, partitionID int32) bool {
+	hasSelection := false
+	for i, regex := range s.AllowedTopicsExpr {
+		if !regex.MatchString(topicName) {
+			continue
+		}
+		partitions := s.allowedTopicPartitions[i]
+		if partitions == nil {
+			return true
+		}
+		hasSelection = true
+		if _, isSelected := partitions[partitionID]; isSelected {
+			return true
+		}
+	}
+	return !hasSelection
+}
@@ -0,0 +1,65 @@
+package minion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTopicPartitionFilter(t *testing.T) {
+	tt := []struct {
+		expr       string
+		topicExpr  string
+		partitions []int32
+		hasError   bool
+	}{
+		{expr: "orders", topicExpr: "orders"},
+		{expr: "/orders-.*/", topicExpr: "/orders-.*/"},
+		{expr: "orders:1,2", topicExpr: "orders", partitions: []int32{1, 2}},
+		{expr: "orders:0-3,7", topicExpr: "orders", partitions: []int32{0, 1, 2, 3, 7}},
+		{expr: "orders:5-5", topicExpr: "orders", partitions: []int32{5}},
+		{expr: "/orders-.*/:0-1", topicExpr: "/orders-.*/", partitions: []int32{0, 1}},
+		// Colons within a regex are part of the topic expression, only the last one may separate the partitions
+		{expr: "/re:x/", topicExpr: "/re:x/"},
+		{expr: "/re:x/:3", topicExpr: "/re:x/", partitions: []int32{3}},
+		// Suffixes that don't look like partition ranges are part of the topic expression
+		{expr: "orders:", topicExpr: "orders:"},
+		{expr: "orders:,", topicExpr: "orders:,"},
+		{expr: "orders:1,", topicExpr: "orders:1,"},
+		{expr: "orders:-1", topicExpr: "orders:-1"},
+		{expr: "orders:a-b", topicExpr: "orders:a-b"},
+		// Bad ranges
+		{expr: "orders:3-1", hasError: true},
+		{expr: "orders:0,7-2", hasError: true},
+		{expr: "orders:2147483648", hasError: true},
+		{expr: "orders:0-2147483648", hasError: true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.expr, func(t *testing.T) {
+			topicExpr, partitions, err := splitTopicPartitionFilter(test.expr)
+			if test.hasError {
+				if err == nil {
+					t.Fatalf("expected an error, got topic expression '%v' and partitions %v", topicExpr, partitions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if topicExpr != test.topicExpr {
+				t.Errorf("expected topic expression '%v', got '%v'", test.topicExpr, topicExpr)
+			}
+
+			var expectedPartitions map[int32]struct{}
+			if test.partitions != nil {
+				expectedPartitions = make(map[int32]struct{})
+				for _, partitionID := range test.partitions {
+					expectedPartitions[partitionID] = struct{}{}
+				}
+			}
+			if !reflect.DeepEqual(partitions, expectedPartitions) {
+				t.Errorf("expected partitions %v, got %v", expectedPartitions, partitions)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package minion
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kbin"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	transactionStateTopic = "__transaction_state"
+
+	// transactionStateOngoing is the state of open transactions in the __transaction_state topic
+	transactionStateOngoing int8 = 1
+)
+
+// transactionStorage contains the start time of every open transaction, indexed by transactional id. It's only
+// ready once the __transaction_state topic has been consumed up to the end offsets it had at startup, as older
+// records may report transactions as open which have been completed since.
+type transactionStorage struct {
+	mutex      sync.RWMutex
+	isReady    bool
+	startTimes map[string]time.Time
+
+	// endOffsets are the end offsets of the __transaction_state partitions at startup. Partitions are removed once
+	// they have been consumed up to their end offset.
+	endOffsets map[int32]int64
+}
+
+func newTransactionStorage() *transactionStorage {
+	return &transactionStorage{startTimes: make(map[string]time.Time)}
+}
+
+func (t *transactionStorage) setEndOffsets(endOffsets map[int32]int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.endOffsets = endOffsets
+	t.checkReady()
+}
+
+func (t *transactionStorage) markRecordConsumed(partitionID int32, offset int64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if endOffset, exists := t.endOffsets[partitionID]; exists && offset+1 >= endOffset {
+		delete(t.endOffsets, partitionID)
+		t.checkReady()
+	}
+}
+
+// checkReady marks the storage as ready once all partitions have been consumed up to their end offsets. It must be
+// called with the lock held.
+func (t *transactionStorage) checkReady() {
+	if t.isReady || t.endOffsets == nil || len(t.endOffsets) > 0 {
+		return
+	}
+	t.isReady = true
+}
+
+func (t *transactionStorage) setOpen(transactionalID string, startTime time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.startTimes[transactionalID] = startTime
+}
+
+func (t *transactionStorage) deleteOpen(transactionalID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.startTimes, transactionalID)
+}
+
+// startConsumingTransactionState consumes the __transaction_state topic from the start and keeps track of all open
+// transactions. It uses a separate client, as the shared client may already be consuming the offsets topic.
+func (s *Service) startConsumingTransactionState(ctx context.Context) {
+	client, err := s.kafkaSvc.NewClient()
+	if err != nil {
+		s.logger.Error("failed to create kafka client for consuming the transaction state", zap.Error(err))
+		return
+	}
+	defer client.Close()
+
+	endOffsets, err := s.listTransactionStateEndOffsets(ctx, client)
+	if err != nil {
+		s.logger.Error("failed to list the end offsets of the transaction state topic", zap.Error(err))
+		return
+	}
+	s.transactions.setEndOffsets(endOffsets)
+
+	client.AssignPartitions(kgo.ConsumeTopics(kgo.NewOffset().AtStart(), transactionStateTopic))
+	s.logger.Info("starting to consume messages from transaction state topic")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			fetches := client.PollFetches(ctx)
+			for _, err := range fetches.Errors() {
+				s.logger.Warn("failed to fetch records from the transaction state topic",
+					zap.Int32("partition", err.Partition),
+					zap.Error(err.Err))
+			}
+
+			iter := fetches.RecordIter()
+			for !iter.Done() {
+				record := iter.Next()
+				err := s.decodeTransactionStateRecord(record)
+				if err != nil {
+					s.logger.Warn("failed to decode transaction state record", zap.Error(err))
+				}
+				s.transactions.markRecordConsumed(record.Partition, record.Offset)
+			}
+		}
+	}
+}
+
+// listTransactionStateEndOffsets returns the end offsets of all __transaction_state partitions, indexed by partition
+// id.
+func (s *Service) listTransactionStateEndOffsets(ctx context.Context, client *kgo.Client) (map[int32]int64, error) {
+	metadataReq := kmsg.NewMetadataRequest()
+	metadataReqTopic := kmsg.NewMetadataRequestTopic()
+	topicName := transactionStateTopic
+	metadataReqTopic.Topic = &topicName
+	metadataReq.Topics = []kmsg.MetadataRequestTopic{metadataReqTopic}
+	metadataRes, err := metadataReq.RequestWith(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request metadata: %w", err)
+	}
+	if len(metadataRes.Topics) != 1 {
+		return nil, fmt.Errorf("expected exactly one topic in the metadata response")
+	}
+	err = kerr.ErrorForCode(metadataRes.Topics[0].ErrorCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata of topic: %w", err)
+	}
+
+	reqTopic := kmsg.NewListOffsetsRequestTopic()
+	reqTopic.Topic = transactionStateTopic
+	for _, partition := range metadataRes.Topics[0].Partitions {
+		reqPartition := kmsg.NewListOffsetsRequestTopicPartition()
+		reqPartition.Partition = partition.Partition
+		reqPartition.Timestamp = -1 // Newest
+		reqTopic.Partitions = append(reqTopic.Partitions, reqPartition)
+	}
+	req := kmsg.NewListOffsetsRequest()
+	req.Topics = []kmsg.ListOffsetsRequestTopic{reqTopic}
+	res, err := req.RequestWith(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets: %w", err)
+	}
+
+	endOffsets := make(map[int32]int64)
+	for _, topic := range res.Topics {
+		for _, partition := range topic.Partitions {
+			err := kerr.ErrorForCode(partition.ErrorCode)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list offsets of partition %d: %w", partition.Partition, err)
+			}
+			// Empty partitions have nothing to catch up on
+			if partition.Offset > 0 {
+				endOffsets[partition.Partition] = partition.Offset
+			}
+		}
+	}
+
+	return endOffsets, nil
+}
+
+// decodeTransactionStateRecord decodes the transaction metadata of a transactional id and remembers the start time of
+// the transaction if it's still open.
+func (s *Service) decodeTransactionStateRecord(record *kgo.Record) error {
+	if len(record.Key) < 2 {
+		return fmt.Errorf("transaction state key is supposed to be at least 2 bytes long")
+	}
+	// Only key version 0 contains transaction metadata
+	if (&kbin.Reader{Src: record.Key}).Int16() != 0 {
+		return nil
+	}
+
+	key := kmsg.NewTxnMetadataKey()
+	err := key.ReadFrom(record.Key)
+	if err != nil {
+		return fmt.Errorf("failed to decode transaction metadata key: %w", err)
+	}
+
+	if record.Value == nil {
+		// Tombstone - The transactional id has expired
+		s.transactions.deleteOpen(key.TransactionalID)
+		return nil
+	}
+	value := kmsg.NewTxnMetadataValue()
+	err = value.ReadFrom(record.Value)
+	if err != nil {
+		return fmt.Errorf("failed to decode transaction metadata value: %w", err)
+	}
+
+	if value.State != transactionStateOngoing {
+		s.transactions.deleteOpen(key.TransactionalID)
+		return nil
+	}
+	s.transactions.setOpen(key.TransactionalID, time.Unix(0, value.StartTimestamp*int64(time.Millisecond)))
+
+	return nil
+}
+
+// GetOldestOpenTransactionStart returns the start time of the oldest open transaction. The second return value is
+// false if there is no open transaction, the third one is false if the transaction state topic has not been consumed
+// up to its end yet.
+func (s *Service) GetOldestOpenTransactionStart() (time.Time, bool, bool) {
+	s.transactions.mutex.RLock()
+	defer s.transactions.mutex.RUnlock()
+
+	if !s.transactions.isReady {
+		return time.Time{}, false, false
+	}
+
+	var oldest time.Time
+	hasOpen := false
+	for _, startTime := range s.transactions.startTimes {
+		if !hasOpen || startTime.Before(oldest) {
+			oldest = startTime
+			hasOpen = true
+		}
+	}
+
+	return oldest, hasOpen, true
+}
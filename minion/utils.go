@@ -7,6 +7,10 @@ import (
 )
 
 func (s *Service) IsGroupAllowed(groupName string) bool {
+	if !s.hasAllowedGroupPrefix(groupName) {
+		return false
+	}
+
 	isAllowed := false
 	for _, regex := range s.AllowedGroupIDsExpr {
 		if regex.MatchString(groupName) {
@@ -24,6 +28,50 @@ func (s *Service) IsGroupAllowed(groupName string) bool {
 	return isAllowed
 }
 
+// hasAllowedGroupPrefix returns whether the group id starts with one of the allowed group prefixes. All groups are
+// considered allowed if no prefixes are configured.
+func (s *Service) hasAllowedGroupPrefix(groupName string) bool {
+	if len(s.Cfg.ConsumerGroups.AllowedGroupPrefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range s.Cfg.ConsumerGroups.AllowedGroupPrefixes {
+		if strings.HasPrefix(groupName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRequiredTopic returns whether the topic is one of the topics a group must have committed offsets on, so that its
+// lags are exported. All topics are considered required if no required topics are configured.
+func (s *Service) IsRequiredTopic(topicName string) bool {
+	if len(s.RequiredTopicsExpr) == 0 {
+		return true
+	}
+
+	for _, regex := range s.RequiredTopicsExpr {
+		if regex.MatchString(topicName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGroupStateIncluded returns whether lags shall be exported for consumer groups in the given state.
+func (s *Service) IsGroupStateIncluded(state string) bool {
+	if len(s.Cfg.ConsumerGroups.IncludeStates) == 0 {
+		return true
+	}
+
+	for _, includedState := range s.Cfg.ConsumerGroups.IncludeStates {
+		if strings.EqualFold(includedState, state) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Service) IsTopicAllowed(topicName string) bool {
 	isAllowed := false
 	for _, regex := range s.AllowedTopicsExpr {
@@ -39,9 +87,51 @@ func (s *Service) IsTopicAllowed(topicName string) bool {
 			break
 		}
 	}
+
+	if isAllowed && !s.topicsCreatedAfter.IsZero() {
+		isAllowed = s.topicCreationTimes.isCreatedAfter(topicName, s.topicsCreatedAfter)
+	}
 	return isAllowed
 }
 
+// getTopicOverride returns the first topic override that matches the given topic name
+func (s *Service) getTopicOverride(topicName string) (TopicOverrideConfig, bool) {
+	for i, regex := range s.TopicOverridesExpr {
+		if regex.MatchString(topicName) {
+			return s.Cfg.Topics.Overrides[i], true
+		}
+	}
+	return TopicOverrideConfig{}, false
+}
+
+// GetTopicGranularity returns the granularity of the topic metrics for the given topic, considering topic overrides.
+func (s *Service) GetTopicGranularity(topicName string) string {
+	override, exists := s.getTopicOverride(topicName)
+	if exists && override.Granularity != "" {
+		return override.Granularity
+	}
+	return s.Cfg.Topics.Granularity
+}
+
+// GetConsumerGroupGranularity returns the granularity of consumer group lags on the given topic, considering topic
+// overrides.
+func (s *Service) GetConsumerGroupGranularity(topicName string) string {
+	override, exists := s.getTopicOverride(topicName)
+	if exists && override.Granularity != "" {
+		return override.Granularity
+	}
+	return s.Cfg.ConsumerGroups.Granularity
+}
+
+// IsTopicConfigCollected returns whether the topic configs of the given topic shall be described.
+func (s *Service) IsTopicConfigCollected(topicName string) bool {
+	override, exists := s.getTopicOverride(topicName)
+	if exists && override.CollectConfig != nil {
+		return *override.CollectConfig
+	}
+	return true
+}
+
 func compileRegex(expr string) (*regexp.Regexp, error) {
 	if strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/") {
 		substr := expr[1 : len(expr)-1]
@@ -18,11 +18,33 @@ func (s *Service) GetClusterVersion(ctx context.Context) (string, error) {
 	return versions.VersionGuess(), nil
 }
 
+// GetAPIVersionsCached returns the api versions that have been fetched at startup or after the most recent broker
+// reconnect. The api versions are fetched again if a broker connection has been established since.
+func (s *Service) GetAPIVersionsCached(ctx context.Context) (*kmsg.ApiVersionsResponse, error) {
+	connects := s.kafkaSvc.SuccessfulConnects()
+
+	s.apiVersionsLock.Lock()
+	defer s.apiVersionsLock.Unlock()
+	if s.apiVersions != nil && s.apiVersionsConnects == connects {
+		return s.apiVersions, nil
+	}
+
+	res, err := s.GetAPIVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.apiVersions = res
+	// The request itself may have established a new connection, which must not trigger another fetch
+	s.apiVersionsConnects = s.kafkaSvc.SuccessfulConnects()
+
+	return res, nil
+}
+
 func (s *Service) GetAPIVersions(ctx context.Context) (*kmsg.ApiVersionsResponse, error) {
 	versionsReq := kmsg.NewApiVersionsRequest()
 	versionsReq.ClientSoftwareName = "kminion"
 	versionsReq.ClientSoftwareVersion = "v2"
-	res, err := versionsReq.RequestWith(ctx, s.kafkaSvc.Client)
+	res, err := versionsReq.RequestWith(ctx, s.kafkaSvc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request api versions: %w", err)
 	}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/pprof"
+)
+
+// registerPprofHandlers serves the Go runtime profiling endpoints under /debug/pprof, if enabled in the exporter config
+func registerPprofHandlers(mux *http.ServeMux, cfg prometheus.Config, logger *zap.Logger) {
+	if !cfg.Pprof {
+		return
+	}
+
+	logger.Info("pprof endpoints are enabled and served under /debug/pprof")
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegisterPprofHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		pprof          bool
+		expectedStatus int
+	}{
+		{"disabled by default", false, http.StatusNotFound},
+		{"enabled", true, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			registerPprofHandlers(mux, prometheus.Config{Pprof: test.pprof}, zap.NewNop())
+
+			for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/symbol", "/debug/pprof/heap"} {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != test.expectedStatus {
+					t.Errorf("expected status %d for %v, got %d", test.expectedStatus, path, rec.Code)
+				}
+			}
+		})
+	}
+}
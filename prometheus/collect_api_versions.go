@@ -0,0 +1,29 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+func (e *Exporter) collectAPIVersions(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	versions, err := e.minionSvc.GetAPIVersionsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to get api versions", zap.Error(err))
+		return false
+	}
+
+	for _, apiKey := range versions.ApiKeys {
+		ch <- prometheus.MustNewConstMetric(
+			e.apiVersion,
+			prometheus.GaugeValue,
+			1,
+			kmsg.NameForKey(apiKey.ApiKey),
+			strconv.Itoa(int(apiKey.MinVersion)),
+			strconv.Itoa(int(apiKey.MaxVersion)),
+		)
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+func (e *Exporter) collectBrokerConfigs(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	if len(e.minionSvc.Cfg.BrokerConfigs.AllowedConfigs) == 0 {
+		return true
+	}
+
+	brokerConfigsSharded, err := e.minionSvc.DescribeBrokerConfigsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to describe broker configs", zap.Error(err))
+		return false
+	}
+
+	isOk := true
+	for _, shard := range brokerConfigsSharded {
+		if shard.Err != nil {
+			e.logger.Warn("failed to describe the configs of a broker",
+				zap.String("broker_id", strconv.Itoa(int(shard.Broker.NodeID))),
+				zap.Error(shard.Err))
+			isOk = false
+			continue
+		}
+
+		for _, resource := range shard.Configs.Resources {
+			typedErr := kerr.TypedErrorForCode(resource.ErrorCode)
+			if typedErr != nil {
+				e.logger.Warn("failed to describe the configs of a broker",
+					zap.String("broker_id", resource.ResourceName),
+					zap.Error(typedErr))
+				isOk = false
+				continue
+			}
+
+			for _, config := range resource.Configs {
+				confVal := "nil"
+				if config.Value != nil {
+					confVal = *config.Value
+				}
+				ch <- prometheus.MustNewConstMetric(
+					e.brokerConfig,
+					prometheus.GaugeValue,
+					1,
+					e.brokerLabelValues(shard.Broker.Rack, resource.ResourceName, config.Name, confVal)...,
+				)
+			}
+		}
+	}
+	return isOk
+}
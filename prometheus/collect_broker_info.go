@@ -31,6 +31,17 @@ func (e *Exporter) collectBrokerInfo(ctx context.Context, ch chan<- prometheus.M
 			rack,
 			strconv.FormatBool(isController),
 		)
+
+		controller := 0
+		if isController {
+			controller = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			e.brokerIsController,
+			prometheus.GaugeValue,
+			float64(controller),
+			e.brokerLabelValues(broker.Rack, strconv.Itoa(int(broker.NodeID)))...,
+		)
 	}
 
 	return true
@@ -34,5 +34,42 @@ func (e *Exporter) collectClusterInfo(ctx context.Context, ch chan<- prometheus.
 		strconv.Itoa(int(metadata.ControllerID)),
 		clusterID,
 	)
+
+	// Topic and partition counts only include the allowed topics
+	topicCount := 0
+	partitionCount := 0
+	preferredLeaderImbalance := 0
+	for _, topic := range metadata.Topics {
+		if !e.minionSvc.IsTopicAllowed(topic.Topic) {
+			continue
+		}
+		topicCount++
+		partitionCount += len(topic.Partitions)
+		for _, partition := range topic.Partitions {
+			if !isPreferredLeader(partition.Leader, partition.Replicas) {
+				preferredLeaderImbalance++
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		e.topicCount,
+		prometheus.GaugeValue,
+		float64(topicCount),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		e.partitionCount,
+		prometheus.GaugeValue,
+		float64(partitionCount),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		e.preferredLeaderImbalance,
+		prometheus.GaugeValue,
+		float64(preferredLeaderImbalance),
+	)
 	return true
 }
+
+// isPreferredLeader returns whether the leader is the preferred replica, which is the first replica of a partition.
+func isPreferredLeader(leader int32, replicas []int32) bool {
+	return len(replicas) > 0 && replicas[0] == leader
+}
@@ -0,0 +1,98 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"go.uber.org/zap"
+	"math"
+)
+
+// collectConsumerGroupTopicLagBytes reports the estimated number of bytes a consumer group is lagging behind on a
+// topic. The lag of each partition is multiplied with the partition's average record size, which is derived from
+// its log dir size divided by the number of messages in the partition. This is an estimate only, as record sizes vary
+// and compacted partitions contain fewer messages than their offsets suggest.
+func (e *Exporter) collectConsumerGroupTopicLagBytes(ctx context.Context, ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset, marks map[string]map[int32]waterMark) bool {
+	if !e.minionSvc.Cfg.LogDirs.Enabled {
+		return true
+	}
+
+	partitionSizes, isOk := e.partitionSizes(ctx)
+	if !isOk {
+		// Without the sizes of all brokers the estimate would be too low
+		return false
+	}
+
+	for groupName, group := range groupOffsets {
+		for topicName, topic := range group {
+			topicLagBytes := float64(0)
+			hasEstimate := false
+			for partitionID, partition := range topic {
+				partitionMark, exists := marks[topicName][partitionID]
+				if !exists {
+					continue
+				}
+				size, exists := partitionSizes[topicName][partitionID]
+				if !exists {
+					continue
+				}
+				hasEstimate = true
+				lag := math.Max(0, float64(partitionMark.HighWaterMark-partition.Offset))
+				topicLagBytes += lag * averageRecordSize(size, partitionMark)
+			}
+			if !hasEstimate {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.consumerGroupTopicLagBytes,
+				prometheus.GaugeValue,
+				topicLagBytes,
+				groupName,
+				topicName,
+			)
+		}
+	}
+	return true
+}
+
+// averageRecordSize returns the average size of a record in bytes given the partition's size and water marks. Empty
+// partitions report an average size of 0.
+func averageRecordSize(partitionSize int64, mark waterMark) float64 {
+	messageCount := mark.HighWaterMark - mark.LowWaterMark
+	if messageCount <= 0 {
+		return 0
+	}
+	return float64(partitionSize) / float64(messageCount)
+}
+
+// partitionSizes returns the size of each partition in bytes, indexed by topic name and partition id. As each replica
+// reports its own size, the largest replica is used. The returned bool is false if the log dirs of one or more
+// brokers could not be described.
+func (e *Exporter) partitionSizes(ctx context.Context) (map[string]map[int32]int64, bool) {
+	isOk := true
+	sizes := make(map[string]map[int32]int64)
+	for _, logDirRes := range e.minionSvc.DescribeLogDirsCached(ctx) {
+		if logDirRes.Err != nil {
+			e.logger.Warn("failed to describe a broker's log dirs for estimating lags in bytes", zap.Error(logDirRes.Err))
+			isOk = false
+			continue
+		}
+		for _, dir := range logDirRes.LogDirs.Dirs {
+			if kerr.ErrorForCode(dir.ErrorCode) != nil {
+				isOk = false
+				continue
+			}
+			for _, topic := range dir.Topics {
+				if _, exists := sizes[topic.Topic]; !exists {
+					sizes[topic.Topic] = make(map[int32]int64)
+				}
+				for _, partition := range topic.Partitions {
+					if partition.Size > sizes[topic.Topic][partition.Partition] {
+						sizes[topic.Topic][partition.Partition] = partition.Size
+					}
+				}
+			}
+		}
+	}
+	return sizes, isOk
+}
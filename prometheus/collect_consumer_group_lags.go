@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap"
 	"math"
 	"strconv"
+	"time"
 )
 
 type waterMark struct {
@@ -19,8 +20,11 @@ type waterMark struct {
 }
 
 func (e *Exporter) collectConsumerGroupLags(ctx context.Context, ch chan<- prometheus.Metric) bool {
-	// Low Watermarks (at the moment they are not needed at all, they could be used to calculate the lag on partitions
-	// that don't have any active offsets)
+	if !e.minionSvc.Cfg.ConsumerGroups.Enabled {
+		return true
+	}
+
+	// Low Watermarks (used to calculate the lag on assigned partitions that don't have any committed offsets yet)
 	lowWaterMarks, err := e.minionSvc.ListOffsetsCached(ctx, -2)
 	if err != nil {
 		e.logger.Error("failed to fetch low water marks", zap.Error(err))
@@ -29,84 +33,96 @@ func (e *Exporter) collectConsumerGroupLags(ctx context.Context, ch chan<- prome
 	// High Watermarks
 	highWaterMarks, err := e.minionSvc.ListOffsetsCached(ctx, -1)
 	if err != nil {
-		e.logger.Error("failed to fetch low water marks", zap.Error(err))
+		e.logger.Error("failed to fetch high water marks", zap.Error(err))
 		return false
 	}
-	waterMarksByTopic := e.waterMarksByTopic(lowWaterMarks, highWaterMarks)
+	now := time.Now()
+	defer e.topicHistory.evictStaleSamples(now)
+	waterMarksByTopic, deletedTopics := e.waterMarksByTopic(lowWaterMarks, highWaterMarks)
+	e.collectWatermarkErrors(ch, lowWaterMarks, highWaterMarks, deletedTopics, now)
+	if e.minionSvc.Cfg.ConsumerGroups.IsLagUnitExported(minion.ConsumerGroupLagUnitTime) {
+		// Time lags are estimated based on the times at which the high water marks have been observed
+		for _, partitionMarks := range waterMarksByTopic {
+			for _, mark := range partitionMarks {
+				e.topicHistory.observeHighWaterMark(mark.TopicName, mark.PartitionID, mark.HighWaterMark, now)
+			}
+		}
+	}
 
 	// We have two different options to get consumer group offsets - either via the AdminAPI or by consuming the
-	// __consumer_offsets topic.
+	// __consumer_offsets topic. Both are converted into the same structure so that the lags can be calculated the
+	// same way.
+	var groupOffsets map[string]map[string]map[int32]groupPartitionOffset
+	isOk := true
 	if e.minionSvc.Cfg.ConsumerGroups.ScrapeMode == minion.ConsumerGroupScrapeModeAdminAPI {
-		return e.collectConsumerGroupLagsAdminAPI(ctx, ch, waterMarksByTopic)
+		groupOffsets, isOk = e.consumerGroupOffsetsAdminAPI(ctx)
 	} else {
-		return e.collectConsumerGroupLagsOffsetTopic(ctx, ch, waterMarksByTopic)
+		groupOffsets = e.consumerGroupOffsetsOffsetTopic(ch)
+	}
+
+	// Offsets of a group can only be considered expired if we have successfully fetched the offsets of all groups
+	if isOk {
+		isOk = e.collectConsumerGroupOffsetExpiries(ctx, ch, groupOffsets)
 	}
+	if e.minionSvc.Cfg.ConsumerGroups.StaleGroupAge > 0 {
+		isOk = e.collectStaleConsumerGroups(ctx, ch, groupOffsets) && isOk
+	}
+	if e.minionSvc.HasPartitionSelection() {
+		e.filterGroupOffsetsBySelectedPartitions(groupOffsets)
+	}
+	if len(e.minionSvc.Cfg.ConsumerGroups.RequireTopics) > 0 {
+		e.filterGroupOffsetsByRequiredTopics(groupOffsets)
+	}
+	if len(e.minionSvc.Cfg.ConsumerGroups.IncludeStates) > 0 {
+		isOk = e.filterGroupOffsetsByState(ctx, groupOffsets) && isOk
+	}
+	isOk = e.collectConsumerGroupTopicLags(ch, groupOffsets, waterMarksByTopic, deletedTopics) && isOk
+	if e.minionSvc.Cfg.ConsumerGroups.IncludeUncommittedPartitions {
+		isOk = e.collectConsumerGroupUncommittedLags(ctx, ch, groupOffsets, waterMarksByTopic) && isOk
+	}
+	if e.minionSvc.Cfg.ConsumerGroups.IncludeLagBytes {
+		isOk = e.collectConsumerGroupTopicLagBytes(ctx, ch, groupOffsets, waterMarksByTopic) && isOk
+	}
+	if e.minionSvc.Cfg.ConsumerGroups.PerMember {
+		isOk = e.collectConsumerGroupMemberLags(ctx, ch, groupOffsets, waterMarksByTopic) && isOk
+	}
+
+	return isOk
 }
 
-func (e *Exporter) collectConsumerGroupLagsOffsetTopic(_ context.Context, ch chan<- prometheus.Metric, marks map[string]map[int32]waterMark) bool {
+// groupPartitionOffset is the committed offset of a consumer group for a single partition. It's the common
+// representation of group offsets for both scrape modes.
+type groupPartitionOffset struct {
+	Offset int64
+
+	// CommitTimestamp is the time at which the offset has been committed. It's only known if the offsets are
+	// consumed from the __consumer_offsets topic, otherwise it's zero.
+	CommitTimestamp time.Time
+}
+
+// consumerGroupOffsetsOffsetTopic returns the allowed group offsets (indexed by group id, topic name and partition id)
+// from the offset consumer's storage. It also reports the offset commit counts, which are only known in this mode.
+func (e *Exporter) consumerGroupOffsetsOffsetTopic(ch chan<- prometheus.Metric) map[string]map[string]map[int32]groupPartitionOffset {
 	offsets := e.minionSvc.ListAllConsumerGroupOffsetsInternal()
+	groupOffsets := make(map[string]map[string]map[int32]groupPartitionOffset)
 	for groupName, group := range offsets {
 		if !e.minionSvc.IsGroupAllowed(groupName) {
 			continue
 		}
 		offsetCommits := 0
+		groupOffsets[groupName] = make(map[string]map[int32]groupPartitionOffset)
 
 		for topicName, topic := range group {
-			topicLag := float64(0)
-			topicOffsetSum := float64(0)
+			groupOffsets[groupName][topicName] = make(map[int32]groupPartitionOffset)
 			for partitionID, partition := range topic {
-				childLogger := e.logger.With(
-					zap.String("consumer_group", groupName),
-					zap.String("topic_name", topicName),
-					zap.Int32("partition_id", partitionID),
-					zap.Int64("group_offset", partition.Value.Offset))
-
-				topicMark, exists := marks[topicName]
-				if !exists {
-					childLogger.Warn("consumer group has committed offsets on a topic we don't have watermarks for")
-					break // We can stop trying to find any other offsets for that topic so let's quit this loop
+				groupOffsets[groupName][topicName][partitionID] = groupPartitionOffset{
+					Offset:          partition.Value.Offset,
+					CommitTimestamp: time.Unix(0, partition.Value.CommitTimestamp*int64(time.Millisecond)),
 				}
-				partitionMark, exists := topicMark[partitionID]
-				if !exists {
-					childLogger.Warn("consumer group has committed offsets on a partition we don't have watermarks for")
-					continue
-				}
-				lag := float64(partitionMark.HighWaterMark - partition.Value.Offset)
-				// Lag might be negative because we fetch group offsets after we get partition offsets. It's kinda a
-				// race condition. Negative lags obviously do not make sense so use at least 0 as lag.
-				lag = math.Max(0, lag)
-				topicLag += lag
-				topicOffsetSum += float64(partition.Value.Offset)
 
 				// Offset commit count for this consumer group
 				offsetCommits += partition.CommitCount
-
-				if e.minionSvc.Cfg.ConsumerGroups.Granularity == minion.ConsumerGroupGranularityTopic {
-					continue
-				}
-				ch <- prometheus.MustNewConstMetric(
-					e.consumerGroupTopicPartitionLag,
-					prometheus.GaugeValue,
-					lag,
-					groupName,
-					topicName,
-					strconv.Itoa(int(partitionID)),
-				)
 			}
-			ch <- prometheus.MustNewConstMetric(
-				e.consumerGroupTopicLag,
-				prometheus.GaugeValue,
-				topicLag,
-				groupName,
-				topicName,
-			)
-			ch <- prometheus.MustNewConstMetric(
-				e.consumerGroupTopicOffsetSum,
-				prometheus.GaugeValue,
-				topicOffsetSum,
-				groupName,
-				topicName,
-			)
 		}
 
 		ch <- prometheus.MustNewConstMetric(
@@ -116,14 +132,22 @@ func (e *Exporter) collectConsumerGroupLagsOffsetTopic(_ context.Context, ch cha
 			groupName,
 		)
 	}
-	return true
+	return groupOffsets
 }
 
-func (e *Exporter) collectConsumerGroupLagsAdminAPI(ctx context.Context, ch chan<- prometheus.Metric, marks map[string]map[int32]waterMark) bool {
+// consumerGroupOffsetsAdminAPI returns the allowed group offsets (indexed by group id, topic name and partition id)
+// using Kafka's Admin API. The returned bool is false if the offsets of one or more groups could not be fetched.
+func (e *Exporter) consumerGroupOffsetsAdminAPI(ctx context.Context) (map[string]map[string]map[int32]groupPartitionOffset, bool) {
 	isOk := true
 
-	groupOffsets, err := e.minionSvc.ListAllConsumerGroupOffsetsAdminAPI(ctx)
-	for groupName, offsetRes := range groupOffsets {
+	groupOffsetResponses, err := e.minionSvc.ListAllConsumerGroupOffsetsAdminAPI(ctx)
+	if err != nil {
+		e.logger.Error("failed to list consumer group offsets", zap.Error(err))
+		return nil, false
+	}
+
+	groupOffsets := make(map[string]map[string]map[int32]groupPartitionOffset)
+	for groupName, offsetRes := range groupOffsetResponses {
 		if !e.minionSvc.IsGroupAllowed(groupName) {
 			continue
 		}
@@ -136,9 +160,9 @@ func (e *Exporter) collectConsumerGroupLagsAdminAPI(ctx context.Context, ch chan
 			isOk = false
 			continue
 		}
+		groupOffsets[groupName] = make(map[string]map[int32]groupPartitionOffset)
 		for _, topic := range offsetRes.Topics {
-			topicLag := float64(0)
-			topicOffsetSum := float64(0)
+			groupOffsets[groupName][topic.Topic] = make(map[int32]groupPartitionOffset)
 			for _, partition := range topic.Partitions {
 				err := kerr.ErrorForCode(partition.ErrorCode)
 				if err != nil {
@@ -148,22 +172,213 @@ func (e *Exporter) collectConsumerGroupLagsAdminAPI(ctx context.Context, ch chan
 					isOk = false
 					continue
 				}
+				groupOffsets[groupName][topic.Topic][partition.Partition] = groupPartitionOffset{Offset: partition.Offset}
+			}
+		}
+	}
+	return groupOffsets, isOk
+}
+
+// filterGroupOffsetsBySelectedPartitions removes the offsets of all partitions which are not selected in the allowed
+// topics, since we don't fetch their watermarks. The offsets of each group are copied, because they may be shared
+// with the group history.
+func (e *Exporter) filterGroupOffsetsBySelectedPartitions(groupOffsets map[string]map[string]map[int32]groupPartitionOffset) {
+	for groupName, topics := range groupOffsets {
+		selectedTopics := make(map[string]map[int32]groupPartitionOffset, len(topics))
+		for topicName, partitions := range topics {
+			selectedPartitions := make(map[int32]groupPartitionOffset, len(partitions))
+			for partitionID, partition := range partitions {
+				if e.minionSvc.IsPartitionSelected(topicName, partitionID) {
+					selectedPartitions[partitionID] = partition
+				}
+			}
+			if len(selectedPartitions) > 0 {
+				selectedTopics[topicName] = selectedPartitions
+			}
+		}
+		groupOffsets[groupName] = selectedTopics
+	}
+}
+
+// filterGroupOffsetsByRequiredTopics removes the offsets of all groups which have not committed offsets on any of the
+// required topics.
+func (e *Exporter) filterGroupOffsetsByRequiredTopics(groupOffsets map[string]map[string]map[int32]groupPartitionOffset) {
+	for groupName, topics := range groupOffsets {
+		hasRequiredTopic := false
+		for topicName, partitions := range topics {
+			if len(partitions) > 0 && e.minionSvc.IsRequiredTopic(topicName) {
+				hasRequiredTopic = true
+				break
+			}
+		}
+		if !hasRequiredTopic {
+			delete(groupOffsets, groupName)
+		}
+	}
+}
+
+// filterGroupOffsetsByState removes the offsets of all groups whose state is not included, so that no lags are
+// reported for them. Groups whose state is unknown are removed as well.
+func (e *Exporter) filterGroupOffsetsByState(ctx context.Context, groupOffsets map[string]map[string]map[int32]groupPartitionOffset) bool {
+	groups, err := e.minionSvc.DescribeConsumerGroupsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to describe consumer groups for filtering group states", zap.Error(err))
+		for groupName := range groupOffsets {
+			delete(groupOffsets, groupName)
+		}
+		return false
+	}
+
+	includedGroups := make(map[string]struct{})
+	for _, group := range groups.Groups {
+		if kerr.ErrorForCode(group.ErrorCode) == nil && e.minionSvc.IsGroupStateIncluded(group.State) {
+			includedGroups[group.Group] = struct{}{}
+		}
+	}
+	for groupName := range groupOffsets {
+		if _, isIncluded := includedGroups[groupName]; !isIncluded {
+			delete(groupOffsets, groupName)
+		}
+	}
+
+	return true
+}
+
+// collectConsumerGroupOffsetExpiries detects groups whose offsets have been expired by Kafka while the group itself
+// still exists and reports the number of expirations per group. If a grace period is configured, the last-known
+// offsets of these groups are added to the given group offsets, so that their lags are still reported.
+func (e *Exporter) collectConsumerGroupOffsetExpiries(ctx context.Context, ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset) bool {
+	groups, err := e.minionSvc.ListConsumerGroupsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to list consumer groups for detecting expired offsets", zap.Error(err))
+		return false
+	}
+	e.reportConsumerGroupOffsetExpiries(ch, groups, groupOffsets, time.Now())
 
+	return true
+}
+
+// reportConsumerGroupOffsetExpiries reports the offset expirations of the listed groups and adds the last-known
+// offsets of groups within the grace period to the given group offsets.
+func (e *Exporter) reportConsumerGroupOffsetExpiries(ch chan<- prometheus.Metric, groups *kmsg.ListGroupsResponse, groupOffsets map[string]map[string]map[int32]groupPartitionOffset, now time.Time) {
+	gracePeriod := e.minionSvc.Cfg.ConsumerGroups.ExpiredOffsetsGracePeriod
+	for _, group := range groups.Groups {
+		if !e.minionSvc.IsGroupAllowed(group.Group) {
+			continue
+		}
+
+		lastKnownOffsets, expiredAt, expirations := e.groupHistory.observeGroupOffsets(group.Group, groupOffsets[group.Group], now)
+		ch <- prometheus.MustNewConstMetric(
+			e.consumerGroupOffsetsExpired,
+			prometheus.CounterValue,
+			float64(expirations),
+			group.Group,
+		)
+		if lastKnownOffsets != nil && now.Sub(expiredAt) < gracePeriod {
+			groupOffsets[group.Group] = lastKnownOffsets
+		}
+	}
+}
+
+// collectStaleConsumerGroups reports whether groups are stale, which is the case if a group has no members (state
+// Empty), but still has committed offsets which are older than the configured stale group age. The age of the offsets
+// is based on the commit timestamps if known, otherwise on the last time KMinion has seen the group's offsets change.
+func (e *Exporter) collectStaleConsumerGroups(ctx context.Context, ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset) bool {
+	groups, err := e.minionSvc.DescribeConsumerGroupsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to describe consumer groups for detecting stale groups", zap.Error(err))
+		return false
+	}
+
+	now := time.Now()
+	staleGroupAge := e.minionSvc.Cfg.ConsumerGroups.StaleGroupAge
+	for _, group := range groups.Groups {
+		offsets, exists := groupOffsets[group.Group]
+		if !exists || len(offsets) == 0 || kerr.ErrorForCode(group.ErrorCode) != nil {
+			continue
+		}
+
+		lastCommit := time.Time{}
+		for _, partitions := range offsets {
+			for _, partition := range partitions {
+				if partition.CommitTimestamp.After(lastCommit) {
+					lastCommit = partition.CommitTimestamp
+				}
+			}
+		}
+		lastChange := e.groupHistory.observeOffsetChange(group.Group, offsets, now)
+		if lastCommit.IsZero() {
+			lastCommit = lastChange
+		}
+
+		isStale := 0
+		if group.State == "Empty" && now.Sub(lastCommit) > staleGroupAge {
+			isStale = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			e.consumerGroupStale,
+			prometheus.GaugeValue,
+			float64(isStale),
+			group.Group,
+		)
+	}
+
+	return true
+}
+
+// collectConsumerGroupTopicLags calculates and reports the partition and topic lags for the given group offsets.
+func (e *Exporter) collectConsumerGroupTopicLags(ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset, marks map[string]map[int32]waterMark, deletedTopics map[string]struct{}) bool {
+	isOk := true
+	now := time.Now()
+	defer e.groupHistory.evictStaleSamples(now)
+	smoothingSamples := e.minionSvc.Cfg.ConsumerGroups.Smoothing.Samples
+	includeOffsetResets := e.minionSvc.Cfg.ConsumerGroups.IncludeOffsetResets
+	hasPartitionSelection := e.minionSvc.HasPartitionSelection()
+
+	laggingPartitionThreshold := float64(e.minionSvc.Cfg.ConsumerGroups.LaggingPartitionThreshold)
+	exportOffsetLag := e.minionSvc.Cfg.ConsumerGroups.IsLagUnitExported(minion.ConsumerGroupLagUnitOffset)
+	exportTimeLag := e.minionSvc.Cfg.ConsumerGroups.IsLagUnitExported(minion.ConsumerGroupLagUnitTime)
+
+	for groupName, group := range groupOffsets {
+		laggingPartitions := 0
+		for topicName, topic := range group {
+			topicMark, exists := marks[topicName]
+			if _, isDeleted := deletedTopics[topicName]; !exists && isDeleted {
+				// The topic has been deleted during this scrape, the next scrape won't see it in the metadata anymore
+				continue
+			}
+			if !exists {
+				// This is usually the case if the topic has been deleted while the group's offsets have not been
+				// expired yet. We must not report any lag for this topic, as the series would otherwise linger.
+				// This is expected until the offsets expire and therefore doesn't fail the collector.
+				e.logger.Debug("consumer group has committed offsets on a topic we don't have watermarks for",
+					zap.String("consumer_group", groupName),
+					zap.String("topic_name", topicName))
+				continue
+			}
+
+			topicLag := float64(0)
+			topicMaxLag := float64(0)
+			topicTimeLag := float64(0)
+			hasTopicTimeLag := false
+			topicOffsetSum := float64(0)
+			topicOffsetPartitions := 0
+			for partitionID, partition := range topic {
 				childLogger := e.logger.With(
 					zap.String("consumer_group", groupName),
-					zap.String("topic_name", topic.Topic),
-					zap.Int32("partition_id", partition.Partition),
+					zap.String("topic_name", topicName),
+					zap.Int32("partition_id", partitionID),
 					zap.Int64("group_offset", partition.Offset))
-				topicMark, exists := marks[topic.Topic]
-				if !exists {
-					childLogger.Warn("consumer group has committed offsets on a topic we don't have watermarks for")
-					isOk = false
-					break // We can stop trying to find any other offsets for that topic so let's quit this loop
+
+				if hasPartitionSelection && !e.minionSvc.IsPartitionSelected(topicName, partitionID) {
+					// We don't fetch the watermarks of partitions that are not selected in the allowed topics
+					continue
 				}
-				partitionMark, exists := topicMark[partition.Partition]
+				partitionMark, exists := topicMark[partitionID]
 				if !exists {
-					childLogger.Warn("consumer group has committed offsets on a partition we don't have watermarks for")
-					isOk = false
+					// This happens if the watermarks of the partition could not be fetched, e.g. because the partition has
+					// no leader at the moment. Failed watermark requests are reported separately already.
+					childLogger.Debug("consumer group has committed offsets on a partition we don't have watermarks for")
 					continue
 				}
 				lag := float64(partitionMark.HighWaterMark - partition.Offset)
@@ -171,46 +386,240 @@ func (e *Exporter) collectConsumerGroupLagsAdminAPI(ctx context.Context, ch chan
 				// race condition. Negative lags obviously do not make sense so use at least 0 as lag.
 				lag = math.Max(0, lag)
 				topicLag += lag
+				topicMaxLag = math.Max(topicMaxLag, lag)
+				if lag > laggingPartitionThreshold {
+					laggingPartitions++
+				}
 				topicOffsetSum += float64(partition.Offset)
+				topicOffsetPartitions++
+				offsetResets := e.groupHistory.observePartitionOffset(groupName, topicName, partitionID, partition.Offset, now)
+				timeLag, hasTimeLag := float64(0), false
+				if exportTimeLag {
+					timeLag, hasTimeLag = e.topicHistory.estimateTimeLag(topicName, partitionID, partition.Offset, now)
+					if hasTimeLag {
+						topicTimeLag = math.Max(topicTimeLag, timeLag)
+						hasTopicTimeLag = true
+					}
+				}
 
-				if e.minionSvc.Cfg.ConsumerGroups.Granularity == minion.ConsumerGroupGranularityTopic {
+				if e.minionSvc.GetConsumerGroupGranularity(topicName) == minion.ConsumerGroupGranularityTopic {
 					continue
 				}
+				if exportOffsetLag && (lag > 0 || e.minionSvc.Cfg.ConsumerGroups.EmitZeroLag) {
+					ch <- prometheus.MustNewConstMetric(
+						e.consumerGroupTopicPartitionLag,
+						prometheus.GaugeValue,
+						lag,
+						groupName,
+						topicName,
+						strconv.Itoa(int(partitionID)),
+					)
+				}
+				if hasTimeLag && (timeLag > 0 || e.minionSvc.Cfg.ConsumerGroups.EmitZeroLag) {
+					ch <- prometheus.MustNewConstMetric(
+						e.consumerGroupTopicPartitionTimeLag,
+						prometheus.GaugeValue,
+						timeLag,
+						groupName,
+						topicName,
+						strconv.Itoa(int(partitionID)),
+					)
+				}
+				if includeOffsetResets && (offsetResets > 0 || e.minionSvc.Cfg.ConsumerGroups.EmitZeroLag) {
+					ch <- prometheus.MustNewConstMetric(
+						e.consumerGroupOffsetResets,
+						prometheus.CounterValue,
+						float64(offsetResets),
+						groupName,
+						topicName,
+						strconv.Itoa(int(partitionID)),
+					)
+				}
+				if smoothingSamples > 0 {
+					// The lag must be observed on every scrape, even if the smoothed lag is not exported
+					smoothedLag := e.groupHistory.observeLag(groupName, topicName, partitionID, lag, smoothingSamples, now)
+					if smoothedLag > 0 || e.minionSvc.Cfg.ConsumerGroups.EmitZeroLag {
+						ch <- prometheus.MustNewConstMetric(
+							e.consumerGroupTopicPartitionSmoothedLag,
+							prometheus.GaugeValue,
+							smoothedLag,
+							groupName,
+							topicName,
+							strconv.Itoa(int(partitionID)),
+						)
+					}
+				}
+			}
+
+			if exportOffsetLag {
 				ch <- prometheus.MustNewConstMetric(
-					e.consumerGroupTopicPartitionLag,
+					e.consumerGroupTopicLag,
 					prometheus.GaugeValue,
-					lag,
+					topicLag,
 					groupName,
-					topic.Topic,
-					strconv.Itoa(int(partition.Partition)),
+					topicName,
+				)
+			}
+			if hasTopicTimeLag {
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicTimeLag,
+					prometheus.GaugeValue,
+					topicTimeLag,
+					groupName,
+					topicName,
+				)
+			}
+			if exportOffsetLag {
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicMaxLag,
+					prometheus.GaugeValue,
+					topicMaxLag,
+					groupName,
+					topicName,
+				)
+			}
+			if smoothingSamples > 0 {
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicSmoothedLag,
+					prometheus.GaugeValue,
+					e.groupHistory.observeLag(groupName, topicName, -1, topicLag, smoothingSamples, now),
+					groupName,
+					topicName,
 				)
 			}
-
-			ch <- prometheus.MustNewConstMetric(
-				e.consumerGroupTopicLag,
-				prometheus.GaugeValue,
-				topicLag,
-				groupName,
-				topic.Topic,
-			)
 			ch <- prometheus.MustNewConstMetric(
 				e.consumerGroupTopicOffsetSum,
 				prometheus.GaugeValue,
 				topicOffsetSum,
 				groupName,
-				topic.Topic,
+				topicName,
 			)
+
+			consumeRate, hasRate := e.groupHistory.observeTopicOffsetSum(groupName, topicName, topicOffsetSum, topicOffsetPartitions, now)
+			if hasRate {
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicConsumeRate,
+					prometheus.GaugeValue,
+					consumeRate,
+					groupName,
+					topicName,
+				)
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicEstimatedDrainSeconds,
+					prometheus.GaugeValue,
+					estimateDrainSeconds(topicLag, consumeRate),
+					groupName,
+					topicName,
+				)
+			}
 		}
+
+		ch <- prometheus.MustNewConstMetric(
+			e.consumerGroupLaggingPartitions,
+			prometheus.GaugeValue,
+			float64(laggingPartitions),
+			groupName,
+		)
 	}
 	return isOk
 }
 
-func (e *Exporter) waterMarksByTopic(lowMarks *kmsg.ListOffsetsResponse, highMarks *kmsg.ListOffsetsResponse) map[string]map[int32]waterMark {
+// estimateDrainSeconds returns the estimated number of seconds it takes a consumer group to consume its current lag,
+// given its consumption rate in messages per second. +Inf is returned if the group is lagging but not making progress.
+func estimateDrainSeconds(lag float64, consumeRate float64) float64 {
+	if lag <= 0 {
+		return 0
+	}
+	if consumeRate <= 0 {
+		return math.Inf(1)
+	}
+
+	return lag / consumeRate
+}
+
+// collectConsumerGroupUncommittedLags reports the lag of partitions which are assigned to a group member, but on
+// which the group has not committed any offset yet. Without this, brand-new consumers that are stuck before their
+// first commit would not show up at all. The reported lag is the number of messages in the partition.
+func (e *Exporter) collectConsumerGroupUncommittedLags(ctx context.Context, ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset, marks map[string]map[int32]waterMark) bool {
+	groups, err := e.minionSvc.DescribeConsumerGroupsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to describe consumer groups for uncommitted partition lags", zap.Error(err))
+		return false
+	}
+
+	isOk := true
+	for _, group := range groups.Groups {
+		if !e.minionSvc.IsGroupAllowed(group.Group) {
+			continue
+		}
+		err := kerr.ErrorForCode(group.ErrorCode)
+		if err != nil {
+			e.logger.Warn("consumer group could not be described", zap.String("consumer_group", group.Group), zap.Error(err))
+			isOk = false
+			continue
+		}
+
+		// Members might claim the same partitions while the group is rebalancing, hence we dedupe the assignments
+		assignedPartitions := make(map[string]map[int32]struct{})
+		for _, member := range group.Members {
+			assignment, err := minion.DecodeMemberAssignment(group.ProtocolType, member)
+			if err != nil {
+				e.logger.Debug("failed to decode member assignment of consumer group",
+					zap.String("consumer_group", group.Group),
+					zap.String("member_id", member.MemberID),
+					zap.Error(err))
+				continue
+			}
+			for _, topic := range assignment.Topics {
+				if _, exists := assignedPartitions[topic.Topic]; !exists {
+					assignedPartitions[topic.Topic] = make(map[int32]struct{})
+				}
+				for _, partitionID := range topic.Partitions {
+					assignedPartitions[topic.Topic][partitionID] = struct{}{}
+				}
+			}
+		}
+
+		for topicName, partitions := range assignedPartitions {
+			if e.minionSvc.GetConsumerGroupGranularity(topicName) == minion.ConsumerGroupGranularityTopic {
+				continue
+			}
+			for partitionID := range partitions {
+				if _, hasCommitted := groupOffsets[group.Group][topicName][partitionID]; hasCommitted {
+					continue
+				}
+				partitionMark, exists := marks[topicName][partitionID]
+				if !exists {
+					continue
+				}
+				lag := math.Max(0, float64(partitionMark.HighWaterMark-partitionMark.LowWaterMark))
+				ch <- prometheus.MustNewConstMetric(
+					e.consumerGroupTopicPartitionUncommittedLag,
+					prometheus.GaugeValue,
+					lag,
+					group.Group,
+					topicName,
+					strconv.Itoa(int(partitionID)),
+				)
+			}
+		}
+	}
+	return isOk
+}
+
+// waterMarksByTopic returns the low and high water marks indexed by topic name and partition id. Topics which have
+// been deleted since the metadata has been fetched are not part of the water marks, but are returned separately.
+func (e *Exporter) waterMarksByTopic(lowMarks *kmsg.ListOffsetsResponse, highMarks *kmsg.ListOffsetsResponse) (map[string]map[int32]waterMark, map[string]struct{}) {
 	type partitionID = int32
 	type topicName = string
 	waterMarks := make(map[topicName]map[partitionID]waterMark)
+	deletedTopics := make(map[topicName]struct{})
 
 	for _, topic := range lowMarks.Topics {
+		if hasUnknownTopicOrPartition(topic.Partitions) {
+			deletedTopics[topic.Topic] = struct{}{}
+			continue
+		}
 		_, exists := waterMarks[topic.Topic]
 		if !exists {
 			waterMarks[topic.Topic] = make(map[partitionID]waterMark)
@@ -234,6 +643,14 @@ func (e *Exporter) waterMarksByTopic(lowMarks *kmsg.ListOffsetsResponse, highMar
 	}
 
 	for _, topic := range highMarks.Topics {
+		if hasUnknownTopicOrPartition(topic.Partitions) {
+			deletedTopics[topic.Topic] = struct{}{}
+			delete(waterMarks, topic.Topic)
+			continue
+		}
+		if _, isDeleted := deletedTopics[topic.Topic]; isDeleted {
+			continue
+		}
 		mark, exists := waterMarks[topic.Topic]
 		if !exists {
 			e.logger.Error("got high water marks for a topic but no low watermarks", zap.String("topic_name", topic.Topic))
@@ -251,17 +668,62 @@ func (e *Exporter) waterMarksByTopic(lowMarks *kmsg.ListOffsetsResponse, highMar
 			}
 			partitionMark, exists := mark[partition.Partition]
 			if !exists {
-				e.logger.Error("got high water marks for a topic's partition but no low watermarks",
-					zap.String("topic_name", topic.Topic),
-					zap.Int32("partition_id", partition.Partition),
-					zap.Int64("offset", partition.Offset))
-				delete(waterMarks, topic.Topic)
-				break // Topic watermarks are invalid -> delete & skip this topic
+				// The low water mark couldn't be fetched, which has been logged already
+				continue
 			}
 			partitionMark.HighWaterMark = partition.Offset
 			waterMarks[topic.Topic][partition.Partition] = partitionMark
 		}
 	}
 
-	return waterMarks
+	// Partitions whose high water mark couldn't be fetched are skipped, so that lags are only calculated for
+	// partitions with complete water marks
+	for topicName, partitions := range waterMarks {
+		for partitionID, mark := range partitions {
+			if mark.HighWaterMark == -1 {
+				delete(partitions, partitionID)
+			}
+		}
+		if len(partitions) == 0 {
+			delete(waterMarks, topicName)
+		}
+	}
+
+	return waterMarks, deletedTopics
+}
+
+// collectWatermarkErrors reports the number of scrapes in which the water marks of a partition couldn't be fetched.
+// Only partitions of topics which still exist are reported, so that the series of deleted topics disappear.
+func (e *Exporter) collectWatermarkErrors(ch chan<- prometheus.Metric, lowMarks *kmsg.ListOffsetsResponse, highMarks *kmsg.ListOffsetsResponse, deletedTopics map[string]struct{}, now time.Time) {
+	failedPartitions := make(map[string]map[int32]bool)
+	for _, response := range []*kmsg.ListOffsetsResponse{lowMarks, highMarks} {
+		for _, topic := range response.Topics {
+			if _, isDeleted := deletedTopics[topic.Topic]; isDeleted {
+				continue
+			}
+			if _, exists := failedPartitions[topic.Topic]; !exists {
+				failedPartitions[topic.Topic] = make(map[int32]bool)
+			}
+			for _, partition := range topic.Partitions {
+				failed := partition.ErrorCode != 0
+				failedPartitions[topic.Topic][partition.Partition] = failedPartitions[topic.Topic][partition.Partition] || failed
+			}
+		}
+	}
+
+	for topicName, partitions := range failedPartitions {
+		for partitionID, failed := range partitions {
+			errorCount := e.topicHistory.observeWatermarkRequest(topicName, partitionID, failed, now)
+			if errorCount == 0 {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.watermarkErrors,
+				prometheus.CounterValue,
+				float64(errorCount),
+				topicName,
+				strconv.Itoa(int(partitionID)),
+			)
+		}
+	}
 }
@@ -0,0 +1,181 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newListOffsetsResponse returns a response with a single partition per topic and the given error code
+func newListOffsetsResponse(errorCodes map[string]int16) *kmsg.ListOffsetsResponse {
+	response := kmsg.NewPtrListOffsetsResponse()
+	for topicName, errorCode := range errorCodes {
+		topic := kmsg.NewListOffsetsResponseTopic()
+		topic.Topic = topicName
+		partition := kmsg.NewListOffsetsResponseTopicPartition()
+		partition.Partition = 0
+		partition.ErrorCode = errorCode
+		partition.Offset = 100
+		topic.Partitions = append(topic.Partitions, partition)
+		response.Topics = append(response.Topics, topic)
+	}
+	return response
+}
+
+// collectWatermarkErrors returns the reported water mark errors indexed by topic name
+func collectWatermarkErrors(t *testing.T, e *Exporter, lowMarks *kmsg.ListOffsetsResponse, highMarks *kmsg.ListOffsetsResponse, now time.Time) map[string]float64 {
+	ch := make(chan prometheus.Metric, 10)
+	_, deletedTopics := e.waterMarksByTopic(lowMarks, highMarks)
+	e.collectWatermarkErrors(ch, lowMarks, highMarks, deletedTopics, now)
+	close(ch)
+
+	errors := make(map[string]float64)
+	for metric := range ch {
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		for _, label := range out.Label {
+			if label.GetName() == "topic_name" {
+				errors[label.GetValue()] = out.GetCounter().GetValue()
+			}
+		}
+	}
+	return errors
+}
+
+func TestWatermarkErrorsOfDeletedTopicDisappear(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+	now := time.Now()
+
+	notLeader := kerr.NotLeaderForPartition.Code
+	marks := newListOffsetsResponse(map[string]int16{"orders": notLeader, "payments": 0})
+	errors := collectWatermarkErrors(t, exporter, marks, marks, now)
+	if len(errors) != 1 || errors["orders"] != 1 {
+		t.Fatalf("expected a single water mark error for orders, got %v", errors)
+	}
+	// Once a partition had an error, the counter is reported on every scrape
+	marks = newListOffsetsResponse(map[string]int16{"orders": 0, "payments": 0})
+	errors = collectWatermarkErrors(t, exporter, marks, marks, now.Add(time.Minute))
+	if len(errors) != 1 || errors["orders"] != 1 {
+		t.Fatalf("expected the water mark error of orders to be reported again, got %v", errors)
+	}
+
+	// The orders topic has been deleted between the scrapes
+	unknownTopic := kerr.UnknownTopicOrPartition.Code
+	marks = newListOffsetsResponse(map[string]int16{"orders": unknownTopic, "payments": 0})
+	if errors := collectWatermarkErrors(t, exporter, marks, marks, now.Add(2*time.Minute)); len(errors) != 0 {
+		t.Fatalf("expected no water mark errors for the deleted topic, got %v", errors)
+	}
+	marks = newListOffsetsResponse(map[string]int16{"payments": 0})
+	exporter.topicHistory.evictStaleSamples(now.Add(2 * historyRetention))
+	if errors := collectWatermarkErrors(t, exporter, marks, marks, now.Add(2*historyRetention)); len(errors) != 0 {
+		t.Fatalf("expected no water mark errors after the deletion, got %v", errors)
+	}
+	if entries := exporter.topicHistory.entries(); entries != 1 {
+		t.Fatalf("expected only the payments partition to be tracked after eviction, got %d entries", entries)
+	}
+}
+
+func TestTopicLagsOfDeletedTopicDoNotFailCollector(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+
+	// The group's offsets on the deleted topic will only be removed once they expire
+	groupOffsets := map[string]map[string]map[int32]groupPartitionOffset{
+		"shop-consumer": {
+			"orders":   {0: {Offset: 50}},
+			"payments": {0: {Offset: 50}},
+		},
+	}
+	marks := map[string]map[int32]waterMark{
+		"payments": {0: {TopicName: "payments", PartitionID: 0, LowWaterMark: 0, HighWaterMark: 100}},
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	isOk := exporter.collectConsumerGroupTopicLags(ch, groupOffsets, marks, map[string]struct{}{})
+	close(ch)
+	if !isOk {
+		t.Fatal("expected committed offsets on a deleted topic not to fail the collector")
+	}
+	for metric := range ch {
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		for _, label := range out.Label {
+			if label.GetName() == "topic_name" && label.GetValue() == "orders" {
+				t.Fatalf("expected no series for the deleted topic, got %v", metric.Desc())
+			}
+		}
+	}
+}
+
+func TestReportConsumerGroupOffsetExpiries(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+	exporter.minionSvc.Cfg.ConsumerGroups.ExpiredOffsetsGracePeriod = time.Minute
+
+	groups := kmsg.NewPtrListGroupsResponse()
+	for _, groupID := range []string{"shop-consumer", "bigquery-sink"} {
+		group := kmsg.NewListGroupsResponseGroup()
+		group.Group = groupID
+		groups.Groups = append(groups.Groups, group)
+	}
+	committedOffsets := func() map[string]map[string]map[int32]groupPartitionOffset {
+		return map[string]map[string]map[int32]groupPartitionOffset{
+			"shop-consumer": {"orders": {0: {Offset: 50}}},
+			"bigquery-sink": {"orders": {0: {Offset: 80}}},
+		}
+	}
+	report := func(groupOffsets map[string]map[string]map[int32]groupPartitionOffset, now time.Time) map[string]float64 {
+		ch := make(chan prometheus.Metric, 10)
+		exporter.reportConsumerGroupOffsetExpiries(ch, groups, groupOffsets, now)
+		close(ch)
+
+		expirations := make(map[string]float64)
+		for metric := range ch {
+			out := &dto.Metric{}
+			if err := metric.Write(out); err != nil {
+				t.Fatalf("failed to write metric: %v", err)
+			}
+			expirations[out.Label[0].GetValue()] = out.GetCounter().GetValue()
+		}
+		return expirations
+	}
+
+	now := time.Now()
+	expected := map[string]float64{"shop-consumer": 0, "bigquery-sink": 0}
+	if expirations := report(committedOffsets(), now); !reflect.DeepEqual(expirations, expected) {
+		t.Fatalf("expected expirations %v, got %v", expected, expirations)
+	}
+
+	// Kafka has expired the offsets of shop-consumer, but the group is still listed
+	groupOffsets := committedOffsets()
+	delete(groupOffsets, "shop-consumer")
+	expected = map[string]float64{"shop-consumer": 1, "bigquery-sink": 0}
+	if expirations := report(groupOffsets, now.Add(10*time.Second)); !reflect.DeepEqual(expirations, expected) {
+		t.Fatalf("expected expirations %v, got %v", expected, expirations)
+	}
+	if offset := groupOffsets["shop-consumer"]["orders"][0].Offset; offset != 50 {
+		t.Fatalf("expected the last-known offset to be reported within the grace period, got %d", offset)
+	}
+
+	// The expiry is only counted once and the last-known offsets are dropped after the grace period
+	groupOffsets = committedOffsets()
+	delete(groupOffsets, "shop-consumer")
+	if expirations := report(groupOffsets, now.Add(2*time.Minute)); !reflect.DeepEqual(expirations, expected) {
+		t.Fatalf("expected expirations %v, got %v", expected, expirations)
+	}
+	if _, exists := groupOffsets["shop-consumer"]; exists {
+		t.Fatal("expected no offsets for the expired group after the grace period")
+	}
+}
@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/cloudhut/kminion/v2/minion"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"go.uber.org/zap"
+	"math"
+	"strings"
+)
+
+// memberLagKey identifies the lag of all group members with the same client id and host on a topic. Multiple members
+// may share the same client id and host (e.g. several consumers within one process), their lags are summed up.
+type memberLagKey struct {
+	TopicName  string
+	ClientID   string
+	ClientHost string
+}
+
+// collectConsumerGroupMemberLags reports the topic lags of a consumer group broken down by the client id and host of
+// the members the partitions are assigned to. This helps to find a single lagging instance of a group.
+func (e *Exporter) collectConsumerGroupMemberLags(ctx context.Context, ch chan<- prometheus.Metric, groupOffsets map[string]map[string]map[int32]groupPartitionOffset, marks map[string]map[int32]waterMark) bool {
+	groups, err := e.minionSvc.DescribeConsumerGroupsCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to describe consumer groups for member lags", zap.Error(err))
+		return false
+	}
+
+	isOk := true
+	for _, group := range groups.Groups {
+		offsets, exists := groupOffsets[group.Group]
+		if !exists {
+			continue
+		}
+		err := kerr.ErrorForCode(group.ErrorCode)
+		if err != nil {
+			e.logger.Warn("consumer group could not be described", zap.String("consumer_group", group.Group), zap.Error(err))
+			isOk = false
+			continue
+		}
+
+		for key, lag := range e.memberLags(group, offsets, marks) {
+			ch <- prometheus.MustNewConstMetric(
+				e.consumerGroupTopicMemberLag,
+				prometheus.GaugeValue,
+				lag,
+				group.Group,
+				key.TopicName,
+				key.ClientID,
+				key.ClientHost,
+			)
+		}
+	}
+	return isOk
+}
+
+// memberLags joins the partition assignments of the group's members with the lags of the assigned partitions
+func (e *Exporter) memberLags(group kmsg.DescribeGroupsResponseGroup, offsets map[string]map[int32]groupPartitionOffset, marks map[string]map[int32]waterMark) map[memberLagKey]float64 {
+	// Members might claim the same partitions while the group is rebalancing, hence each partition is only
+	// accounted to the first member claiming it
+	accountedPartitions := make(map[string]map[int32]struct{})
+	lags := make(map[memberLagKey]float64)
+	for _, member := range group.Members {
+		assignment, err := minion.DecodeMemberAssignment(group.ProtocolType, member)
+		if err != nil {
+			e.logger.Debug("failed to decode member assignment of consumer group",
+				zap.String("consumer_group", group.Group),
+				zap.String("member_id", member.MemberID),
+				zap.Error(err))
+			continue
+		}
+
+		// Kafka reports the client host with a leading slash, e.g. "/10.0.0.1"
+		clientHost := strings.TrimPrefix(member.ClientHost, "/")
+		for _, topic := range assignment.Topics {
+			if _, exists := accountedPartitions[topic.Topic]; !exists {
+				accountedPartitions[topic.Topic] = make(map[int32]struct{})
+			}
+			key := memberLagKey{TopicName: topic.Topic, ClientID: member.ClientID, ClientHost: clientHost}
+			for _, partitionID := range topic.Partitions {
+				if _, isAccounted := accountedPartitions[topic.Topic][partitionID]; isAccounted {
+					continue
+				}
+				partitionOffset, hasCommitted := offsets[topic.Topic][partitionID]
+				if !hasCommitted {
+					continue
+				}
+				partitionMark, exists := marks[topic.Topic][partitionID]
+				if !exists {
+					continue
+				}
+				accountedPartitions[topic.Topic][partitionID] = struct{}{}
+				lags[key] += math.Max(0, float64(partitionMark.HighWaterMark-partitionOffset.Offset))
+			}
+		}
+	}
+	return lags
+}
@@ -0,0 +1,72 @@
+package prometheus
+
+import (
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"reflect"
+	"testing"
+)
+
+// newDescribedMember returns a group member whose assignment is encoded the way the consumer protocol does it
+func newDescribedMember(memberID string, clientID string, clientHost string, assignedPartitions map[string][]int32) kmsg.DescribeGroupsResponseGroupMember {
+	assignment := kmsg.NewGroupMemberAssignment()
+	for topicName, partitions := range assignedPartitions {
+		topic := kmsg.NewGroupMemberAssignmentTopic()
+		topic.Topic = topicName
+		topic.Partitions = partitions
+		assignment.Topics = append(assignment.Topics, topic)
+	}
+
+	member := kmsg.NewDescribeGroupsResponseGroupMember()
+	member.MemberID = memberID
+	member.ClientID = clientID
+	member.ClientHost = clientHost
+	member.MemberAssignment = assignment.AppendTo(nil)
+	return member
+}
+
+func TestMemberLags(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+
+	group := kmsg.NewDescribeGroupsResponseGroup()
+	group.Group = "shop-consumer"
+	group.ProtocolType = "consumer"
+	group.Members = []kmsg.DescribeGroupsResponseGroupMember{
+		newDescribedMember("member-1", "shop-1", "/10.0.0.1", map[string][]int32{"orders": {0, 1}, "payments": {0}}),
+		// The second member still claims partition 1 from a previous generation, which is accounted to the first
+		// member only
+		newDescribedMember("member-2", "shop-2", "/10.0.0.2", map[string][]int32{"orders": {1, 2}}),
+	}
+
+	offsets := map[string]map[int32]groupPartitionOffset{
+		"orders":   {0: {Offset: 90}, 1: {Offset: 80}, 2: {Offset: 100}},
+		"payments": {0: {Offset: 20}},
+	}
+	marks := map[string]map[int32]waterMark{
+		"orders": {
+			0: {TopicName: "orders", PartitionID: 0, HighWaterMark: 100},
+			1: {TopicName: "orders", PartitionID: 1, HighWaterMark: 100},
+			2: {TopicName: "orders", PartitionID: 2, HighWaterMark: 150},
+		},
+		"payments": {
+			0: {TopicName: "payments", PartitionID: 0, HighWaterMark: 50},
+		},
+	}
+
+	lags := exporter.memberLags(group, offsets, marks)
+	expected := map[memberLagKey]float64{
+		{TopicName: "orders", ClientID: "shop-1", ClientHost: "10.0.0.1"}:   30,
+		{TopicName: "payments", ClientID: "shop-1", ClientHost: "10.0.0.1"}: 30,
+		{TopicName: "orders", ClientID: "shop-2", ClientHost: "10.0.0.2"}:   50,
+	}
+	if !reflect.DeepEqual(lags, expected) {
+		t.Errorf("expected member lags %v, got %v", expected, lags)
+	}
+
+	// Groups that don't use the consumer protocol (e.g. Kafka Connect workers) have no partition assignments
+	group.ProtocolType = "connect"
+	if lags := exporter.memberLags(group, offsets, marks); len(lags) != 0 {
+		t.Errorf("expected no member lags for the connect protocol, got %v", lags)
+	}
+}
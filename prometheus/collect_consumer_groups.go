@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"github.com/cloudhut/kminion/v2/minion"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/twmb/franz-go/pkg/kerr"
 	"go.uber.org/zap"
@@ -12,7 +13,7 @@ func (e *Exporter) collectConsumerGroups(ctx context.Context, ch chan<- promethe
 	if !e.minionSvc.Cfg.ConsumerGroups.Enabled {
 		return true
 	}
-	groups, err := e.minionSvc.DescribeConsumerGroups(ctx)
+	groups, err := e.minionSvc.DescribeConsumerGroupsCached(ctx)
 	if err != nil {
 		e.logger.Error("failed to collect consumer groups, because Kafka request failed", zap.Error(err))
 		return false
@@ -43,6 +44,43 @@ func (e *Exporter) collectConsumerGroups(ctx context.Context, ch chan<- promethe
 			group.ProtocolType,
 			group.State,
 		)
+
+		clientIDs := make(map[string]struct{}, len(group.Members))
+		for _, member := range group.Members {
+			clientIDs[member.ClientID] = struct{}{}
+		}
+		ch <- prometheus.MustNewConstMetric(
+			e.consumerGroupDistinctClients,
+			prometheus.GaugeValue,
+			float64(len(clientIDs)),
+			group.Group,
+		)
+
+		// Groups without members (e.g. empty groups) have not chosen a protocol
+		if group.Protocol != "" {
+			ch <- prometheus.MustNewConstMetric(
+				e.consumerGroupProtocol,
+				prometheus.GaugeValue,
+				1,
+				group.Group,
+				group.Protocol,
+			)
+		}
+	}
+
+	// DescribeGroups does not report the generation, so it is only known when consuming the offsets topic
+	if e.minionSvc.Cfg.ConsumerGroups.ScrapeMode == minion.ConsumerGroupScrapeModeOffsetsTopic {
+		for group, generation := range e.minionSvc.ListConsumerGroupGenerationsInternal() {
+			if !e.minionSvc.IsGroupAllowed(group) {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.consumerGroupGeneration,
+				prometheus.GaugeValue,
+				float64(generation),
+				group,
+			)
+		}
 	}
 	return true
 }
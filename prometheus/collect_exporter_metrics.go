@@ -12,5 +12,24 @@ func (e *Exporter) collectExporterMetrics(_ context.Context, ch chan<- prometheu
 		prometheus.CounterValue,
 		recordsConsumed,
 	)
+
+	preflightOk := 0.0
+	if e.minionSvc.IsPreflightOk() {
+		preflightOk = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(
+		e.startupPreflightOk,
+		prometheus.GaugeValue,
+		preflightOk,
+	)
+
+	for _, topicName := range e.minionSvc.GetMissingConfiguredTopics() {
+		ch <- prometheus.MustNewConstMetric(
+			e.configuredTopicMissing,
+			prometheus.GaugeValue,
+			1,
+			topicName,
+		)
+	}
 	return true
 }
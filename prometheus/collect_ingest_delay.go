@@ -0,0 +1,23 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (e *Exporter) collectIngestDelay(_ context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.IngestDelay.Enabled {
+		return true
+	}
+
+	for topicName, histogram := range e.minionSvc.GetIngestDelayHistograms() {
+		ch <- prometheus.MustNewConstHistogram(
+			e.endToEndIngestDelay,
+			histogram.Count,
+			histogram.Sum,
+			histogram.Buckets,
+			topicName,
+		)
+	}
+	return true
+}
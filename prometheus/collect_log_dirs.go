@@ -7,6 +7,7 @@ import (
 	"github.com/twmb/franz-go/pkg/kgo"
 	"go.uber.org/zap"
 	"strconv"
+	"time"
 )
 
 func (e *Exporter) collectLogDirs(ctx context.Context, ch chan<- prometheus.Metric) bool {
@@ -18,7 +19,7 @@ func (e *Exporter) collectLogDirs(ctx context.Context, ch chan<- prometheus.Metr
 	sizeByBroker := make(map[kgo.BrokerMetadata]int64)
 	sizeByTopicName := make(map[string]int64)
 
-	logDirsSharded := e.minionSvc.DescribeLogDirs(ctx)
+	logDirsSharded := e.minionSvc.DescribeLogDirsCached(ctx)
 	for _, logDirRes := range logDirsSharded {
 		childLogger := e.logger.With(zap.String("broker_address", logDirRes.Broker.Host),
 			zap.String("broker_id", strconv.Itoa(int(logDirRes.Broker.NodeID))))
@@ -73,6 +74,8 @@ func (e *Exporter) collectLogDirs(ctx context.Context, ch chan<- prometheus.Metr
 	}
 
 	// Report the total log dir size per topic
+	now := time.Now()
+	defer e.topicHistory.evictStaleSamples(now)
 	for topicName, size := range sizeByTopicName {
 		ch <- prometheus.MustNewConstMetric(
 			e.topicLogDirSize,
@@ -80,6 +83,16 @@ func (e *Exporter) collectLogDirs(ctx context.Context, ch chan<- prometheus.Metr
 			float64(size),
 			topicName,
 		)
+
+		bytesInRate, hasRate := e.topicHistory.observeLogDirSize(topicName, float64(size), now)
+		if hasRate {
+			ch <- prometheus.MustNewConstMetric(
+				e.topicBytesInRate,
+				prometheus.GaugeValue,
+				bytesInRate,
+				topicName,
+			)
+		}
 	}
 
 	return isOk
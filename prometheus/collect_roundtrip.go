@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (e *Exporter) collectRoundtrip(_ context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Roundtrip.Enabled {
+		return true
+	}
+
+	total, overSLA := e.minionSvc.GetRoundtripCounts()
+	ch <- prometheus.MustNewConstMetric(
+		e.endToEndRoundtrips,
+		prometheus.CounterValue,
+		float64(total),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		e.endToEndRoundtripsOverSLA,
+		prometheus.CounterValue,
+		float64(overSLA),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		e.endToEndProduceUnderReplicated,
+		prometheus.CounterValue,
+		float64(e.minionSvc.GetRoundtripUnderReplicatedProduces()),
+	)
+
+	ok, hasResult := e.minionSvc.GetRoundtripStatus()
+	if !hasResult {
+		return true
+	}
+	roundtripOk := 0
+	if ok {
+		roundtripOk = 1
+	}
+	ch <- prometheus.MustNewConstMetric(
+		e.roundtripOk,
+		prometheus.GaugeValue,
+		float64(roundtripOk),
+	)
+	return true
+}
@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"time"
+)
+
+// collectTopicAge reports the age of topics, as Kafka does not expose the creation time of topics. The age is derived
+// from the timestamp of the oldest message, which is only reliable as long as no data has been removed from the
+// topic. Therefore the age is omitted for all topics whose oldest message is unknown, see minion.OldestMessages.
+func (e *Exporter) collectTopicAge(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Topics.Enabled || !e.minionSvc.Cfg.Topics.IncludeAge {
+		return true
+	}
+
+	oldestMessages, err := e.minionSvc.GetOldestMessages(ctx)
+	if err != nil {
+		e.logger.Error("failed to get oldest messages for topic age", zap.Error(err))
+		return false
+	}
+
+	now := time.Now()
+	for topicName, oldestTimestamp := range oldestMessages.Timestamps {
+		if !e.minionSvc.IsTopicAllowed(topicName) {
+			continue
+		}
+
+		age := now.Sub(oldestTimestamp)
+		if age < 0 {
+			// Messages with timestamps in the future make the age unreliable
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			e.topicAge,
+			prometheus.GaugeValue,
+			age.Seconds(),
+			topicName,
+		)
+	}
+
+	return !oldestMessages.HasErrors
+}
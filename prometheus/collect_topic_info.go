@@ -9,13 +9,17 @@ import (
 )
 
 func (e *Exporter) collectTopicInfo(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Topics.Enabled {
+		return true
+	}
+
 	metadata, err := e.minionSvc.GetMetadataCached(ctx)
 	if err != nil {
 		e.logger.Error("failed to get metadata", zap.Error(err))
 		return false
 	}
 
-	topicConfigs, err := e.minionSvc.GetTopicConfigs(ctx)
+	topicConfigs, err := e.minionSvc.GetTopicConfigsCached(ctx)
 	if err != nil {
 		e.logger.Error("failed to get topic configs", zap.Error(err))
 		return false
@@ -78,6 +82,58 @@ func (e *Exporter) collectTopicInfo(ctx context.Context, ch chan<- prometheus.Me
 			strconv.Itoa(replicationFactor),
 			cleanupPolicy,
 		)
+
+		minReplicationFactor := e.minionSvc.Cfg.Topics.MinReplicationFactor
+		if minReplicationFactor > 0 && replicationFactor > 0 {
+			belowMin := 0
+			if replicationFactor < minReplicationFactor {
+				belowMin = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.topicReplicationFactorBelowMin,
+				prometheus.GaugeValue,
+				float64(belowMin),
+				topic.Topic,
+			)
+		}
+
+		partitionCountPolicy := e.minionSvc.Cfg.Topics.PartitionCountPolicy
+		if partitionCountPolicy.IsEnabled() {
+			violation := 0
+			if partitionCountPolicy.IsViolatedBy(partitionCount) {
+				violation = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.topicPartitionCountPolicyViolation,
+				prometheus.GaugeValue,
+				float64(violation),
+				topic.Topic,
+			)
+		}
+
+		// A topic whose min.insync.replicas is not lower than its replication factor can't tolerate the loss of a
+		// single broker for producers that use acks=all
+		minInSyncReplicasStr, exists := configsByTopic[topic.Topic]["min.insync.replicas"]
+		if exists && replicationFactor > 0 {
+			minInSyncReplicas, err := strconv.Atoi(minInSyncReplicasStr)
+			if err != nil {
+				e.logger.Warn("failed to parse min.insync.replicas of topic",
+					zap.String("topic_name", topic.Topic),
+					zap.String("min_insync_replicas", minInSyncReplicasStr),
+					zap.Error(err))
+				continue
+			}
+			isrRisk := 0
+			if minInSyncReplicas >= replicationFactor {
+				isrRisk = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.topicISRRisk,
+				prometheus.GaugeValue,
+				float64(isrRisk),
+				topic.Topic,
+			)
+		}
 	}
 	return isOk
 }
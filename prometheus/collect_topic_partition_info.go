@@ -0,0 +1,90 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/cloudhut/kminion/v2/minion"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+func (e *Exporter) collectTopicPartitionInfo(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Topics.Enabled {
+		return true
+	}
+
+	metadata, err := e.minionSvc.GetMetadataCached(ctx)
+	if err != nil {
+		e.logger.Error("failed to get metadata", zap.Error(err))
+		return false
+	}
+
+	isOk := true
+	now := time.Now()
+	defer e.topicHistory.evictStaleSamples(now)
+	for _, topic := range metadata.Topics {
+		if !e.minionSvc.IsTopicAllowed(topic.Topic) {
+			continue
+		}
+		if e.minionSvc.GetTopicGranularity(topic.Topic) == minion.TopicGranularityTopic {
+			continue
+		}
+		typedErr := kerr.TypedErrorForCode(topic.ErrorCode)
+		if typedErr != nil {
+			isOk = false
+			e.logger.Warn("failed to get metadata of a specific topic",
+				zap.String("topic_name", topic.Topic),
+				zap.Error(typedErr))
+			continue
+		}
+
+		for _, partition := range topic.Partitions {
+			partitionID := strconv.Itoa(int(partition.Partition))
+			ch <- prometheus.MustNewConstMetric(
+				e.partitionInSyncReplicas,
+				prometheus.GaugeValue,
+				float64(len(partition.ISR)),
+				topic.Topic,
+				partitionID,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				e.partitionISRChanges,
+				prometheus.CounterValue,
+				float64(e.topicHistory.observeISR(topic.Topic, partition.Partition, partition.ISR, now)),
+				topic.Topic,
+				partitionID,
+			)
+			leaderIsPreferred := 0
+			if isPreferredLeader(partition.Leader, partition.Replicas) {
+				leaderIsPreferred = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.partitionLeaderIsPreferred,
+				prometheus.GaugeValue,
+				float64(leaderIsPreferred),
+				topic.Topic,
+				partitionID,
+			)
+			// The leader epoch is only reported by brokers since Kafka v2.1
+			if partition.LeaderEpoch >= 0 {
+				ch <- prometheus.MustNewConstMetric(
+					e.partitionLeaderEpoch,
+					prometheus.GaugeValue,
+					float64(partition.LeaderEpoch),
+					topic.Topic,
+					partitionID,
+				)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				e.partitionReplicas,
+				prometheus.GaugeValue,
+				float64(len(partition.Replicas)),
+				topic.Topic,
+				partitionID,
+			)
+		}
+	}
+	return isOk
+}
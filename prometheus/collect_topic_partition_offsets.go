@@ -2,14 +2,20 @@ package prometheus
 
 import (
 	"context"
+	"errors"
 	"github.com/cloudhut/kminion/v2/minion"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"go.uber.org/zap"
 	"strconv"
 )
 
 func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Topics.Enabled {
+		return true
+	}
+
 	isOk := true
 
 	// Low Watermarks
@@ -29,6 +35,12 @@ func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- p
 		if !e.minionSvc.IsTopicAllowed(topic.Topic) {
 			continue
 		}
+		if hasUnknownTopicOrPartition(topic.Partitions) {
+			// The topic has been deleted since the metadata has been fetched, hence we skip all of its series
+			e.logger.Debug("skipping low water marks of topic which has been deleted while scraping",
+				zap.String("topic_name", topic.Topic))
+			continue
+		}
 		waterMarkSum := int64(0)
 		for _, partition := range topic.Partitions {
 			err := kerr.ErrorForCode(partition.ErrorCode)
@@ -39,7 +51,7 @@ func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- p
 			}
 			waterMarkSum += partition.Offset
 			// Let's end here if partition metrics shall not be exposed
-			if e.minionSvc.Cfg.Topics.Granularity == minion.TopicGranularityTopic {
+			if e.minionSvc.GetTopicGranularity(topic.Topic) == minion.TopicGranularityTopic {
 				continue
 			}
 			ch <- prometheus.MustNewConstMetric(
@@ -62,6 +74,12 @@ func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- p
 		if !e.minionSvc.IsTopicAllowed(topic.Topic) {
 			continue
 		}
+		if hasUnknownTopicOrPartition(topic.Partitions) {
+			// The topic has been deleted since the metadata has been fetched, hence we skip all of its series
+			e.logger.Debug("skipping high water marks of topic which has been deleted while scraping",
+				zap.String("topic_name", topic.Topic))
+			continue
+		}
 		waterMarkSum := int64(0)
 		for _, partition := range topic.Partitions {
 			err := kerr.ErrorForCode(partition.ErrorCode)
@@ -72,7 +90,7 @@ func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- p
 			}
 			waterMarkSum += partition.Offset
 			// Let's end here if partition metrics shall not be exposed
-			if e.minionSvc.Cfg.Topics.Granularity == minion.TopicGranularityTopic {
+			if e.minionSvc.GetTopicGranularity(topic.Topic) == minion.TopicGranularityTopic {
 				continue
 			}
 			ch <- prometheus.MustNewConstMetric(
@@ -93,3 +111,14 @@ func (e *Exporter) collectTopicPartitionOffsets(ctx context.Context, ch chan<- p
 
 	return isOk
 }
+
+// hasUnknownTopicOrPartition returns true if any of the partitions reports an UnknownTopicOrPartition error. This
+// happens if a topic has been deleted between the metadata and the list offsets requests of a scrape.
+func hasUnknownTopicOrPartition(partitions []kmsg.ListOffsetsResponseTopicPartition) bool {
+	for _, partition := range partitions {
+		if errors.Is(kerr.ErrorForCode(partition.ErrorCode), kerr.UnknownTopicOrPartition) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+func (e *Exporter) collectTransactions(_ context.Context, ch chan<- prometheus.Metric) bool {
+	if !e.minionSvc.Cfg.Transactions.Enabled {
+		return true
+	}
+
+	oldestStart, hasOpen, isReady := e.minionSvc.GetOldestOpenTransactionStart()
+	if !isReady {
+		return true
+	}
+
+	age := float64(0)
+	if hasOpen {
+		age = time.Since(oldestStart).Seconds()
+		if age < 0 {
+			// Clocks of the transaction coordinators may be skewed
+			age = 0
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		e.oldestOpenTransactionAge,
+		prometheus.GaugeValue,
+		age,
+	)
+	return true
+}
@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+type collectFunc func(context.Context, chan<- prometheus.Metric) bool
+
+// collectorCache remembers the metrics of the most recent successful run of each collector, so that collectors with
+// their own interval are only run once the interval has passed. In between, the remembered metrics are exported
+// again. It is safe for concurrent use.
+type collectorCache struct {
+	mutex sync.Mutex
+	// results is indexed by collector name
+	results map[string]collectorResult
+}
+
+type collectorResult struct {
+	Metrics     []prometheus.Metric
+	CollectedAt time.Time
+}
+
+func newCollectorCache() *collectorCache {
+	return &collectorCache{results: make(map[string]collectorResult)}
+}
+
+// collect returns the metrics of the previous successful run of the collector if it's younger than the interval.
+// Otherwise the collector is run and its metrics are remembered if it has succeeded. Failed runs are not remembered,
+// so that the collector is run again on the next scrape. The returned metrics are the collector's full output, the
+// series limit is applied by the caller afterwards. If the interval is 0 the collector is run on every call.
+func (c *collectorCache) collect(ctx context.Context, name string, interval time.Duration, collect collectFunc) (bool, []prometheus.Metric) {
+	if interval <= 0 {
+		return bufferCollect(ctx, collect)
+	}
+
+	if metrics, isFresh := c.get(name, interval); isFresh {
+		return true, metrics
+	}
+
+	ok, metrics := bufferCollect(ctx, collect)
+	if ok {
+		c.set(name, collectorResult{Metrics: metrics, CollectedAt: time.Now()})
+	}
+	return ok, metrics
+}
+
+func (c *collectorCache) get(name string, interval time.Duration) ([]prometheus.Metric, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result, exists := c.results[name]
+	if !exists || time.Since(result.CollectedAt) >= interval {
+		return nil, false
+	}
+	return result.Metrics, true
+}
+
+func (c *collectorCache) set(name string, result collectorResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.results[name] = result
+}
+
+// entries returns the number of remembered metrics
+func (c *collectorCache) entries() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := 0
+	for _, result := range c.results {
+		count += len(result.Metrics)
+	}
+	return count
+}
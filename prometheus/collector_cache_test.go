@@ -0,0 +1,52 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCollectorCacheReplaysFullOutput(t *testing.T) {
+	desc := prometheus.NewDesc("kminion_kafka_broker_log_dir_size_total_bytes", "size", []string{"broker_id"}, nil)
+	runs := 0
+	collect := func(_ context.Context, ch chan<- prometheus.Metric) bool {
+		runs++
+		for brokerID := 0; brokerID < 5; brokerID++ {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, strconv.Itoa(brokerID))
+		}
+		return true
+	}
+
+	cache := newCollectorCache()
+	// The first scrape is limited to 2 series, which must not affect what is replayed later on
+	_, metrics := cache.collect(context.Background(), "logDirs", time.Hour, collect)
+	kept, _ := newSeriesLimiter(2).limit(metrics)
+	if len(kept) != 2 {
+		t.Fatalf("expected 2 kept series, got %d", len(kept))
+	}
+
+	ok, replayed := cache.collect(context.Background(), "logDirs", time.Hour, collect)
+	if !ok || len(replayed) != 5 {
+		t.Fatalf("expected 5 replayed series, got %d (ok: %v)", len(replayed), ok)
+	}
+	if runs != 1 {
+		t.Fatalf("expected the collector to run once within the interval, it ran %d times", runs)
+	}
+}
+
+func TestCollectorCacheDoesNotRememberFailures(t *testing.T) {
+	runs := 0
+	collect := func(_ context.Context, _ chan<- prometheus.Metric) bool {
+		runs++
+		return false
+	}
+
+	cache := newCollectorCache()
+	cache.collect(context.Background(), "logDirs", time.Hour, collect)
+	ok, _ := cache.collect(context.Background(), "logDirs", time.Hour, collect)
+	if ok || runs != 2 {
+		t.Fatalf("expected failed runs to be repeated, got %d runs (ok: %v)", runs, ok)
+	}
+}
@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"strings"
+)
+
+// compactLagKeyLabels are the labels of the lag metrics that are joined into the key label in compat mode, in order
+var compactLagKeyLabels = []string{"group_id", topicLabelName, "partition_id"}
+
+// compactLags returns a channel which forwards all metrics to ch, but replaces the topic and partition lags by
+// kminion_kafka_consumer_group_topic_lag_compact and kminion_kafka_consumer_group_topic_partition_lag_compact. Their
+// single "key" label joins the group id, topic name and (for partition lags) partition id with "/", for downstream
+// systems that struggle with many labels. Topic and partition lags keep separate names, so that aggregating one of
+// them doesn't count the lag twice. The returned function must be called once all metrics have been sent, it waits
+// until all metrics have been forwarded. If compat mode is disabled ch itself is returned.
+func (e *Exporter) compactLags(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	if !e.cfg.CompatMode {
+		return ch, func() {}
+	}
+
+	compactCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range compactCh {
+			var compactDesc *prometheus.Desc
+			switch metric.Desc() {
+			case e.consumerGroupTopicLag:
+				compactDesc = e.consumerGroupTopicLagCompact
+			case e.consumerGroupTopicPartitionLag:
+				compactDesc = e.consumerGroupTopicPartitionLagCompact
+			default:
+				ch <- metric
+				continue
+			}
+			compacted, ok := compactLag(metric, compactDesc)
+			if !ok {
+				continue
+			}
+			ch <- compacted
+		}
+	}()
+
+	return compactCh, func() {
+		close(compactCh)
+		<-done
+	}
+}
+
+// compactLag returns the lag with the given compact desc, whose key label joins the lag's labels
+func compactLag(metric prometheus.Metric, compactDesc *prometheus.Desc) (prometheus.Metric, bool) {
+	out := &dto.Metric{}
+	err := metric.Write(out)
+	if err != nil {
+		return nil, false
+	}
+
+	labelValues := make(map[string]string, len(out.Label))
+	for _, label := range out.Label {
+		labelValues[label.GetName()] = label.GetValue()
+	}
+	keyParts := make([]string, 0, len(compactLagKeyLabels))
+	for _, labelName := range compactLagKeyLabels {
+		if value, exists := labelValues[labelName]; exists {
+			keyParts = append(keyParts, value)
+		}
+	}
+
+	return prometheus.MustNewConstMetric(
+		compactDesc,
+		prometheus.GaugeValue,
+		out.GetGauge().GetValue(),
+		strings.Join(keyParts, "/"),
+	), true
+}
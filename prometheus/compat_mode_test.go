@@ -0,0 +1,65 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// labelNames returns the names of all labels of the metric
+func labelNames(t *testing.T, metric prometheus.Metric) []string {
+	out := &dto.Metric{}
+	if err := metric.Write(out); err != nil {
+		t.Fatalf("failed to write metric: %v", err)
+	}
+	names := make([]string, 0, len(out.Label))
+	for _, label := range out.Label {
+		names = append(names, label.GetName())
+	}
+	return names
+}
+
+func TestCompatModeCompactsLags(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.CompatMode = true
+	exporter := newTestExporter(t, cfg)
+
+	lags := func(_ context.Context, ch chan<- prometheus.Metric) bool {
+		ch <- prometheus.MustNewConstMetric(exporter.consumerGroupTopicLag, prometheus.GaugeValue, 30, "shop-consumer", "orders")
+		for partitionID, lag := range []float64{10, 20} {
+			ch <- prometheus.MustNewConstMetric(exporter.consumerGroupTopicPartitionLag, prometheus.GaugeValue, lag,
+				"shop-consumer", "orders", strconv.Itoa(partitionID))
+		}
+		return true
+	}
+	metrics := collectMetrics(exporter, []namedCollector{{"consumerGroupLags", lags}})
+
+	for _, metric := range metrics {
+		if desc := metric.Desc(); desc == exporter.consumerGroupTopicLag || desc == exporter.consumerGroupTopicPartitionLag {
+			t.Fatalf("expected the labeled lags to be replaced in compat mode, got %v", desc)
+		}
+	}
+
+	// Topic and partition lags have separate names and a single key label, so that summing one of them doesn't count
+	// the lag twice
+	topicLags := gaugeValues(t, metrics, exporter.consumerGroupTopicLagCompact, "key")
+	if expected := map[string]float64{"shop-consumer/orders": 30}; !reflect.DeepEqual(topicLags, expected) {
+		t.Errorf("expected compact topic lags %v, got %v", expected, topicLags)
+	}
+	partitionLags := gaugeValues(t, metrics, exporter.consumerGroupTopicPartitionLagCompact, "key")
+	expected := map[string]float64{"shop-consumer/orders/0": 10, "shop-consumer/orders/1": 20}
+	if !reflect.DeepEqual(partitionLags, expected) {
+		t.Errorf("expected compact partition lags %v, got %v", expected, partitionLags)
+	}
+	for _, metric := range metrics {
+		if desc := metric.Desc(); desc == exporter.consumerGroupTopicLagCompact || desc == exporter.consumerGroupTopicPartitionLagCompact {
+			if labels := labelNames(t, metric); !reflect.DeepEqual(labels, []string{"key"}) {
+				t.Fatalf("expected compact lags to only have the key label, got %v", labels)
+			}
+		}
+	}
+}
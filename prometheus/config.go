@@ -1,12 +1,163 @@
 package prometheus
 
+import (
+	"fmt"
+	"time"
+)
+
 type Config struct {
 	Host      string `koanf:"host"`
 	Port      int    `koanf:"port"`
 	Namespace string `koanf:"namespace"`
+
+	// Mode specifies whether the metrics are scraped via the HTTP server (pull) or additionally pushed to a
+	// Pushgateway (push).
+	Mode string     `koanf:"mode"`
+	Push PushConfig `koanf:"push"`
+
+	// IncludeClusterID adds the Kafka cluster id as constant label to all exported Kafka metrics, including the
+	// metrics of the Kafka client and the log messages. If the cluster does not report a cluster id, ClusterName is
+	// used instead.
+	IncludeClusterID bool   `koanf:"includeClusterId"`
+	ClusterName      string `koanf:"clusterName"`
+
+	// CompatMode replaces the topic and partition lags by compact metrics whose single "key" label joins the group id,
+	// topic name and partition id, for downstream systems that struggle with many labels.
+	CompatMode bool `koanf:"compatMode"`
+
+	// IncludeBrokerRack adds the rack of the broker as "rack_id" label to the broker metrics that don't carry it
+	// already. Brokers without a configured rack are reported with an empty rack_id.
+	IncludeBrokerRack bool `koanf:"includeBrokerRack"`
+
+	// OpenMetrics enables the OpenMetrics exposition format for scrapers that negotiate it via the Accept header.
+	OpenMetrics bool `koanf:"openMetrics"`
+
+	// CompressResponses gzip-compresses the /metrics response for scrapers that send "Accept-Encoding: gzip".
+	CompressResponses bool `koanf:"compressResponses"`
+
+	// Pprof serves the Go runtime profiling endpoints under /debug/pprof
+	Pprof bool `koanf:"pprof"`
+
+	// DebugScope serves the monitored topics and consumer groups (after applying all filters) as JSON under
+	// /debug/scope
+	DebugScope bool `koanf:"debugScope"`
+
+	// GoCollector and ProcessCollector specify whether the Go runtime (go_*) and process (process_*) metrics shall
+	// be exported.
+	GoCollector      bool `koanf:"goCollector"`
+	ProcessCollector bool `koanf:"processCollector"`
+
+	// MaxConcurrentScrapes limits the number of scrapes that are served at the same time. 0 means unlimited. Excess
+	// scrapes either wait for a free slot or are rejected, depending on the ScrapeLimitMode.
+	MaxConcurrentScrapes int    `koanf:"maxConcurrentScrapes"`
+	ScrapeLimitMode      string `koanf:"scrapeLimitMode"`
+
+	// MetricSet is either "full" or "minimal". The minimal metric set only exports the consumer group lags as well as
+	// the exporter and cluster info metrics.
+	MetricSet string `koanf:"metricSet"`
+
+	// TopicLabelNormalize are regex replacements which are applied in order to the topic_name label values of all
+	// exported metrics. The actual topic names are still used for all requests against Kafka.
+	TopicLabelNormalize []TopicLabelNormalizeRule `koanf:"topicLabelNormalize"`
+
+	// MaxSeries limits the number of topic, partition and consumer group series exported per scrape. The series are
+	// limited in a fixed order, so that the same series are kept on every scrape. Cluster, broker and exporter metrics
+	// are always exported. 0 means unlimited.
+	MaxSeries int `koanf:"maxSeries"`
+
+	// StaleSeriesGracePeriod is the number of scrapes for which topic, partition and consumer group series are still
+	// exported with their last value after they have disappeared (e.g. because the topic has been deleted). 0 means
+	// that such series are removed with the first scrape that doesn't contain them anymore.
+	StaleSeriesGracePeriod int `koanf:"staleSeriesGracePeriod"`
+
+	// CollectorTimeout is the maximum duration a single collector may take. All collectors of a scrape run
+	// concurrently, so that a slow collector does not delay the others and a scrape takes at most this long.
+	CollectorTimeout time.Duration `koanf:"collectorTimeout"`
+
+	// WarmupScrapes is the number of scrapes in which each collector must have succeeded before /ready reports
+	// KMinion as ready. Successful runs are counted per collector, so they don't have to succeed in the same scrape.
+	// A collector that has failed in this many scrapes, e.g. due to missing permissions, doesn't block readiness. In
+	// pull mode KMinion runs the warmup scrapes itself at startup. This prevents alerts on partially populated metrics
+	// right after startup.
+	WarmupScrapes int `koanf:"warmupScrapes"`
+
+	// HTTP configures the HTTP server that serves the metrics
+	HTTP HTTPConfig `koanf:"http"`
 }
 
 func (c *Config) SetDefaults() {
 	c.Port = 8080
 	c.Namespace = "kminion"
+	c.Mode = ExporterModePull
+	c.Push.SetDefaults()
+	c.GoCollector = true
+	c.ProcessCollector = true
+	c.CompressResponses = true
+	c.ScrapeLimitMode = ScrapeLimitModeWait
+	c.CollectorTimeout = 30 * time.Second
+	c.MetricSet = MetricSetFull
+	c.HTTP.SetDefaults()
+}
+
+func (c *Config) Validate() error {
+	switch c.Mode {
+	case ExporterModePull:
+	case ExporterModePush:
+		err := c.Push.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate push config: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid exporter mode '%v' specified. Valid modes are '%v' or '%v'",
+			c.Mode,
+			ExporterModePull,
+			ExporterModePush)
+	}
+
+	switch c.MetricSet {
+	case MetricSetFull, MetricSetMinimal:
+	default:
+		return fmt.Errorf("invalid metric set '%v' specified. Valid metric sets are '%v' or '%v'",
+			c.MetricSet,
+			MetricSetFull,
+			MetricSetMinimal)
+	}
+
+	for i, rule := range c.TopicLabelNormalize {
+		err := rule.Validate()
+		if err != nil {
+			return fmt.Errorf("failed to validate topic label normalize rule at index %d: %w", i, err)
+		}
+	}
+
+	if c.WarmupScrapes < 0 {
+		return fmt.Errorf("warmup scrapes must not be negative")
+	}
+	if c.MaxSeries < 0 {
+		return fmt.Errorf("max series must not be negative")
+	}
+	if c.StaleSeriesGracePeriod < 0 {
+		return fmt.Errorf("stale series grace period must not be negative")
+	}
+	if c.CollectorTimeout <= 0 {
+		return fmt.Errorf("collector timeout must be greater than 0")
+	}
+	if c.MaxConcurrentScrapes < 0 {
+		return fmt.Errorf("max concurrent scrapes must not be negative")
+	}
+	switch c.ScrapeLimitMode {
+	case ScrapeLimitModeWait, ScrapeLimitModeReject:
+	default:
+		return fmt.Errorf("invalid scrape limit mode '%v' specified. Valid modes are '%v' or '%v'",
+			c.ScrapeLimitMode,
+			ScrapeLimitModeWait,
+			ScrapeLimitModeReject)
+	}
+
+	err := c.HTTP.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate http config: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,33 @@
+package prometheus
+
+import (
+	"fmt"
+	"time"
+)
+
+// HTTPConfig for the HTTP server that serves the metrics endpoint
+type HTTPConfig struct {
+	ReadTimeout  time.Duration `koanf:"readTimeout"`
+	WriteTimeout time.Duration `koanf:"writeTimeout"`
+	IdleTimeout  time.Duration `koanf:"idleTimeout"`
+}
+
+func (c *HTTPConfig) SetDefaults() {
+	c.ReadTimeout = 10 * time.Second
+	c.WriteTimeout = 60 * time.Second
+	c.IdleTimeout = 120 * time.Second
+}
+
+func (c *HTTPConfig) Validate() error {
+	if c.ReadTimeout <= 0 {
+		return fmt.Errorf("http read timeout must be a positive duration")
+	}
+	if c.WriteTimeout <= 0 {
+		return fmt.Errorf("http write timeout must be a positive duration")
+	}
+	if c.IdleTimeout <= 0 {
+		return fmt.Errorf("http idle timeout must be a positive duration")
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import "fmt"
+
+const (
+	ExporterModePull string = "pull"
+	ExporterModePush string = "push"
+)
+
+// PushConfig for pushing the collected metrics to a Prometheus Pushgateway
+type PushConfig struct {
+	// URL of the Pushgateway, e.g. http://pushgateway:9091
+	URL string `koanf:"url"`
+
+	// Job is the value of the job label the metrics are pushed with
+	Job string `koanf:"job"`
+
+	// GroupingKey contains additional labels which identify the pushed metric group, e.g. the cluster name
+	GroupingKey map[string]string `koanf:"groupingKey"`
+}
+
+func (c *PushConfig) SetDefaults() {
+	c.Job = "kminion"
+}
+
+func (c *PushConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("a pushgateway url must be configured in push mode")
+	}
+	if c.Job == "" {
+		return fmt.Errorf("a job name must be configured in push mode")
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TopicLabelNormalizeRule replaces all matches of Pattern in topic_name label values with Replacement
+type TopicLabelNormalizeRule struct {
+	// Pattern is a regular expression, e.g. "[^a-zA-Z0-9_-]"
+	Pattern string `koanf:"pattern"`
+
+	// Replacement may refer to capture groups of the pattern, e.g. "${1}"
+	Replacement string `koanf:"replacement"`
+}
+
+func (c *TopicLabelNormalizeRule) Validate() error {
+	if c.Pattern == "" {
+		return fmt.Errorf("pattern must be set")
+	}
+	_, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return fmt.Errorf("pattern '%v' is not valid regex: %w", c.Pattern, err)
+	}
+
+	return nil
+}
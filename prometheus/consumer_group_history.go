@@ -0,0 +1,321 @@
+package prometheus
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// minRateInterval is the minimum time between two samples that is required to calculate a rate. Samples that
+	// arrive more frequently (e.g. because multiple Prometheus instances scrape KMinion) reuse the previous rate.
+	minRateInterval = 5 * time.Second
+
+	// historyRetention is the duration after which samples that haven't been updated will be evicted, e.g. because
+	// the group or topic has been deleted.
+	historyRetention = time.Hour
+)
+
+// consumerGroupHistory remembers consumer group offsets across scrapes, so that we can derive metrics which depend on
+// previous scrapes, such as the consumption rate of a group. It is safe for concurrent use.
+type consumerGroupHistory struct {
+	mutex sync.Mutex
+
+	// topicOffsets is indexed by group id and topic name
+	topicOffsets map[string]map[string]offsetSumSample
+
+	// partitionOffsets is indexed by group id, topic name and partition id
+	partitionOffsets map[string]map[string]map[int32]partitionOffsetSample
+
+	// lagSamples contains the most recent lags of each group on a topic or partition
+	lagSamples map[lagSampleKey]*lagSampleWindow
+
+	// groupOffsets contains the last-known offsets of each group, indexed by group id
+	groupOffsets map[string]*groupOffsetsSample
+
+	// offsetChanges contains the time at which the offsets of each group have last changed, indexed by group id
+	offsetChanges map[string]offsetChangeSample
+}
+
+type offsetChangeSample struct {
+	OffsetSum int64
+	ChangedAt time.Time
+	Timestamp time.Time
+}
+
+type groupOffsetsSample struct {
+	Offsets   map[string]map[int32]groupPartitionOffset
+	Timestamp time.Time
+
+	// ExpiredAt is the time at which the group's offsets have been found to be expired. It's zero as long as the
+	// group has committed offsets.
+	ExpiredAt time.Time
+	// Expirations is the number of times the group's offsets have expired
+	Expirations int
+}
+
+// lagSampleKey identifies a lag series. PartitionID is -1 for the summed lag of a topic.
+type lagSampleKey struct {
+	GroupID     string
+	TopicName   string
+	PartitionID int32
+}
+
+type lagSampleWindow struct {
+	Lags      []float64
+	Timestamp time.Time
+}
+
+type offsetSumSample struct {
+	OffsetSum float64
+	Timestamp time.Time
+	// PartitionCount is the number of partitions whose offsets have been summed up
+	PartitionCount int
+
+	// Rate is the number of consumed messages per second between the previous and this sample
+	Rate    float64
+	HasRate bool
+}
+
+type partitionOffsetSample struct {
+	Offset    int64
+	Timestamp time.Time
+
+	// Resets is the number of times the committed offset has been lower than in the previous sample
+	Resets int
+}
+
+func newConsumerGroupHistory() *consumerGroupHistory {
+	return &consumerGroupHistory{
+		topicOffsets:     make(map[string]map[string]offsetSumSample),
+		partitionOffsets: make(map[string]map[string]map[int32]partitionOffsetSample),
+		lagSamples:       make(map[lagSampleKey]*lagSampleWindow),
+		groupOffsets:     make(map[string]*groupOffsetsSample),
+		offsetChanges:    make(map[string]offsetChangeSample),
+	}
+}
+
+// observeTopicOffsetSum stores the summed committed offsets of a group on a topic and returns the consumption rate in
+// messages per second since the previous sample. The returned bool is false as long as there is no previous sample.
+// Offsets that have been reset to a lower value result in a rate of 0. If the sum covers a different number of
+// partitions than the previous sample (e.g. because a partition has been added or its water marks were missing), the
+// sample is stored as new baseline and no rate is returned, as the difference would be a bogus spike.
+func (h *consumerGroupHistory) observeTopicOffsetSum(groupID string, topicName string, offsetSum float64, partitionCount int, now time.Time) (float64, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, exists := h.topicOffsets[groupID]; !exists {
+		h.topicOffsets[groupID] = make(map[string]offsetSumSample)
+	}
+
+	previous, exists := h.topicOffsets[groupID][topicName]
+	if !exists || previous.PartitionCount != partitionCount {
+		h.topicOffsets[groupID][topicName] = offsetSumSample{OffsetSum: offsetSum, Timestamp: now, PartitionCount: partitionCount}
+		return 0, false
+	}
+
+	elapsed := now.Sub(previous.Timestamp)
+	if elapsed < minRateInterval {
+		return previous.Rate, previous.HasRate
+	}
+
+	rate := (offsetSum - previous.OffsetSum) / elapsed.Seconds()
+	if rate < 0 {
+		rate = 0
+	}
+	h.topicOffsets[groupID][topicName] = offsetSumSample{
+		OffsetSum:      offsetSum,
+		Timestamp:      now,
+		PartitionCount: partitionCount,
+		Rate:           rate,
+		HasRate:        true,
+	}
+
+	return rate, true
+}
+
+// observePartitionOffset stores the committed offset of a group on a partition and returns the number of times the
+// offset has gone backwards (e.g. because the offsets have been reset to the earliest offset) since it's been tracked.
+func (h *consumerGroupHistory) observePartitionOffset(groupID string, topicName string, partitionID int32, offset int64, now time.Time) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, exists := h.partitionOffsets[groupID]; !exists {
+		h.partitionOffsets[groupID] = make(map[string]map[int32]partitionOffsetSample)
+	}
+	if _, exists := h.partitionOffsets[groupID][topicName]; !exists {
+		h.partitionOffsets[groupID][topicName] = make(map[int32]partitionOffsetSample)
+	}
+
+	previous := h.partitionOffsets[groupID][topicName][partitionID]
+	resets := previous.Resets
+	if !previous.Timestamp.IsZero() && offset < previous.Offset {
+		resets++
+	}
+	h.partitionOffsets[groupID][topicName][partitionID] = partitionOffsetSample{Offset: offset, Timestamp: now, Resets: resets}
+
+	return resets
+}
+
+// observeLag stores the lag of a group on a topic or partition and returns the average of the most recent lags, up to
+// the given number of samples. Pass -1 as partition id for the summed lag of a topic.
+func (h *consumerGroupHistory) observeLag(groupID string, topicName string, partitionID int32, lag float64, maxSamples int, now time.Time) float64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := lagSampleKey{GroupID: groupID, TopicName: topicName, PartitionID: partitionID}
+	window, exists := h.lagSamples[key]
+	if !exists {
+		window = &lagSampleWindow{}
+		h.lagSamples[key] = window
+	}
+
+	if len(window.Lags) == 0 || now.Sub(window.Timestamp) >= minRateInterval {
+		window.Lags = append(window.Lags, lag)
+		window.Timestamp = now
+	} else {
+		// Scrapes that arrive more frequently replace the most recent sample, so that the window is not dominated
+		// by multiple Prometheus instances scraping at the same time
+		window.Lags[len(window.Lags)-1] = lag
+	}
+	if len(window.Lags) > maxSamples {
+		window.Lags = window.Lags[len(window.Lags)-maxSamples:]
+	}
+
+	sum := float64(0)
+	for _, sample := range window.Lags {
+		sum += sample
+	}
+
+	return sum / float64(len(window.Lags))
+}
+
+// observeGroupOffsets stores the offsets of an existing group. If the group used to have offsets, but has none anymore,
+// its offsets are considered expired. In that case the last-known offsets and the time of the expiry are returned.
+// Additionally, the number of expirations since the group is tracked is returned.
+func (h *consumerGroupHistory) observeGroupOffsets(groupID string, offsets map[string]map[int32]groupPartitionOffset, now time.Time) (map[string]map[int32]groupPartitionOffset, time.Time, int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	hasOffsets := false
+	for _, partitions := range offsets {
+		if len(partitions) > 0 {
+			hasOffsets = true
+			break
+		}
+	}
+
+	sample, exists := h.groupOffsets[groupID]
+	if !exists {
+		sample = &groupOffsetsSample{}
+		h.groupOffsets[groupID] = sample
+	}
+	sample.Timestamp = now
+
+	if hasOffsets {
+		sample.Offsets = offsets
+		sample.ExpiredAt = time.Time{}
+		return nil, time.Time{}, sample.Expirations
+	}
+	if sample.Offsets == nil {
+		// The group has never had any offsets since we track it
+		return nil, time.Time{}, sample.Expirations
+	}
+	if sample.ExpiredAt.IsZero() {
+		sample.ExpiredAt = now
+		sample.Expirations++
+	}
+
+	return sample.Offsets, sample.ExpiredAt, sample.Expirations
+}
+
+// observeOffsetChange stores the summed committed offsets of a group and returns the time at which they have last
+// changed. Groups whose offsets haven't changed since they are tracked return the time of the first observation.
+func (h *consumerGroupHistory) observeOffsetChange(groupID string, offsets map[string]map[int32]groupPartitionOffset, now time.Time) time.Time {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	offsetSum := int64(0)
+	for _, partitions := range offsets {
+		for _, partition := range partitions {
+			offsetSum += partition.Offset
+		}
+	}
+
+	previous, exists := h.offsetChanges[groupID]
+	changedAt := previous.ChangedAt
+	if !exists || previous.OffsetSum != offsetSum {
+		changedAt = now
+	}
+	h.offsetChanges[groupID] = offsetChangeSample{OffsetSum: offsetSum, ChangedAt: changedAt, Timestamp: now}
+
+	return changedAt
+}
+
+// evictStaleSamples removes all samples that haven't been updated within the history retention.
+func (h *consumerGroupHistory) evictStaleSamples(now time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for groupID, topics := range h.topicOffsets {
+		for topicName, sample := range topics {
+			if now.Sub(sample.Timestamp) > historyRetention {
+				delete(topics, topicName)
+			}
+		}
+		if len(topics) == 0 {
+			delete(h.topicOffsets, groupID)
+		}
+	}
+
+	for groupID, topics := range h.partitionOffsets {
+		for topicName, partitions := range topics {
+			for partitionID, sample := range partitions {
+				if now.Sub(sample.Timestamp) > historyRetention {
+					delete(partitions, partitionID)
+				}
+			}
+			if len(partitions) == 0 {
+				delete(topics, topicName)
+			}
+		}
+		if len(topics) == 0 {
+			delete(h.partitionOffsets, groupID)
+		}
+	}
+
+	for key, window := range h.lagSamples {
+		if now.Sub(window.Timestamp) > historyRetention {
+			delete(h.lagSamples, key)
+		}
+	}
+
+	for groupID, sample := range h.groupOffsets {
+		if now.Sub(sample.Timestamp) > historyRetention {
+			delete(h.groupOffsets, groupID)
+		}
+	}
+
+	for groupID, sample := range h.offsetChanges {
+		if now.Sub(sample.Timestamp) > historyRetention {
+			delete(h.offsetChanges, groupID)
+		}
+	}
+}
+
+// entries returns the number of samples that are currently stored, counting each topic and partition sample
+// separately
+func (h *consumerGroupHistory) entries() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := len(h.lagSamples) + len(h.groupOffsets) + len(h.offsetChanges)
+	for _, topics := range h.topicOffsets {
+		count += len(topics)
+	}
+	for _, topics := range h.partitionOffsets {
+		for _, partitions := range topics {
+			count += len(partitions)
+		}
+	}
+	return count
+}
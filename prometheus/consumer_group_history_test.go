@@ -0,0 +1,359 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+)
+
+var historyStart = time.Unix(1600000000, 0)
+
+// at returns the time that is the given number of seconds after historyStart
+func at(seconds float64) time.Time {
+	return historyStart.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func TestObserveTopicOffsetSum(t *testing.T) {
+	type sample struct {
+		seconds    float64
+		offsetSum  float64
+		partitions int
+		rate       float64
+		hasRate    bool
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+	}{
+		{
+			name: "first sample has no rate",
+			samples: []sample{
+				{0, 100, 2, 0, false},
+			},
+		},
+		{
+			name: "rate between samples",
+			samples: []sample{
+				{0, 100, 2, 0, false},
+				{10, 200, 2, 10, true},
+				{20, 250, 2, 5, true},
+			},
+		},
+		{
+			name: "frequent samples reuse the previous rate",
+			samples: []sample{
+				{0, 100, 2, 0, false},
+				{2, 500, 2, 0, false},
+				{10, 200, 2, 10, true},
+				{12, 1000, 2, 10, true},
+				{20, 300, 2, 10, true},
+			},
+		},
+		{
+			name: "changed partition count starts a new baseline",
+			samples: []sample{
+				{0, 100, 2, 0, false},
+				{10, 200, 2, 10, true},
+				{20, 150, 1, 0, false},
+				{30, 250, 1, 10, true},
+			},
+		},
+		{
+			name: "reset offsets result in a rate of zero",
+			samples: []sample{
+				{0, 100, 2, 0, false},
+				{10, 0, 2, 0, true},
+				{20, 50, 2, 5, true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := newConsumerGroupHistory()
+			for i, s := range test.samples {
+				rate, hasRate := history.observeTopicOffsetSum("group", "topic", s.offsetSum, s.partitions, at(s.seconds))
+				if rate != s.rate || hasRate != s.hasRate {
+					t.Errorf("sample %d: expected rate %v (%v), got %v (%v)", i, s.rate, s.hasRate, rate, hasRate)
+				}
+			}
+		})
+	}
+}
+
+func TestObservePartitionOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		offsets []int64
+		resets  []int
+	}{
+		{"increasing offsets", []int64{0, 10, 10, 20}, []int{0, 0, 0, 0}},
+		{"single reset", []int64{100, 200, 0, 50}, []int{0, 0, 1, 1}},
+		{"resets are accumulated", []int64{100, 50, 80, 10, 5}, []int{0, 1, 1, 2, 3}},
+		{"first offset of zero is no reset", []int64{0, 0}, []int{0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := newConsumerGroupHistory()
+			for i, offset := range test.offsets {
+				resets := history.observePartitionOffset("group", "topic", 0, offset, at(float64(i*10)))
+				if resets != test.resets[i] {
+					t.Errorf("sample %d: expected %d resets, got %d", i, test.resets[i], resets)
+				}
+			}
+		})
+	}
+
+	// Resets are tracked per partition
+	history := newConsumerGroupHistory()
+	history.observePartitionOffset("group", "topic", 0, 100, at(0))
+	history.observePartitionOffset("group", "topic", 0, 10, at(10))
+	if resets := history.observePartitionOffset("group", "topic", 1, 5, at(10)); resets != 0 {
+		t.Errorf("expected no resets on another partition, got %d", resets)
+	}
+}
+
+func TestObserveLag(t *testing.T) {
+	type sample struct {
+		seconds     float64
+		lag         float64
+		smoothedLag float64
+	}
+	tests := []struct {
+		name       string
+		maxSamples int
+		samples    []sample
+	}{
+		{
+			name:       "single sample is not smoothed",
+			maxSamples: 1,
+			samples: []sample{
+				{0, 10, 10},
+				{10, 20, 20},
+			},
+		},
+		{
+			name:       "average of the most recent samples",
+			maxSamples: 3,
+			samples: []sample{
+				{0, 30, 30},
+				{10, 60, 45},
+				{20, 90, 60},
+				{30, 0, 50},
+			},
+		},
+		{
+			name:       "frequent samples replace the most recent sample",
+			maxSamples: 3,
+			samples: []sample{
+				{0, 10, 10},
+				{10, 20, 15},
+				{12, 40, 25},
+				{20, 70, 40},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := newConsumerGroupHistory()
+			for i, s := range test.samples {
+				smoothedLag := history.observeLag("group", "topic", 0, s.lag, test.maxSamples, at(s.seconds))
+				if smoothedLag != s.smoothedLag {
+					t.Errorf("sample %d: expected smoothed lag %v, got %v", i, s.smoothedLag, smoothedLag)
+				}
+			}
+		})
+	}
+
+	// The summed topic lag (partition id -1) is smoothed separately from the partition lags
+	history := newConsumerGroupHistory()
+	history.observeLag("group", "topic", 0, 100, 3, at(0))
+	if smoothedLag := history.observeLag("group", "topic", -1, 10, 3, at(0)); smoothedLag != 10 {
+		t.Errorf("expected topic lag of 10, got %v", smoothedLag)
+	}
+}
+
+func TestObserveGroupOffsets(t *testing.T) {
+	offsets := map[string]map[int32]groupPartitionOffset{
+		"topic": {0: {Offset: 10}},
+	}
+	noOffsets := map[string]map[int32]groupPartitionOffset{}
+
+	type sample struct {
+		seconds     float64
+		offsets     map[string]map[int32]groupPartitionOffset
+		expired     bool
+		expiredAt   float64
+		expirations int
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+	}{
+		{
+			name: "group without offsets has never expired",
+			samples: []sample{
+				{0, noOffsets, false, 0, 0},
+				{10, noOffsets, false, 0, 0},
+			},
+		},
+		{
+			name: "expired offsets keep the time of the expiry",
+			samples: []sample{
+				{0, offsets, false, 0, 0},
+				{10, noOffsets, true, 10, 1},
+				{20, noOffsets, true, 10, 1},
+			},
+		},
+		{
+			name: "expirations are counted",
+			samples: []sample{
+				{0, offsets, false, 0, 0},
+				{10, noOffsets, true, 10, 1},
+				{20, offsets, false, 0, 1},
+				{30, noOffsets, true, 30, 2},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := newConsumerGroupHistory()
+			for i, s := range test.samples {
+				lastKnown, expiredAt, expirations := history.observeGroupOffsets("group", s.offsets, at(s.seconds))
+				if expirations != s.expirations {
+					t.Errorf("sample %d: expected %d expirations, got %d", i, s.expirations, expirations)
+				}
+				if !s.expired {
+					if lastKnown != nil || !expiredAt.IsZero() {
+						t.Errorf("sample %d: expected offsets not to be expired, got expiry at %v", i, expiredAt)
+					}
+					continue
+				}
+				if lastKnown["topic"][0].Offset != 10 {
+					t.Errorf("sample %d: expected the last-known offsets, got %v", i, lastKnown)
+				}
+				if !expiredAt.Equal(at(s.expiredAt)) {
+					t.Errorf("sample %d: expected expiry at %v, got %v", i, at(s.expiredAt), expiredAt)
+				}
+			}
+		})
+	}
+}
+
+func TestObserveOffsetChange(t *testing.T) {
+	newOffsets := func(offset int64) map[string]map[int32]groupPartitionOffset {
+		return map[string]map[int32]groupPartitionOffset{"topic": {0: {Offset: offset}, 1: {Offset: 5}}}
+	}
+
+	type sample struct {
+		seconds   float64
+		offset    int64
+		changedAt float64
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+	}{
+		{
+			name: "unchanged offsets keep the time of the first observation",
+			samples: []sample{
+				{0, 10, 0},
+				{10, 10, 0},
+				{20, 10, 0},
+			},
+		},
+		{
+			name: "changed offsets update the time",
+			samples: []sample{
+				{0, 10, 0},
+				{10, 20, 10},
+				{20, 20, 10},
+				{30, 15, 30},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := newConsumerGroupHistory()
+			for i, s := range test.samples {
+				changedAt := history.observeOffsetChange("group", newOffsets(s.offset), at(s.seconds))
+				if !changedAt.Equal(at(s.changedAt)) {
+					t.Errorf("sample %d: expected change at %v, got %v", i, at(s.changedAt), changedAt)
+				}
+			}
+		})
+	}
+}
+
+// storedSampleKinds returns the number of different kinds of samples that are stored for the given group
+func storedSampleKinds(h *consumerGroupHistory, groupID string) int {
+	kinds := 0
+	if _, exists := h.topicOffsets[groupID]; exists {
+		kinds++
+	}
+	if _, exists := h.partitionOffsets[groupID]; exists {
+		kinds++
+	}
+	for key := range h.lagSamples {
+		if key.GroupID == groupID {
+			kinds++
+			break
+		}
+	}
+	if _, exists := h.groupOffsets[groupID]; exists {
+		kinds++
+	}
+	if _, exists := h.offsetChanges[groupID]; exists {
+		kinds++
+	}
+	return kinds
+}
+
+func TestEvictStaleSamples(t *testing.T) {
+	offsets := map[string]map[int32]groupPartitionOffset{"topic": {0: {Offset: 10}}}
+	observe := func(history *consumerGroupHistory, groupID string, now time.Time) {
+		history.observeTopicOffsetSum(groupID, "topic", 10, 1, now)
+		history.observePartitionOffset(groupID, "topic", 0, 10, now)
+		history.observeLag(groupID, "topic", 0, 10, 3, now)
+		history.observeGroupOffsets(groupID, offsets, now)
+		history.observeOffsetChange(groupID, offsets, now)
+	}
+	sampleKinds := 5
+
+	history := newConsumerGroupHistory()
+	observe(history, "stale", at(0))
+	observe(history, "active", at(0))
+	observe(history, "active", at(0).Add(historyRetention))
+
+	// Samples are kept up to the retention
+	history.evictStaleSamples(at(0).Add(historyRetention))
+	if kinds := storedSampleKinds(history, "stale"); kinds != sampleKinds {
+		t.Fatalf("expected %d kinds of samples before the retention has passed, got %d", sampleKinds, kinds)
+	}
+
+	history.evictStaleSamples(at(1).Add(historyRetention))
+	if kinds := storedSampleKinds(history, "stale"); kinds != 0 {
+		t.Errorf("expected the stale group to be evicted, got %d kinds of samples", kinds)
+	}
+	if kinds := storedSampleKinds(history, "active"); kinds != sampleKinds {
+		t.Errorf("expected the active group to be kept, got %d kinds of samples", kinds)
+	}
+
+	// Evicted samples start from scratch
+	if resets := history.observePartitionOffset("stale", "topic", 0, 0, at(2).Add(historyRetention)); resets != 0 {
+		t.Errorf("expected no resets after eviction, got %d", resets)
+	}
+	if _, hasRate := history.observeTopicOffsetSum("stale", "topic", 20, 1, at(2).Add(historyRetention)); hasRate {
+		t.Errorf("expected no rate after eviction")
+	}
+	if smoothedLag := history.observeLag("stale", "topic", 0, 40, 3, at(2).Add(historyRetention)); smoothedLag != 40 {
+		t.Errorf("expected the lag not to be smoothed with evicted samples, got %v", smoothedLag)
+	}
+	restart := at(2).Add(historyRetention)
+	if changedAt := history.observeOffsetChange("stale", offsets, restart); !changedAt.Equal(restart) {
+		t.Errorf("expected the offset change to be tracked from scratch, got %v", changedAt)
+	}
+}
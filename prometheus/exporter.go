@@ -7,6 +7,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -16,36 +17,126 @@ type Exporter struct {
 	logger    *zap.Logger
 	minionSvc *minion.Service
 
+	// groupHistory stores consumer group offsets of previous scrapes
+	groupHistory *consumerGroupHistory
+	topicHistory *topicHistory
+	pushTiming   *pushTiming
+
+	topicLabelNormalizer *topicLabelNormalizer
+	staleSeries          *staleSeriesTracker
+
+	// collectorCache remembers the metrics of collectors that run on their own interval
+	collectorCache *collectorCache
+
+	// collectorRuns counts the successful and failed runs of each collector since startup
+	collectorRunsMutex sync.Mutex
+	collectorRuns      map[string]collectorRunCount
+
 	// Exporter metrics
 	exporterUp                    *prometheus.Desc
+	collectorUp                   *prometheus.Desc
 	offsetConsumerRecordsConsumed *prometheus.Desc
+	startupPreflightOk            *prometheus.Desc
+	configuredTopicMissing        *prometheus.Desc
+	seriesLimitExceeded           *prometheus.CounterVec
+	watermarkErrors               *prometheus.Desc
+	lastScrapeTimestamp           *prometheus.Desc
+	collectionInterval            *prometheus.Desc
+	actualCollectionGap           *prometheus.Desc
+	trackedSeries                 *prometheus.Desc
+	internalCacheEntries          *prometheus.Desc
+
+	// End to end
+	endToEndIngestDelay *prometheus.Desc
+	roundtripOk         *prometheus.Desc
+
+	endToEndRoundtrips             *prometheus.Desc
+	endToEndRoundtripsOverSLA      *prometheus.Desc
+	endToEndProduceUnderReplicated *prometheus.Desc
+
+	// Transactions
+	oldestOpenTransactionAge *prometheus.Desc
 
 	// Kafka metrics
 	// General
-	clusterInfo *prometheus.Desc
-	brokerInfo  *prometheus.Desc
+	clusterInfo        *prometheus.Desc
+	brokerInfo         *prometheus.Desc
+	brokerIsController *prometheus.Desc
+	topicCount         *prometheus.Desc
+	partitionCount     *prometheus.Desc
+	apiVersion         *prometheus.Desc
+
+	preferredLeaderImbalance *prometheus.Desc
+	brokerConfig             *prometheus.Desc
 
 	// Log Dir Sizes
 	brokerLogDirSize *prometheus.Desc
 	topicLogDirSize  *prometheus.Desc
+	topicBytesInRate *prometheus.Desc
 
 	// Topic / Partition
 	topicInfo              *prometheus.Desc
+	topicISRRisk           *prometheus.Desc
+	topicAge               *prometheus.Desc
 	topicHighWaterMarkSum  *prometheus.Desc
 	partitionHighWaterMark *prometheus.Desc
 	topicLowWaterMarkSum   *prometheus.Desc
 	partitionLowWaterMark  *prometheus.Desc
 
+	// Topic policy checks
+	topicReplicationFactorBelowMin     *prometheus.Desc
+	topicPartitionCountPolicyViolation *prometheus.Desc
+
+	// Partition replicas
+	partitionInSyncReplicas *prometheus.Desc
+	partitionISRChanges     *prometheus.Desc
+	partitionReplicas       *prometheus.Desc
+
+	partitionLeaderIsPreferred *prometheus.Desc
+	partitionLeaderEpoch       *prometheus.Desc
+
 	// Consumer Groups
 	consumerGroupInfo              *prometheus.Desc
+	consumerGroupProtocol          *prometheus.Desc
+	consumerGroupGeneration        *prometheus.Desc
+	consumerGroupDistinctClients   *prometheus.Desc
 	consumerGroupTopicOffsetSum    *prometheus.Desc
 	consumerGroupTopicPartitionLag *prometheus.Desc
 	consumerGroupTopicLag          *prometheus.Desc
+	consumerGroupTopicMaxLag       *prometheus.Desc
 	offsetCommits                  *prometheus.Desc
+
+	consumerGroupTopicLagCompact          *prometheus.Desc
+	consumerGroupTopicPartitionLagCompact *prometheus.Desc
+
+	consumerGroupTopicPartitionUncommittedLag *prometheus.Desc
+	consumerGroupTopicEstimatedDrainSeconds   *prometheus.Desc
+	consumerGroupOffsetResets                 *prometheus.Desc
+	consumerGroupTopicPartitionSmoothedLag    *prometheus.Desc
+	consumerGroupTopicSmoothedLag             *prometheus.Desc
+	consumerGroupOffsetsExpired               *prometheus.Desc
+	consumerGroupTopicMemberLag               *prometheus.Desc
+	consumerGroupStale                        *prometheus.Desc
+	consumerGroupTopicLagBytes                *prometheus.Desc
+	consumerGroupLaggingPartitions            *prometheus.Desc
+	consumerGroupTopicPartitionTimeLag        *prometheus.Desc
+	consumerGroupTopicTimeLag                 *prometheus.Desc
+	consumerGroupTopicConsumeRate             *prometheus.Desc
 }
 
 func NewExporter(cfg Config, logger *zap.Logger, minionSvc *minion.Service) (*Exporter, error) {
-	return &Exporter{cfg: cfg, logger: logger, minionSvc: minionSvc}, nil
+	return &Exporter{
+		cfg:                  cfg,
+		logger:               logger,
+		minionSvc:            minionSvc,
+		groupHistory:         newConsumerGroupHistory(),
+		topicHistory:         newTopicHistory(),
+		pushTiming:           &pushTiming{},
+		topicLabelNormalizer: newTopicLabelNormalizer(cfg.TopicLabelNormalize, logger),
+		staleSeries:          newStaleSeriesTracker(cfg.StaleSeriesGracePeriod),
+		collectorCache:       newCollectorCache(),
+		collectorRuns:        make(map[string]collectorRunCount),
+	}, nil
 }
 
 func (e *Exporter) InitializeMetrics() {
@@ -57,6 +148,45 @@ func (e *Exporter) InitializeMetrics() {
 		nil,
 		map[string]string{"version": os.Getenv("VERSION")},
 	)
+	// Collector up
+	e.collectorUp = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "collector_up"),
+		"Gauge value is 1 if the last run of the given collector was fully successful, otherwise 0.",
+		[]string{"collector"},
+		nil,
+	)
+	// Scrape timing
+	e.lastScrapeTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "last_scrape_timestamp_seconds"),
+		"Unix timestamp of the time this scrape (or collection in push mode) has been started",
+		nil,
+		nil,
+	)
+	e.collectionInterval = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "collection_interval_seconds"),
+		"The configured interval in which metrics are collected and pushed. Only reported in push mode.",
+		nil,
+		nil,
+	)
+	e.actualCollectionGap = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "actual_collection_gap_seconds"),
+		"The number of seconds between the starts of the two most recent collections in push mode",
+		nil,
+		nil,
+	)
+	// Self observability
+	e.trackedSeries = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "tracked_series"),
+		"The number of series that have been exported by all collectors in this scrape",
+		nil,
+		nil,
+	)
+	e.internalCacheEntries = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "internal_cache_entries"),
+		"The number of entries in the given internal cache",
+		[]string{"cache"},
+		nil,
+	)
 	// OffsetConsumer records consumed
 	e.offsetConsumerRecordsConsumed = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "exporter", "offset_consumer_records_consumed_total"),
@@ -64,6 +194,68 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{},
 		nil,
 	)
+	// Series limit exceeded
+	e.seriesLimitExceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: e.cfg.Namespace,
+		Name:      "series_limit_exceeded_total",
+		Help:      "The number of scrapes in which the given collector had to drop series, because the series limit has been exceeded",
+	}, []string{"collector"})
+	// Water mark errors
+	e.watermarkErrors = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "partition_watermark_errors_total"),
+		"The number of scrapes in which the water marks of a partition couldn't be fetched, so that no consumer group lags could be calculated for the partition",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+	// Startup preflight
+	e.startupPreflightOk = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "startup_preflight_ok"),
+		"Gauge value is 1 if the startup preflight has verified that all required permissions are granted, otherwise 0.",
+		[]string{},
+		nil,
+	)
+	// Configured topic missing
+	e.configuredTopicMissing = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "", "configured_topic_missing"),
+		"Reports 1 for each topic that is allowed by its literal name, but did not exist at startup",
+		[]string{"topic_name"},
+		nil,
+	)
+
+	// End to end metrics
+	// Ingest delay of existing topics
+	e.endToEndIngestDelay = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "end_to_end", "ingest_delay_seconds"),
+		"Delay between the timestamp of a record in an existing topic and the time it has been consumed by KMinion",
+		[]string{"topic_name"},
+		nil,
+	)
+	// Produce and consume roundtrip
+	e.roundtripOk = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "roundtrip_ok"),
+		"Gauge value is 1 if the most recent roundtrip has produced and consumed a record within the timeout, otherwise 0",
+		[]string{},
+		nil,
+	)
+	e.endToEndRoundtrips = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "end_to_end", "roundtrips_total"),
+		"The number of roundtrips that have been completed since startup",
+		[]string{},
+		nil,
+	)
+	e.endToEndRoundtripsOverSLA = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "end_to_end", "roundtrips_over_sla_total"),
+		"The number of roundtrips that have failed or taken longer than the configured SLA since startup",
+		[]string{},
+		nil,
+	)
+	e.endToEndProduceUnderReplicated = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "end_to_end", "produce_under_replicated_total"),
+		"The number of roundtrip records that have been produced successfully to a partition with fewer in sync "+
+			"replicas than replicas since startup",
+		[]string{},
+		nil,
+	)
 
 	// Kafka metrics
 	// Cluster info
@@ -73,6 +265,47 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"cluster_version", "broker_count", "controller_id", "cluster_id"},
 		nil,
 	)
+	// Supported api versions
+	e.apiVersion = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "api_version"),
+		"The min and max version the Kafka cluster supports for the given Kafka API",
+		[]string{"api", "min_version", "max_version"},
+		nil,
+	)
+	// Topic and partition count
+	e.topicCount = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topics_total"),
+		"The number of topics in the cluster, after applying the topic filters",
+		[]string{},
+		nil,
+	)
+	e.partitionCount = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "partitions_total"),
+		"The number of partitions across all topics in the cluster, after applying the topic filters",
+		[]string{},
+		nil,
+	)
+	e.preferredLeaderImbalance = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "preferred_leader_imbalance_total"),
+		"The number of partitions whose leader is not the preferred replica, after applying the topic filters",
+		[]string{},
+		nil,
+	)
+	// Broker configs
+	e.brokerConfig = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "broker_config"),
+		"Broker config values of the allowed broker configs. The value is exposed as label.",
+		e.brokerLabels("broker_id", "config_name", "value"),
+		nil,
+	)
+	// Oldest open transaction
+	e.oldestOpenTransactionAge = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "oldest_open_transaction_age_seconds"),
+		"The age in seconds of the oldest transaction that has been started, but neither committed nor aborted "+
+			"yet. Reports 0 if there is no open transaction.",
+		[]string{},
+		nil,
+	)
 	// Broker Info
 	e.brokerInfo = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "broker_info"),
@@ -80,6 +313,13 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"broker_id", "address", "port", "rack_id", "is_controller"},
 		nil,
 	)
+	// Broker is controller
+	e.brokerIsController = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "broker_is_controller"),
+		"Reports 1 if the broker is the cluster's controller, otherwise 0",
+		e.brokerLabels("broker_id"),
+		nil,
+	)
 
 	// LogDir sizes
 	e.brokerLogDirSize = prometheus.NewDesc(
@@ -94,6 +334,13 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"topic_name"},
 		nil,
 	)
+	e.topicBytesInRate = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_bytes_in_rate"),
+		"The estimated number of bytes per second a topic grows, derived from the change of its log dir size between "+
+			"scrapes. This includes replicated bytes. Intervals in which retention deleted data are reported as 0.",
+		[]string{"topic_name"},
+		nil,
+	)
 
 	// Topic / Partition metrics
 	// Topic info
@@ -103,6 +350,36 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"topic_name", "partition_count", "replication_factor", "cleanup_policy"},
 		nil,
 	)
+	// Topic ISR risk
+	e.topicISRRisk = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_isr_risk"),
+		"Reports 1 if the topic's min.insync.replicas is greater than or equal to its replication factor, so that "+
+			"acks=all producers can't tolerate the loss of a single broker, otherwise 0",
+		[]string{"topic_name"},
+		nil,
+	)
+	// Topic replication factor below the configured minimum
+	e.topicReplicationFactorBelowMin = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_replication_factor_below_min"),
+		"Reports 1 if the topic's replication factor is lower than the configured minimum replication factor, otherwise 0",
+		[]string{"topic_name"},
+		nil,
+	)
+	// Topic partition count violating the configured partition count policy
+	e.topicPartitionCountPolicyViolation = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_count_policy_violation"),
+		"Reports 1 if the topic's partition count violates the configured partition count policy, otherwise 0",
+		[]string{"topic_name"},
+		nil,
+	)
+	// Topic age
+	e.topicAge = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_age_seconds"),
+		"The age of a topic in seconds, derived from the timestamp of its oldest message. Only reported for "+
+			"non-compacted topics from which no data has been removed yet.",
+		[]string{"topic_name"},
+		nil,
+	)
 	// Partition Low Water Mark
 	e.partitionLowWaterMark = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_low_water_mark"),
@@ -132,6 +409,43 @@ func (e *Exporter) InitializeMetrics() {
 		nil,
 	)
 
+	// Partition in sync replicas
+	e.partitionInSyncReplicas = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_in_sync_replicas"),
+		"The number of replicas that are currently in sync with the partition leader",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+	// Partition ISR changes
+	e.partitionISRChanges = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_isr_changes_total"),
+		"The number of times the set of in sync replicas of the partition has shrunk or expanded since KMinion "+
+			"tracks the partition",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+	// Partition replicas
+	e.partitionReplicas = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_replicas"),
+		"The number of replicas that are assigned to the partition",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+	// Partition leader is preferred replica
+	e.partitionLeaderIsPreferred = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_leader_is_preferred"),
+		"Reports 1 if the current leader of the partition is its preferred (first) replica, otherwise 0",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+	// Partition leader epoch
+	e.partitionLeaderEpoch = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "topic_partition_leader_epoch"),
+		"The epoch of the partition's current leader. It's incremented on every leader election.",
+		[]string{"topic_name", "partition_id"},
+		nil,
+	)
+
 	// Consumer Group Metrics
 	// Group Info
 	e.consumerGroupInfo = prometheus.NewDesc(
@@ -140,6 +454,29 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"group_id", "member_count", "protocol", "protocol_type", "state"},
 		nil,
 	)
+	// Consumer Group protocol
+	e.consumerGroupProtocol = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_protocol"),
+		"Reports 1 for the protocol the consumer group has chosen, which is the partition assignor (e.g. range or "+
+			"cooperative-sticky) for regular consumer groups. Groups without members are not reported.",
+		[]string{"group_id", "protocol"},
+		nil,
+	)
+	// Consumer Group distinct clients
+	e.consumerGroupDistinctClients = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_distinct_clients"),
+		"The number of distinct client ids among the members of the consumer group",
+		[]string{"group_id"},
+		nil,
+	)
+	// Consumer Group generation
+	e.consumerGroupGeneration = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_generation"),
+		"The generation id of the consumer group, which is incremented with every rebalance. Only reported if "+
+			"consumer groups are scraped from the __consumer_offsets topic.",
+		[]string{"group_id"},
+		nil,
+	)
 	// Topic / Partition Offset Sum (useful for calculating the consumed messages / sec on a topic)
 	e.consumerGroupTopicOffsetSum = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_offset_sum"),
@@ -154,6 +491,14 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"group_id", "topic_name", "partition_id"},
 		nil,
 	)
+	// Partition Lag for assigned partitions without committed offsets
+	e.consumerGroupTopicPartitionUncommittedLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_partition_uncommitted_lag"),
+		"The number of messages in a partition that is assigned to a consumer group member, but on which the group "+
+			"has not committed any offset yet",
+		[]string{"group_id", "topic_name", "partition_id"},
+		nil,
+	)
 	// Topic Lag (sum of all partition lags)
 	e.consumerGroupTopicLag = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag"),
@@ -161,6 +506,115 @@ func (e *Exporter) InitializeMetrics() {
 		[]string{"group_id", "topic_name"},
 		nil,
 	)
+	// Compact lag of topics and partitions (compat mode)
+	e.consumerGroupTopicLagCompact = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag_compact"),
+		"The number of messages a consumer group is lagging behind across all partitions in a topic (key: "+
+			"group/topic). Replaces the topic lag in compat mode.",
+		[]string{"key"},
+		nil,
+	)
+	e.consumerGroupTopicPartitionLagCompact = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_partition_lag_compact"),
+		"The number of messages a consumer group is lagging behind on a partition (key: group/topic/partition). "+
+			"Replaces the partition lag in compat mode.",
+		[]string{"key"},
+		nil,
+	)
+	// Max Lag across the partitions of a topic
+	e.consumerGroupTopicMaxLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag_max"),
+		"The highest number of messages a consumer group is lagging behind on a single partition of a topic",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Time lags
+	e.consumerGroupTopicPartitionTimeLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_partition_lag_seconds"),
+		"The estimated number of seconds since the latest offset of a partition has passed the committed offset of a consumer group",
+		[]string{"group_id", "topic_name", "partition_id"},
+		nil,
+	)
+	e.consumerGroupTopicTimeLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag_seconds"),
+		"The highest estimated number of seconds a consumer group is lagging behind on a single partition of a topic",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Consume rate
+	e.consumerGroupTopicConsumeRate = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_consume_rate"),
+		"The number of messages per second a consumer group has committed on a topic since the previous scrape. Offset resets result in a rate of 0.",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Number of lagging partitions
+	e.consumerGroupLaggingPartitions = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_lagging_partitions"),
+		"The number of partitions on which a consumer group's lag is above the configured lagging partition threshold",
+		[]string{"group_id"},
+		nil,
+	)
+	// Estimated lag in bytes
+	e.consumerGroupTopicLagBytes = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag_bytes"),
+		"The estimated number of bytes a consumer group is lagging behind across all partitions in a topic, based "+
+			"on the average record size of each partition",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Stale groups without members
+	e.consumerGroupStale = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_stale"),
+		"Reports 1 if the consumer group has no members, but committed offsets that are older than the configured "+
+			"stale group age, otherwise 0",
+		[]string{"group_id"},
+		nil,
+	)
+	// Lag by the client id and host of the members the partitions are assigned to
+	e.consumerGroupTopicMemberLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_member_lag"),
+		"The number of messages a consumer group is lagging behind on the partitions of a topic that are assigned "+
+			"to members with the given client id and host",
+		[]string{"group_id", "topic_name", "client_id", "client_host"},
+		nil,
+	)
+	// Smoothed lags
+	e.consumerGroupTopicPartitionSmoothedLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_partition_lag_smoothed"),
+		"The average partition lag of a consumer group across the most recent scrapes",
+		[]string{"group_id", "topic_name", "partition_id"},
+		nil,
+	)
+	e.consumerGroupTopicSmoothedLag = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_lag_smoothed"),
+		"The average topic lag of a consumer group across the most recent scrapes",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Estimated time until the topic lag has been consumed
+	e.consumerGroupTopicEstimatedDrainSeconds = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_topic_estimated_drain_seconds"),
+		"The estimated number of seconds until a consumer group has consumed its lag on a topic, based on the "+
+			"group's consumption rate since the previous scrape. +Inf if the group is lagging but not making progress.",
+		[]string{"group_id", "topic_name"},
+		nil,
+	)
+	// Offset expiries by group id
+	e.consumerGroupOffsetsExpired = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_offsets_expired"),
+		"The number of times the committed offsets of a still existing consumer group have been expired by Kafka",
+		[]string{"group"},
+		nil,
+	)
+	// Offset resets by group id, topic and partition
+	e.consumerGroupOffsetResets = prometheus.NewDesc(
+		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_offset_resets_total"),
+		"The number of times the committed group offset of a partition has been lower than in the previous scrape, "+
+			"e.g. because the offsets have been reset to the earliest offset",
+		[]string{"group_id", "topic_name", "partition_id"},
+		nil,
+	)
 	// Offset commits by group id
 	e.offsetCommits = prometheus.NewDesc(
 		prometheus.BuildFQName(e.cfg.Namespace, "kafka", "consumer_group_offset_commits_total"),
@@ -182,21 +636,76 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+	e.collect(ch, e.collectors())
+}
+
+// collect runs the given collectors and sends their metrics along with the exporter metrics to ch
+func (e *Exporter) collect(ch chan<- prometheus.Metric, collectors []namedCollector) {
+	ch, finishStaleSeries := e.staleSeries.wrap(ch)
+	defer finishStaleSeries()
+	ch, finishFilter := e.filterMetricSet(ch)
+	defer finishFilter()
+	ch, finishCompactLags := e.compactLags(ch)
+	defer finishCompactLags()
+	ch, finishNormalizer := e.topicLabelNormalizer.wrap(ch)
+	defer finishNormalizer()
+
+	// The scrape itself has no deadline, each collector is bounded by the collector timeout instead. As collectors
+	// run concurrently, this is also the maximum duration of the scrape.
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	scrapeStart := time.Now()
 
 	// Attach a unique id which will be used for caching (and and it's invalidation) of the kafka requests
 	uuid := uuid2.New()
 	ctx = context.WithValue(ctx, "requestId", uuid.String())
 
-	ok := e.collectClusterInfo(ctx, ch)
-	ok = e.collectExporterMetrics(ctx, ch) && ok
-	ok = e.collectBrokerInfo(ctx, ch) && ok
-	ok = e.collectLogDirs(ctx, ch) && ok
-	ok = e.collectConsumerGroups(ctx, ch) && ok
-	ok = e.collectTopicPartitionOffsets(ctx, ch) && ok
-	ok = e.collectConsumerGroupLags(ctx, ch) && ok
-	ok = e.collectTopicInfo(ctx, ch) && ok
+	// Collectors are independent of each other and run concurrently, so that the scrape takes as long as the slowest
+	// collector. Shared Kafka requests are deduplicated by the minion service's request cache. If a series limit is
+	// configured, the series of high cardinality collectors are buffered and limited in the order of the collectors
+	// once all of them have finished.
+	limiter := newSeriesLimiter(e.cfg.MaxSeries)
+	limitedOutputs := make([][]prometheus.Metric, len(collectors))
+	wg := sync.WaitGroup{}
+	okMutex := sync.Mutex{}
+	ok := true
+	for i, c := range collectors {
+		wg.Add(1)
+		go func(i int, name string, collect collectFunc) {
+			defer wg.Done()
+			collectorCtx, cancel := context.WithTimeout(ctx, e.cfg.CollectorTimeout)
+			defer cancel()
+
+			collectorOk, limitedOutput := e.runCollector(collectorCtx, ch, limiter, name, collect)
+			limitedOutputs[i] = limitedOutput
+			e.observeCollectorRun(name, collectorOk)
+			okMutex.Lock()
+			ok = ok && collectorOk
+			okMutex.Unlock()
+		}(i, c.name, c.collect)
+	}
+	wg.Wait()
+
+	for i, c := range collectors {
+		if limitedOutputs[i] == nil {
+			continue
+		}
+		kept, dropped := limiter.limit(limitedOutputs[i])
+		for _, metric := range kept {
+			ch <- metric
+		}
+		if dropped > 0 {
+			e.logger.Warn("series limit has been exceeded, dropped series of collector",
+				zap.String("collector", c.name),
+				zap.Int("max_series", e.cfg.MaxSeries),
+				zap.Int("dropped_series", dropped))
+			e.seriesLimitExceeded.WithLabelValues(c.name).Inc()
+		}
+	}
+
+	e.seriesLimitExceeded.Collect(ch)
+	e.collectScrapeTiming(ch, scrapeStart)
+	e.collectInternalState(ch, limiter)
 
 	if ok {
 		ch <- prometheus.MustNewConstMetric(e.exporterUp, prometheus.GaugeValue, 1.0)
@@ -204,3 +713,248 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(e.exporterUp, prometheus.GaugeValue, 0.0)
 	}
 }
+
+type namedCollector struct {
+	name    string
+	collect collectFunc
+}
+
+// collectors returns all collectors in the order in which their series are limited
+func (e *Exporter) collectors() []namedCollector {
+	return []namedCollector{
+		{"clusterInfo", e.collectClusterInfo},
+		{"apiVersions", e.collectAPIVersions},
+		{"exporterMetrics", e.collectExporterMetrics},
+		{"ingestDelay", e.collectIngestDelay},
+		{"roundtrip", e.collectRoundtrip},
+		{"transactions", e.collectTransactions},
+		{"brokerInfo", e.collectBrokerInfo},
+		{"brokerConfigs", e.collectBrokerConfigs},
+		{"logDirs", e.collectLogDirs},
+		{"consumerGroups", e.collectConsumerGroups},
+		{"topicPartitionOffsets", e.collectTopicPartitionOffsets},
+		{"consumerGroupLags", e.collectConsumerGroupLags},
+		{"topicInfo", e.collectTopicInfo},
+		{"topicAge", e.collectTopicAge},
+		{"topicPartitionInfo", e.collectTopicPartitionInfo},
+	}
+}
+
+type collectorRunCount struct {
+	Successes int
+	Failures  int
+}
+
+func (e *Exporter) observeCollectorRun(name string, ok bool) {
+	e.collectorRunsMutex.Lock()
+	defer e.collectorRunsMutex.Unlock()
+
+	count := e.collectorRuns[name]
+	if ok {
+		count.Successes++
+	} else {
+		count.Failures++
+	}
+	e.collectorRuns[name] = count
+}
+
+// IsWarmedUp returns whether every collector has completed the configured number of warmup scrapes, so that all
+// metrics are populated. A collector is warmed up once it has succeeded in that many runs. Collectors that keep
+// failing, e.g. because kminion runs in a degraded mode without the required permissions, are warmed up once they
+// have failed in that many runs, so that they don't hold back readiness forever.
+func (e *Exporter) IsWarmedUp() bool {
+	return e.isWarmedUp(e.collectors())
+}
+
+func (e *Exporter) isWarmedUp(collectors []namedCollector) bool {
+	e.collectorRunsMutex.Lock()
+	defer e.collectorRunsMutex.Unlock()
+
+	for _, c := range collectors {
+		count := e.collectorRuns[c.name]
+		if count.Successes < e.cfg.WarmupScrapes && count.Failures < e.cfg.WarmupScrapes {
+			return false
+		}
+	}
+	return true
+}
+
+// WarmUp runs scrapes whose metrics are discarded until the exporter is warmed up or the context is cancelled. In
+// pull mode collectors only run when KMinion is scraped, which may not happen before /ready reports KMinion as ready.
+// Every scrape counts towards the warmup of every collector, hence this runs at most 2*WarmupScrapes-1 scrapes.
+func (e *Exporter) WarmUp(ctx context.Context) {
+	e.warmUp(ctx, e.collectors())
+}
+
+func (e *Exporter) warmUp(ctx context.Context, collectors []namedCollector) {
+	for !e.isWarmedUp(collectors) {
+		if ctx.Err() != nil {
+			return
+		}
+		ch := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range ch {
+			}
+		}()
+		e.collect(ch, collectors)
+		close(ch)
+		<-done
+	}
+	e.logger.Info("warmup scrapes have been completed")
+}
+
+// collectScrapeTiming reports when this scrape has been started. In push mode it additionally reports how much time
+// has passed between the two most recent pushes.
+func (e *Exporter) collectScrapeTiming(ch chan<- prometheus.Metric, scrapeStart time.Time) {
+	ch <- prometheus.MustNewConstMetric(
+		e.lastScrapeTimestamp,
+		prometheus.GaugeValue,
+		float64(scrapeStart.UnixNano())/1e9,
+	)
+	if e.cfg.Mode != ExporterModePush {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		e.collectionInterval,
+		prometheus.GaugeValue,
+		e.minionSvc.Cfg.CollectionInterval.Seconds(),
+	)
+	if gap, hasGap := e.pushTiming.lastGap(); hasGap {
+		ch <- prometheus.MustNewConstMetric(
+			e.actualCollectionGap,
+			prometheus.GaugeValue,
+			gap.Seconds(),
+		)
+	}
+}
+
+// collectInternalState reports the number of exported series and the size of internal caches, so that cardinality
+// creep and growing caches can be spotted.
+func (e *Exporter) collectInternalState(ch chan<- prometheus.Metric, limiter *seriesLimiter) {
+	ch <- prometheus.MustNewConstMetric(
+		e.trackedSeries,
+		prometheus.GaugeValue,
+		float64(limiter.emittedSeries()),
+	)
+
+	cacheEntries := e.minionSvc.GetCacheEntryCounts()
+	cacheEntries["consumer_group_history"] = e.groupHistory.entries()
+	cacheEntries["topic_history"] = e.topicHistory.entries()
+	cacheEntries["collector_results"] = e.collectorCache.entries()
+	for cache, entries := range cacheEntries {
+		ch <- prometheus.MustNewConstMetric(
+			e.internalCacheEntries,
+			prometheus.GaugeValue,
+			float64(entries),
+			cache,
+		)
+	}
+}
+
+// brokerLabels returns the given label names of a broker metric along with the rack_id label if the broker rack
+// shall be included.
+func (e *Exporter) brokerLabels(labels ...string) []string {
+	if e.cfg.IncludeBrokerRack {
+		labels = append(labels, "rack_id")
+	}
+	return labels
+}
+
+// brokerLabelValues returns the given label values of a broker metric along with the broker's rack if the broker rack
+// shall be included.
+func (e *Exporter) brokerLabelValues(rack *string, values ...string) []string {
+	if e.cfg.IncludeBrokerRack {
+		rackID := ""
+		if rack != nil {
+			rackID = *rack
+		}
+		values = append(values, rackID)
+	}
+	return values
+}
+
+// limitedCollectors are the collectors whose series count towards the series limit. All other collectors only export
+// low cardinality cluster, broker and exporter metrics, which are always exported.
+var limitedCollectors = map[string]struct{}{
+	"logDirs":               {},
+	"consumerGroups":        {},
+	"topicPartitionOffsets": {},
+	"consumerGroupLags":     {},
+	"topicInfo":             {},
+	"topicAge":              {},
+	"topicPartitionInfo":    {},
+}
+
+// collectorInterval returns the configured interval of the given collector. 0 means the collector runs on every scrape.
+func (e *Exporter) collectorInterval(name string) time.Duration {
+	switch name {
+	case "logDirs":
+		return e.minionSvc.Cfg.LogDirs.Interval
+	case "consumerGroups", "consumerGroupLags":
+		return e.minionSvc.Cfg.ConsumerGroups.Interval
+	default:
+		return 0
+	}
+}
+
+// runCollector executes a single collector and reports its success via the collector up metric. If a series limit is
+// configured, the series of limited collectors are not sent to ch, but returned so that the limit can be applied to
+// them in a fixed order. Otherwise all series are sent to ch right away.
+func (e *Exporter) runCollector(ctx context.Context, ch chan<- prometheus.Metric, limiter *seriesLimiter, name string, collect collectFunc) (bool, []prometheus.Metric) {
+	interval := e.collectorInterval(name)
+	_, isLimited := limitedCollectors[name]
+	isLimited = isLimited && limiter.isEnabled()
+	if interval <= 0 && !isLimited {
+		collectorCh, finish := limiter.forward(ch)
+		ok := collect(ctx, collectorCh)
+		finish()
+		e.reportCollectorUp(ch, name, ok)
+		return ok, nil
+	}
+
+	// Collectors with their own interval may replay the metrics of a previous run. These are remembered before the
+	// series limit is applied, so that replayed metrics are the collector's full output.
+	ok, metrics := e.collectorCache.collect(ctx, name, interval, collect)
+	var limitedOutput []prometheus.Metric
+	if isLimited {
+		limitedOutput = metrics
+	} else {
+		for _, metric := range metrics {
+			ch <- metric
+		}
+		limiter.count(len(metrics))
+	}
+	e.reportCollectorUp(ch, name, ok)
+
+	return ok, limitedOutput
+}
+
+// reportCollectorUp reports whether the given collector has succeeded
+func (e *Exporter) reportCollectorUp(ch chan<- prometheus.Metric, name string, ok bool) {
+	up := 0.0
+	if ok {
+		up = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(e.collectorUp, prometheus.GaugeValue, up, name)
+}
+
+// bufferCollect runs the collector and returns all metrics it has sent. The returned slice is never nil.
+func bufferCollect(ctx context.Context, collect collectFunc) (bool, []prometheus.Metric) {
+	bufferCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	metrics := make([]prometheus.Metric, 0)
+	go func() {
+		defer close(done)
+		for metric := range bufferCh {
+			metrics = append(metrics, metric)
+		}
+	}()
+	ok := collect(ctx, bufferCh)
+	close(bufferCh)
+	<-done
+
+	return ok, metrics
+}
@@ -0,0 +1,246 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/cloudhut/kminion/v2/minion"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// newTestExporter creates an exporter with the given config whose minion service is not connected to any cluster
+func newTestExporter(t *testing.T, cfg Config) *Exporter {
+	minionCfg := minion.Config{}
+	minionCfg.SetDefaults()
+	minionSvc, err := minion.NewService(minionCfg, zap.NewNop(), nil, "kminion", prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("failed to create minion service: %v", err)
+	}
+	exporter, err := NewExporter(cfg, zap.NewNop(), minionSvc)
+	if err != nil {
+		t.Fatalf("failed to create exporter: %v", err)
+	}
+	exporter.InitializeMetrics()
+	return exporter
+}
+
+// collectMetrics runs a scrape with the given collectors and returns all metrics that have been sent
+func collectMetrics(e *Exporter, collectors []namedCollector) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	metrics := make([]prometheus.Metric, 0)
+	go func() {
+		defer close(done)
+		for metric := range ch {
+			metrics = append(metrics, metric)
+		}
+	}()
+	e.collect(ch, collectors)
+	close(ch)
+	<-done
+	return metrics
+}
+
+// gaugeValues returns the values of all metrics with the given desc, indexed by the value of the given label
+func gaugeValues(t *testing.T, metrics []prometheus.Metric, desc *prometheus.Desc, labelName string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, metric := range metrics {
+		if metric.Desc() != desc {
+			continue
+		}
+		out := &dto.Metric{}
+		if err := metric.Write(out); err != nil {
+			t.Fatalf("failed to write metric: %v", err)
+		}
+		labelValue := ""
+		for _, label := range out.Label {
+			if label.GetName() == labelName {
+				labelValue = label.GetValue()
+			}
+		}
+		values[labelValue] = out.GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestCollectReportsCollectorUp(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+
+	succeeding := func(_ context.Context, _ chan<- prometheus.Metric) bool { return true }
+	failing := func(_ context.Context, _ chan<- prometheus.Metric) bool { return false }
+	metrics := collectMetrics(exporter, []namedCollector{
+		{"clusterInfo", succeeding},
+		{"logDirs", failing},
+		{"topicInfo", succeeding},
+	})
+
+	// A failing collector only affects its own collector up metric, but the whole scrape is reported as failed
+	collectorUp := gaugeValues(t, metrics, exporter.collectorUp, "collector")
+	expected := map[string]float64{"clusterInfo": 1, "logDirs": 0, "topicInfo": 1}
+	for name, up := range expected {
+		if collectorUp[name] != up {
+			t.Errorf("expected collector up of %v for %v, got %v", up, name, collectorUp[name])
+		}
+	}
+	if len(collectorUp) != len(expected) {
+		t.Errorf("expected %d collector up series, got %d", len(expected), len(collectorUp))
+	}
+	if exporterUp := gaugeValues(t, metrics, exporter.exporterUp, ""); exporterUp[""] != 0 {
+		t.Errorf("expected exporter up of 0, got %v", exporterUp[""])
+	}
+}
+
+// sleepingCollector returns a collector that takes the given duration unless its context is done before
+func sleepingCollector(duration time.Duration) collectFunc {
+	return func(ctx context.Context, _ chan<- prometheus.Metric) bool {
+		select {
+		case <-time.After(duration):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func TestCollectTakesAsLongAsSlowestCollector(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+
+	start := time.Now()
+	metrics := collectMetrics(exporter, []namedCollector{
+		{"clusterInfo", sleepingCollector(100 * time.Millisecond)},
+		{"logDirs", sleepingCollector(300 * time.Millisecond)},
+		{"topicInfo", sleepingCollector(200 * time.Millisecond)},
+	})
+	elapsed := time.Since(start)
+
+	// Collectors run concurrently, so the scrape must take far less than the sum of all collector durations
+	if elapsed < 300*time.Millisecond || elapsed >= 550*time.Millisecond {
+		t.Errorf("expected the scrape to take about as long as the slowest collector (300ms), took %v", elapsed)
+	}
+	if exporterUp := gaugeValues(t, metrics, exporter.exporterUp, ""); exporterUp[""] != 1 {
+		t.Errorf("expected exporter up of 1, got %v", exporterUp[""])
+	}
+}
+
+func TestCollectCancelsCollectorsAfterTimeout(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.CollectorTimeout = 50 * time.Millisecond
+	exporter := newTestExporter(t, cfg)
+
+	start := time.Now()
+	metrics := collectMetrics(exporter, []namedCollector{
+		{"clusterInfo", sleepingCollector(0)},
+		{"logDirs", sleepingCollector(time.Hour)},
+	})
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("expected the slow collector to be cancelled after the collector timeout, the scrape took %v", elapsed)
+	}
+	collectorUp := gaugeValues(t, metrics, exporter.collectorUp, "collector")
+	if collectorUp["clusterInfo"] != 1 || collectorUp["logDirs"] != 0 {
+		t.Errorf("expected only the timed out collector to be down, got %v", collectorUp)
+	}
+}
+
+func TestCollectSkipsCollectorsWithinTheirInterval(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+	exporter.minionSvc.Cfg.LogDirs.Interval = 200 * time.Millisecond
+
+	sizeDesc := prometheus.NewDesc("kminion_kafka_broker_log_dir_size_total_bytes", "size", []string{"broker_id"}, nil)
+	logDirRuns, clusterInfoRuns := 0, 0
+	logDirs := func(_ context.Context, ch chan<- prometheus.Metric) bool {
+		logDirRuns++
+		ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(logDirRuns), "0")
+		return true
+	}
+	clusterInfo := func(_ context.Context, _ chan<- prometheus.Metric) bool {
+		clusterInfoRuns++
+		return true
+	}
+	scrape := func() []prometheus.Metric {
+		return collectMetrics(exporter, []namedCollector{{"clusterInfo", clusterInfo}, {"logDirs", logDirs}})
+	}
+
+	// Within the interval the log dirs collector is skipped, but its series of the previous run are exported again
+	for i := 1; i <= 3; i++ {
+		metrics := scrape()
+		if sizes := gaugeValues(t, metrics, sizeDesc, "broker_id"); len(sizes) != 1 || sizes["0"] != 1 {
+			t.Fatalf("expected the series of the first run to be exported in scrape %d, got %v", i, sizes)
+		}
+		if collectorUp := gaugeValues(t, metrics, exporter.collectorUp, "collector"); collectorUp["logDirs"] != 1 {
+			t.Fatalf("expected the skipped collector to be reported as up in scrape %d, got %v", i, collectorUp)
+		}
+	}
+	if logDirRuns != 1 || clusterInfoRuns != 3 {
+		t.Fatalf("expected the log dirs collector to run once and cluster info on every scrape, they ran %d and %d "+
+			"times", logDirRuns, clusterInfoRuns)
+	}
+
+	// Once the interval has passed the collector runs again
+	time.Sleep(250 * time.Millisecond)
+	if sizes := gaugeValues(t, scrape(), sizeDesc, "broker_id"); logDirRuns != 2 || sizes["0"] != 2 {
+		t.Fatalf("expected the log dirs collector to run again after its interval, it ran %d times and exported %v",
+			logDirRuns, sizes)
+	}
+}
+
+func TestIsWarmedUpAfterConfiguredNumberOfSuccessfulScrapes(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.WarmupScrapes = 3
+	exporter := newTestExporter(t, cfg)
+
+	clusterInfoOk := false
+	collectors := []namedCollector{
+		{"clusterInfo", func(_ context.Context, _ chan<- prometheus.Metric) bool { return clusterInfoOk }},
+		{"topicInfo", func(_ context.Context, _ chan<- prometheus.Metric) bool { return true }},
+	}
+	if exporter.isWarmedUp(collectors) {
+		t.Fatal("expected the exporter not to be warmed up before the first scrape")
+	}
+
+	// The cluster info collector fails in the first scrape and succeeds afterwards
+	collectMetrics(exporter, collectors)
+	clusterInfoOk = true
+	for i := 1; i <= cfg.WarmupScrapes; i++ {
+		if exporter.isWarmedUp(collectors) {
+			t.Fatalf("expected the exporter not to be warmed up after %d successful cluster info scrapes", i-1)
+		}
+		collectMetrics(exporter, collectors)
+	}
+	if !exporter.isWarmedUp(collectors) {
+		t.Fatalf("expected the exporter to be warmed up after %d successful scrapes", cfg.WarmupScrapes)
+	}
+}
+
+func TestFailingCollectorDoesNotBlockWarmup(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.WarmupScrapes = 2
+	exporter := newTestExporter(t, cfg)
+
+	// Without the required permissions the log dirs collector fails on every scrape
+	scrapes := 0
+	collectors := []namedCollector{
+		{"clusterInfo", func(_ context.Context, _ chan<- prometheus.Metric) bool { scrapes++; return true }},
+		{"logDirs", func(_ context.Context, _ chan<- prometheus.Metric) bool { return false }},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	exporter.warmUp(ctx, collectors)
+
+	if !exporter.isWarmedUp(collectors) {
+		t.Fatal("expected the exporter to be warmed up although a collector keeps failing")
+	}
+	if scrapes != cfg.WarmupScrapes {
+		t.Fatalf("expected %d warmup scrapes, got %d", cfg.WarmupScrapes, scrapes)
+	}
+}
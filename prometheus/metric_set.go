@@ -0,0 +1,54 @@
+package prometheus
+
+import (
+	"github.com/cloudhut/kminion/v2/minion"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	MetricSetFull    string = "full"
+	MetricSetMinimal string = "minimal"
+)
+
+// filterMetricSet returns a channel which only forwards the metrics of the configured metric set to ch. The returned
+// function must be called once all metrics have been sent, it waits until all metrics have been forwarded. For the
+// full metric set ch itself is returned.
+func (e *Exporter) filterMetricSet(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	if e.cfg.MetricSet != MetricSetMinimal {
+		return ch, func() {}
+	}
+
+	// The minimal metric set consists of the consumer group lags in the primary lag unit and the cluster health only
+	minimalDescs := map[*prometheus.Desc]struct{}{
+		e.exporterUp:                            {},
+		e.clusterInfo:                           {},
+		e.consumerGroupTopicLag:                 {},
+		e.consumerGroupTopicPartitionLag:        {},
+		e.consumerGroupTopicLagCompact:          {},
+		e.consumerGroupTopicPartitionLagCompact: {},
+	}
+	if e.minionSvc.Cfg.ConsumerGroups.PrimaryLagUnit == minion.ConsumerGroupLagUnitTime {
+		minimalDescs = map[*prometheus.Desc]struct{}{
+			e.exporterUp:                         {},
+			e.clusterInfo:                        {},
+			e.consumerGroupTopicTimeLag:          {},
+			e.consumerGroupTopicPartitionTimeLag: {},
+		}
+	}
+
+	filteredCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range filteredCh {
+			if _, isIncluded := minimalDescs[metric.Desc()]; isIncluded {
+				ch <- metric
+			}
+		}
+	}()
+
+	return filteredCh, func() {
+		close(filteredCh)
+		<-done
+	}
+}
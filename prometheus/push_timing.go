@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"sync"
+	"time"
+)
+
+// pushTiming tracks when the two most recent collections of the pusher have been started, so that the spacing between
+// them can be exported. Scrapes are not tracked, as the spacing between the scrapes of independent Prometheus servers
+// is meaningless.
+type pushTiming struct {
+	mutex     sync.Mutex
+	lastStart time.Time
+	gap       time.Duration
+}
+
+// observe records the start of a push
+func (t *pushTiming) observe(start time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.lastStart.IsZero() {
+		t.gap = start.Sub(t.lastStart)
+	}
+	t.lastStart = start
+}
+
+// lastGap returns the duration between the starts of the two most recent pushes. False is returned until the second
+// push has been started.
+func (t *pushTiming) lastGap() (time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.gap, t.gap > 0
+}
@@ -0,0 +1,42 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+	"time"
+)
+
+// StartPushing collects the metrics of the given gatherer and pushes them to the configured Pushgateway on each
+// collection interval, until the context is cancelled. Each push replaces all metrics of the previous push with the
+// same grouping key.
+func (e *Exporter) StartPushing(ctx context.Context, gatherer prometheus.Gatherer) {
+	cfg := e.cfg.Push
+	interval := e.minionSvc.Cfg.CollectionInterval
+	pusher := push.New(cfg.URL, cfg.Job).Gatherer(gatherer)
+	for name, value := range cfg.GroupingKey {
+		pusher = pusher.Grouping(name, value)
+	}
+
+	e.logger.Info("pushing metrics to pushgateway",
+		zap.String("url", cfg.URL),
+		zap.String("job", cfg.Job),
+		zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.pushTiming.observe(time.Now())
+		err := pusher.Push()
+		if err != nil {
+			e.logger.Error("failed to push metrics to pushgateway", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
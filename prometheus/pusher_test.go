@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/cloudhut/kminion/v2/minion"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartPushing(t *testing.T) {
+	pushedPaths := make(chan string, 10)
+	pushgateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushedPaths <- r.Method + " " + r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer pushgateway.Close()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "kminion_exporter_up"}))
+	exporter := &Exporter{
+		cfg: Config{Push: PushConfig{
+			URL:         pushgateway.URL,
+			Job:         "batch-kminion",
+			GroupingKey: map[string]string{"cluster": "batch-1"},
+		}},
+		logger:     zap.NewNop(),
+		minionSvc:  &minion.Service{Cfg: minion.Config{CollectionInterval: 10 * time.Millisecond}},
+		pushTiming: &pushTiming{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exporter.StartPushing(ctx, registry)
+	}()
+
+	// The first push happens right away, the second one after the collection interval
+	for i := 0; i < 2; i++ {
+		select {
+		case path := <-pushedPaths:
+			if path != "PUT /metrics/job/batch-kminion/cluster/batch-1" {
+				t.Fatalf("unexpected push request %q", path)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pushgateway has not received push %d", i+1)
+		}
+	}
+
+	cancel()
+	<-done
+
+	if gap, hasGap := exporter.pushTiming.lastGap(); !hasGap || gap <= 0 {
+		t.Fatalf("expected the gap between both pushes to be tracked, got %v", gap)
+	}
+}
+
+func TestPushTimingDelayedCollection(t *testing.T) {
+	timing := &pushTiming{}
+	start := time.Unix(1600000000, 0)
+
+	timing.observe(start)
+	if _, hasGap := timing.lastGap(); hasGap {
+		t.Fatalf("expected no gap after the first push")
+	}
+
+	// The second collection starts 45s after the first one, although the interval is 30s
+	timing.observe(start.Add(45 * time.Second))
+	if gap, hasGap := timing.lastGap(); !hasGap || gap != 45*time.Second {
+		t.Fatalf("expected a gap of 45s, got %v", gap)
+	}
+}
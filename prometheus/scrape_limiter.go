@@ -0,0 +1,45 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+)
+
+const (
+	ScrapeLimitModeWait   string = "wait"
+	ScrapeLimitModeReject string = "reject"
+)
+
+// LimitConcurrentScrapes wraps the metrics handler so that at most maxConcurrentScrapes scrapes are served at the
+// same time. Depending on the mode, excess scrapes either wait for a free slot or are rejected with a 503 status
+// code and a Retry-After header. Scrapes being served are reported in the given inFlight gauge.
+func LimitConcurrentScrapes(next http.Handler, maxConcurrentScrapes int, mode string, inFlight prometheus.Gauge) http.Handler {
+	if maxConcurrentScrapes <= 0 {
+		return next
+	}
+
+	semaphore := make(chan struct{}, maxConcurrentScrapes)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mode == ScrapeLimitModeReject {
+			select {
+			case semaphore <- struct{}{}:
+			default:
+				w.Header().Set("Retry-After", "5")
+				http.Error(w, "too many concurrent scrapes", http.StatusServiceUnavailable)
+				return
+			}
+		} else {
+			select {
+			case semaphore <- struct{}{}:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		defer func() { <-semaphore }()
+
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		next.ServeHTTP(w, r)
+	})
+}
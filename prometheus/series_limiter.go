@@ -0,0 +1,133 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// seriesLimiter counts and limits the number of series that are emitted during a single scrape. Only the series of high
+// cardinality collectors (topic, partition and consumer group metrics) are limited. They are buffered until all
+// collectors have finished and the limit is applied to them in a fixed order, so that the same series are kept on
+// every scrape. It is safe for concurrent use.
+type seriesLimiter struct {
+	maxSeries int
+
+	mutex   sync.Mutex
+	emitted int
+	// limitedEmitted is the number of emitted series which count towards the limit
+	limitedEmitted int
+}
+
+func newSeriesLimiter(maxSeries int) *seriesLimiter {
+	return &seriesLimiter{maxSeries: maxSeries}
+}
+
+// isEnabled returns whether a series limit is configured. Without a limit, series don't need to be buffered.
+func (l *seriesLimiter) isEnabled() bool {
+	return l.maxSeries > 0
+}
+
+// forward returns a channel for a collector whose series are not limited. All metrics are forwarded to ch right away
+// and counted as emitted series. The returned function must be called once the collector has finished.
+func (l *seriesLimiter) forward(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	collectorCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	forwarded := 0
+	go func() {
+		defer close(done)
+		for metric := range collectorCh {
+			forwarded++
+			ch <- metric
+		}
+	}()
+
+	return collectorCh, func() {
+		close(collectorCh)
+		<-done
+		l.count(forwarded)
+	}
+}
+
+// limit sorts the given series into a stable order and returns those that fit into the remaining limit, along with
+// the number of dropped series. The given slice is not modified, as it may be shared with the collector cache.
+func (l *seriesLimiter) limit(metrics []prometheus.Metric) ([]prometheus.Metric, int) {
+	metrics = append([]prometheus.Metric(nil), metrics...)
+	sortSeries(metrics)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	kept := metrics
+	if l.isEnabled() {
+		remaining := l.maxSeries - l.limitedEmitted
+		if remaining < 0 {
+			remaining = 0
+		}
+		if len(kept) > remaining {
+			kept = kept[:remaining]
+		}
+	}
+	l.limitedEmitted += len(kept)
+	l.emitted += len(kept)
+
+	return kept, len(metrics) - len(kept)
+}
+
+// count records series which have been sent without being limited
+func (l *seriesLimiter) count(series int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.emitted += series
+}
+
+// emittedSeries returns the number of series that have been emitted so far
+func (l *seriesLimiter) emittedSeries() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.emitted
+}
+
+// sortSeries sorts metrics by their metric name and label values
+func sortSeries(metrics []prometheus.Metric) {
+	keys := make([]string, len(metrics))
+	for i, metric := range metrics {
+		keys[i] = seriesKey(metric)
+	}
+	sort.Sort(seriesByKey{metrics: metrics, keys: keys})
+}
+
+// seriesKey returns a string which uniquely identifies the series of the given metric
+func seriesKey(metric prometheus.Metric) string {
+	var sb strings.Builder
+	sb.WriteString(metric.Desc().String())
+
+	out := &dto.Metric{}
+	if err := metric.Write(out); err != nil {
+		return sb.String()
+	}
+	// Label pairs are sorted by label name already
+	for _, label := range out.Label {
+		sb.WriteString("|")
+		sb.WriteString(label.GetName())
+		sb.WriteString("=")
+		sb.WriteString(label.GetValue())
+	}
+	return sb.String()
+}
+
+type seriesByKey struct {
+	metrics []prometheus.Metric
+	keys    []string
+}
+
+func (s seriesByKey) Len() int           { return len(s.metrics) }
+func (s seriesByKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s seriesByKey) Swap(i, j int) {
+	s.metrics[i], s.metrics[j] = s.metrics[j], s.metrics[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
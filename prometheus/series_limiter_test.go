@@ -0,0 +1,119 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestSeriesLimiterKeepsSameSeriesAcrossScrapes(t *testing.T) {
+	lagDesc := prometheus.NewDesc("kminion_kafka_consumer_group_topic_partition_lag", "lag",
+		[]string{"group_id", "topic_name", "partition_id"}, nil)
+	offsetDesc := prometheus.NewDesc("kminion_kafka_topic_partition_high_water_mark", "hwm",
+		[]string{"topic_name", "partition_id"}, nil)
+
+	newScrape := func(seed int64) []prometheus.Metric {
+		metrics := make([]prometheus.Metric, 0)
+		for partitionID := 0; partitionID < 10; partitionID++ {
+			metrics = append(metrics,
+				prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, float64(seed), "group", "topic",
+					strconv.Itoa(partitionID)),
+				prometheus.MustNewConstMetric(offsetDesc, prometheus.GaugeValue, float64(seed), "topic",
+					strconv.Itoa(partitionID)),
+			)
+		}
+		// Collectors iterate over maps, so the order differs from scrape to scrape
+		rand.New(rand.NewSource(seed)).Shuffle(len(metrics), func(i, j int) {
+			metrics[i], metrics[j] = metrics[j], metrics[i]
+		})
+		return metrics
+	}
+
+	keptSeries := func(seed int64) []string {
+		limiter := newSeriesLimiter(7)
+		// Exempt series must not take up any of the limit
+		limiter.count(3)
+		kept, dropped := limiter.limit(newScrape(seed))
+		if len(kept) != 7 || dropped != 13 {
+			t.Fatalf("expected 7 kept and 13 dropped series, got %d kept and %d dropped", len(kept), dropped)
+		}
+		if limiter.emittedSeries() != 10 {
+			t.Fatalf("expected 10 emitted series, got %d", limiter.emittedSeries())
+		}
+		keys := make([]string, len(kept))
+		for i, metric := range kept {
+			keys[i] = seriesKey(metric)
+		}
+		return keys
+	}
+
+	first := keptSeries(1)
+	second := keptSeries(2)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("kept series differ between scrapes at index %d: %q != %q", i, first[i], second[i])
+		}
+	}
+}
+
+func TestSeriesLimiterTripsAcrossCollectors(t *testing.T) {
+	desc := prometheus.NewDesc("kminion_kafka_topic_info", "info", []string{"topic_name"}, nil)
+	newSeries := func(count int) []prometheus.Metric {
+		metrics := make([]prometheus.Metric, count)
+		for i := range metrics {
+			metrics[i] = prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, strconv.Itoa(i))
+		}
+		return metrics
+	}
+
+	// The limit is applied to the collectors in order, so the first collector's series are kept entirely
+	limiter := newSeriesLimiter(5)
+	tests := []struct {
+		series  int
+		kept    int
+		dropped int
+	}{
+		{3, 3, 0},
+		{4, 2, 2},
+		{2, 0, 2},
+	}
+	for i, test := range tests {
+		kept, dropped := limiter.limit(newSeries(test.series))
+		if len(kept) != test.kept || dropped != test.dropped {
+			t.Errorf("collector %d: expected %d kept and %d dropped series, got %d kept and %d dropped",
+				i, test.kept, test.dropped, len(kept), dropped)
+		}
+	}
+}
+
+func TestSeriesLimiterUnlimited(t *testing.T) {
+	desc := prometheus.NewDesc("kminion_kafka_topic_info", "info", []string{"topic_name"}, nil)
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "b"),
+		prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "a"),
+	}
+
+	limiter := newSeriesLimiter(0)
+	if limiter.isEnabled() {
+		t.Fatalf("expected the limiter to be disabled without a limit")
+	}
+	kept, dropped := limiter.limit(metrics)
+	if len(kept) != 2 || dropped != 0 {
+		t.Fatalf("expected all series to be kept, got %d kept and %d dropped", len(kept), dropped)
+	}
+
+	// Series of collectors which are not buffered are streamed and still counted as emitted series
+	ch := make(chan prometheus.Metric, len(metrics))
+	collectorCh, finish := limiter.forward(ch)
+	for _, metric := range metrics {
+		collectorCh <- metric
+	}
+	finish()
+	if len(ch) != 2 {
+		t.Fatalf("expected 2 forwarded series, got %d", len(ch))
+	}
+	if limiter.emittedSeries() != 4 {
+		t.Fatalf("expected 4 emitted series, got %d", limiter.emittedSeries())
+	}
+}
@@ -0,0 +1,102 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"sync"
+)
+
+// staleSeriesTracker remembers the topic, partition and consumer group series of previous scrapes, so that series
+// which have disappeared are still exported with their last value for a configurable number of scrapes before they
+// are removed. It is safe for concurrent use, but scrapes are expected to finish in the order they have been started.
+type staleSeriesTracker struct {
+	gracePeriod int
+
+	mutex sync.Mutex
+	// scrape is the number of finished scrapes
+	scrape int
+	// series is indexed by the series key
+	series map[string]trackedSeries
+}
+
+type trackedSeries struct {
+	Metric prometheus.Metric
+	// LastSeen is the number of the scrape in which the series has been exported by a collector for the last time
+	LastSeen int
+}
+
+func newStaleSeriesTracker(gracePeriod int) *staleSeriesTracker {
+	return &staleSeriesTracker{
+		gracePeriod: gracePeriod,
+		series:      make(map[string]trackedSeries),
+	}
+}
+
+// wrap returns a channel that forwards all metrics to ch and remembers the tracked series. The returned func must be
+// called once all metrics of the scrape have been sent. It sends the series that have disappeared less than the grace
+// period ago and removes the older ones.
+func (t *staleSeriesTracker) wrap(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	if t.gracePeriod <= 0 {
+		return ch, func() {}
+	}
+
+	seen := make(map[string]prometheus.Metric)
+	trackingCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range trackingCh {
+			if isTrackedSeries(metric) {
+				seen[seriesKey(metric)] = metric
+			}
+			ch <- metric
+		}
+	}()
+
+	return trackingCh, func() {
+		close(trackingCh)
+		<-done
+		for _, metric := range t.finishScrape(seen) {
+			ch <- metric
+		}
+	}
+}
+
+// finishScrape stores the series seen in the finished scrape and returns the stale series that must still be exported
+func (t *staleSeriesTracker) finishScrape(seen map[string]prometheus.Metric) []prometheus.Metric {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.scrape++
+	for key, metric := range seen {
+		t.series[key] = trackedSeries{Metric: metric, LastSeen: t.scrape}
+	}
+
+	stale := make([]prometheus.Metric, 0)
+	for key, series := range t.series {
+		if series.LastSeen == t.scrape {
+			continue
+		}
+		if t.scrape-series.LastSeen > t.gracePeriod {
+			delete(t.series, key)
+			continue
+		}
+		stale = append(stale, series.Metric)
+	}
+	return stale
+}
+
+// isTrackedSeries returns true if the metric belongs to a topic, partition or consumer group
+func isTrackedSeries(metric prometheus.Metric) bool {
+	out := &dto.Metric{}
+	if err := metric.Write(out); err != nil {
+		return false
+	}
+	for _, label := range out.Label {
+		switch label.GetName() {
+		case "topic_name", "group_id", "group":
+			return true
+		}
+	}
+	return false
+}
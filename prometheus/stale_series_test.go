@@ -0,0 +1,71 @@
+package prometheus
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"testing"
+)
+
+func TestStaleSeriesOfDeletedTopicAreRemovedAfterGracePeriod(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	cfg.StaleSeriesGracePeriod = 2
+	exporter := newTestExporter(t, cfg)
+
+	sizeDesc := prometheus.NewDesc("kminion_kafka_topic_log_dir_size_total_bytes", "size", []string{"topic_name"}, nil)
+	topics := []string{"orders", "payments"}
+	topicInfo := func(_ context.Context, ch chan<- prometheus.Metric) bool {
+		for _, topicName := range topics {
+			ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, 42, topicName)
+		}
+		return true
+	}
+	scrape := func() map[string]float64 {
+		return gaugeValues(t, collectMetrics(exporter, []namedCollector{{"topicInfo", topicInfo}}), sizeDesc, "topic_name")
+	}
+
+	if sizes := scrape(); len(sizes) != 2 {
+		t.Fatalf("expected series of both topics, got %v", sizes)
+	}
+
+	// The payments topic has been deleted between the scrapes
+	topics = []string{"orders"}
+	for i := 1; i <= cfg.StaleSeriesGracePeriod; i++ {
+		sizes := scrape()
+		if len(sizes) != 2 || sizes["payments"] != 42 {
+			t.Fatalf("expected the deleted topic to be exported with its last value in scrape %d after the deletion, got %v", i, sizes)
+		}
+	}
+	sizes := scrape()
+	if _, exists := sizes["payments"]; exists || len(sizes) != 1 {
+		t.Fatalf("expected the deleted topic to be removed after the grace period, got %v", sizes)
+	}
+
+	// A topic that is re-created with the same name is exported again right away
+	topics = []string{"orders", "payments"}
+	if sizes := scrape(); len(sizes) != 2 {
+		t.Fatalf("expected series of both topics, got %v", sizes)
+	}
+}
+
+func TestStaleSeriesAreRemovedImmediatelyWithoutGracePeriod(t *testing.T) {
+	cfg := Config{}
+	cfg.SetDefaults()
+	exporter := newTestExporter(t, cfg)
+
+	sizeDesc := prometheus.NewDesc("kminion_kafka_topic_log_dir_size_total_bytes", "size", []string{"topic_name"}, nil)
+	topics := []string{"orders", "payments"}
+	topicInfo := func(_ context.Context, ch chan<- prometheus.Metric) bool {
+		for _, topicName := range topics {
+			ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, 42, topicName)
+		}
+		return true
+	}
+
+	collectMetrics(exporter, []namedCollector{{"topicInfo", topicInfo}})
+	topics = []string{"orders"}
+	metrics := collectMetrics(exporter, []namedCollector{{"topicInfo", topicInfo}})
+	if sizes := gaugeValues(t, metrics, sizeDesc, "topic_name"); len(sizes) != 1 || sizes["orders"] != 42 {
+		t.Fatalf("expected only the remaining topic to be exported, got %v", sizes)
+	}
+}
@@ -0,0 +1,307 @@
+package prometheus
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// topicHistory remembers topic sizes, in sync replicas and high water marks across scrapes, so that we can derive the
+// rate at which topics grow, how often the ISR of a partition changes and when a partition has reached a given offset.
+// It is safe for concurrent use.
+type topicHistory struct {
+	mutex sync.Mutex
+
+	// logDirSizes is indexed by topic name
+	logDirSizes map[string]logDirSizeSample
+
+	// isrSamples is indexed by topic name and partition id
+	isrSamples map[string]map[int32]isrSample
+
+	// highWaterMarks is indexed by topic name and partition id
+	highWaterMarks map[string]map[int32]*highWaterMarkHistory
+
+	// watermarkErrors is indexed by topic name and partition id
+	watermarkErrors map[string]map[int32]watermarkErrorCount
+}
+
+// watermarkErrorCount is the number of scrapes in which the water marks of a partition couldn't be fetched
+type watermarkErrorCount struct {
+	Errors   int
+	LastSeen time.Time
+}
+
+// highWaterMarkHistory contains the high water marks of a partition in ascending order. A sample is only added if
+// the high water mark has changed, so that each sample's timestamp is the first time the offset has been observed.
+type highWaterMarkHistory struct {
+	Samples  []highWaterMarkSample
+	LastSeen time.Time
+	// Observations is the number of scrapes in which the partition has been observed
+	Observations int
+}
+
+type highWaterMarkSample struct {
+	Offset    int64
+	Timestamp time.Time
+}
+
+type isrSample struct {
+	// ISR are the sorted broker ids of the in sync replicas
+	ISR       []int32
+	Timestamp time.Time
+
+	// Changes is the number of times the ISR has changed since the partition is tracked
+	Changes int
+}
+
+type logDirSizeSample struct {
+	Size      float64
+	Timestamp time.Time
+
+	// Rate is the number of bytes per second the topic has grown between the previous and this sample
+	Rate    float64
+	HasRate bool
+}
+
+func newTopicHistory() *topicHistory {
+	return &topicHistory{
+		logDirSizes:     make(map[string]logDirSizeSample),
+		isrSamples:      make(map[string]map[int32]isrSample),
+		highWaterMarks:  make(map[string]map[int32]*highWaterMarkHistory),
+		watermarkErrors: make(map[string]map[int32]watermarkErrorCount),
+	}
+}
+
+// observeLogDirSize stores the summed log dir size of a topic and returns the growth in bytes per second since the
+// previous sample. The returned bool is false as long as there is no previous sample. Sizes that decreased (e.g.
+// because retention deleted old segments) result in a rate of 0.
+func (h *topicHistory) observeLogDirSize(topicName string, size float64, now time.Time) (float64, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	previous, exists := h.logDirSizes[topicName]
+	if !exists {
+		h.logDirSizes[topicName] = logDirSizeSample{Size: size, Timestamp: now}
+		return 0, false
+	}
+
+	elapsed := now.Sub(previous.Timestamp)
+	if elapsed < minRateInterval {
+		return previous.Rate, previous.HasRate
+	}
+
+	rate := (size - previous.Size) / elapsed.Seconds()
+	if rate < 0 {
+		rate = 0
+	}
+	h.logDirSizes[topicName] = logDirSizeSample{Size: size, Timestamp: now, Rate: rate, HasRate: true}
+
+	return rate, true
+}
+
+// observeISR stores the in sync replicas of a partition and returns the number of times the set of in sync replicas
+// has changed (shrunk or expanded) since the partition is tracked.
+func (h *topicHistory) observeISR(topicName string, partitionID int32, isr []int32, now time.Time) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	sortedISR := make([]int32, len(isr))
+	copy(sortedISR, isr)
+	sort.Slice(sortedISR, func(i, j int) bool { return sortedISR[i] < sortedISR[j] })
+
+	if _, exists := h.isrSamples[topicName]; !exists {
+		h.isrSamples[topicName] = make(map[int32]isrSample)
+	}
+	previous, exists := h.isrSamples[topicName][partitionID]
+	changes := previous.Changes
+	if exists && !isEqualISR(previous.ISR, sortedISR) {
+		changes++
+	}
+	h.isrSamples[topicName][partitionID] = isrSample{ISR: sortedISR, Timestamp: now, Changes: changes}
+
+	return changes
+}
+
+// observeHighWaterMark stores the high water mark of a partition, if it has changed since the previous sample.
+func (h *topicHistory) observeHighWaterMark(topicName string, partitionID int32, offset int64, now time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, exists := h.highWaterMarks[topicName]; !exists {
+		h.highWaterMarks[topicName] = make(map[int32]*highWaterMarkHistory)
+	}
+	history, exists := h.highWaterMarks[topicName][partitionID]
+	if !exists {
+		history = &highWaterMarkHistory{}
+		h.highWaterMarks[topicName][partitionID] = history
+	}
+	history.LastSeen = now
+	history.Observations++
+
+	sampleCount := len(history.Samples)
+	if sampleCount > 0 && history.Samples[sampleCount-1].Offset >= offset {
+		if history.Samples[sampleCount-1].Offset > offset {
+			// The partition has been recreated or truncated, older samples are meaningless now
+			history.Samples = history.Samples[:0]
+		} else {
+			return
+		}
+	}
+	history.Samples = append(history.Samples, highWaterMarkSample{Offset: offset, Timestamp: now})
+}
+
+// estimateTimeLag returns the estimated number of seconds since the partition's high water mark has passed the given
+// committed offset. The time is interpolated between the two high water mark samples around the offset. If the
+// offset is older than all samples, it is extrapolated using the rate of the known samples, or the age of the oldest
+// sample is returned as lower bound if there is no rate. The returned bool is false if the partition is not tracked or
+// has only been observed once, as the first observation can't tell for how long the offset has been reached already.
+func (h *topicHistory) estimateTimeLag(topicName string, partitionID int32, offset int64, now time.Time) (float64, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	history, exists := h.highWaterMarks[topicName][partitionID]
+	if !exists || len(history.Samples) == 0 || history.Observations < 2 {
+		return 0, false
+	}
+	samples := history.Samples
+	newest := samples[len(samples)-1]
+	if offset >= newest.Offset {
+		// The group has caught up
+		return 0, true
+	}
+
+	// Find the first sample whose high water mark is beyond the committed offset
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Offset > offset })
+	var reachedAt time.Time
+	if i > 0 {
+		reachedAt = interpolateTimestamp(samples[i-1], samples[i], offset)
+	} else if len(samples) > 1 {
+		reachedAt = interpolateTimestamp(samples[0], newest, offset)
+	} else {
+		reachedAt = samples[0].Timestamp
+	}
+
+	lag := now.Sub(reachedAt).Seconds()
+	if lag < 0 {
+		lag = 0
+	}
+	return lag, true
+}
+
+// interpolateTimestamp returns the time at which the given offset has been reached, assuming a constant produce rate
+// between the two samples. Offsets before the first sample are extrapolated.
+func interpolateTimestamp(first highWaterMarkSample, second highWaterMarkSample, offset int64) time.Time {
+	offsetDelta := float64(second.Offset - first.Offset)
+	if offsetDelta <= 0 {
+		return first.Timestamp
+	}
+	elapsed := float64(second.Timestamp.Sub(first.Timestamp))
+	fraction := float64(offset-first.Offset) / offsetDelta
+
+	return first.Timestamp.Add(time.Duration(fraction * elapsed))
+}
+
+func isEqualISR(a []int32, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// evictStaleSamples removes all samples that haven't been updated within the history retention.
+func (h *topicHistory) evictStaleSamples(now time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for topicName, sample := range h.logDirSizes {
+		if now.Sub(sample.Timestamp) > historyRetention {
+			delete(h.logDirSizes, topicName)
+		}
+	}
+
+	for topicName, partitions := range h.isrSamples {
+		for partitionID, sample := range partitions {
+			if now.Sub(sample.Timestamp) > historyRetention {
+				delete(partitions, partitionID)
+			}
+		}
+		if len(partitions) == 0 {
+			delete(h.isrSamples, topicName)
+		}
+	}
+
+	for topicName, partitions := range h.highWaterMarks {
+		for partitionID, history := range partitions {
+			if now.Sub(history.LastSeen) > historyRetention {
+				delete(partitions, partitionID)
+				continue
+			}
+			// Keep the newest sample outside of the retention, as it's the time at which the partition has reached
+			// the next sample's offset at the latest
+			firstRetained := 0
+			for firstRetained < len(history.Samples)-1 && now.Sub(history.Samples[firstRetained+1].Timestamp) > historyRetention {
+				firstRetained++
+			}
+			history.Samples = history.Samples[firstRetained:]
+		}
+		if len(partitions) == 0 {
+			delete(h.highWaterMarks, topicName)
+		}
+	}
+
+	for topicName, partitions := range h.watermarkErrors {
+		for partitionID, count := range partitions {
+			if now.Sub(count.LastSeen) > historyRetention {
+				delete(partitions, partitionID)
+			}
+		}
+		if len(partitions) == 0 {
+			delete(h.watermarkErrors, topicName)
+		}
+	}
+}
+
+// observeWatermarkRequest records whether the water marks of a partition could be fetched and returns the number of
+// scrapes in which they couldn't be fetched since the partition is tracked.
+func (h *topicHistory) observeWatermarkRequest(topicName string, partitionID int32, failed bool, now time.Time) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	partitions, exists := h.watermarkErrors[topicName]
+	if !exists {
+		partitions = make(map[int32]watermarkErrorCount)
+		h.watermarkErrors[topicName] = partitions
+	}
+	count := partitions[partitionID]
+	if failed {
+		count.Errors++
+	}
+	count.LastSeen = now
+	partitions[partitionID] = count
+	return count.Errors
+}
+
+// entries returns the number of samples that are currently stored
+func (h *topicHistory) entries() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	count := len(h.logDirSizes)
+	for _, partitions := range h.isrSamples {
+		count += len(partitions)
+	}
+	for _, partitions := range h.highWaterMarks {
+		for _, history := range partitions {
+			count += len(history.Samples)
+		}
+	}
+	for _, partitions := range h.watermarkErrors {
+		count += len(partitions)
+	}
+	return count
+}
@@ -0,0 +1,39 @@
+package prometheus
+
+import (
+	"testing"
+)
+
+func TestEstimateTimeLag(t *testing.T) {
+	history := newTopicHistory()
+	if _, hasTimeLag := history.estimateTimeLag("topic", 0, 0, at(0)); hasTimeLag {
+		t.Fatalf("expected no time lag for an untracked partition")
+	}
+
+	// A single observation can't tell for how long the offset has been reached already
+	history.observeHighWaterMark("topic", 0, 100, at(0))
+	if _, hasTimeLag := history.estimateTimeLag("topic", 0, 50, at(0)); hasTimeLag {
+		t.Fatalf("expected no time lag after a single observation")
+	}
+
+	history.observeHighWaterMark("topic", 0, 200, at(10))
+	tests := []struct {
+		name    string
+		offset  int64
+		seconds float64
+		timeLag float64
+	}{
+		{"caught up", 200, 10, 0},
+		{"interpolated between samples", 150, 10, 5},
+		{"extrapolated before the oldest sample", 50, 10, 15},
+		{"increasing with time", 150, 20, 15},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			timeLag, hasTimeLag := history.estimateTimeLag("topic", 0, test.offset, at(test.seconds))
+			if !hasTimeLag || timeLag != test.timeLag {
+				t.Errorf("expected time lag %v, got %v (%v)", test.timeLag, timeLag, hasTimeLag)
+			}
+		})
+	}
+}
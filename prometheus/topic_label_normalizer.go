@@ -0,0 +1,170 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"regexp"
+	"sync"
+)
+
+// topicLabelName is the name of the label that contains topic names in all exported metrics
+const topicLabelName = "topic_name"
+
+type topicLabelNormalizeExpr struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// topicLabelNormalizer rewrites the topic_name label values of exported metrics according to the configured rules.
+// Only the label values are changed, all requests still use the actual topic names. Colliding topics are detected
+// per scrape and only the series of the lexically smallest topic name are exported. It is safe for concurrent use.
+type topicLabelNormalizer struct {
+	logger *zap.Logger
+	rules  []topicLabelNormalizeExpr
+
+	mutex sync.Mutex
+	// collisions contains all topic names whose series have been dropped in the most recent scrape, so that each
+	// collision is only logged once when it appears
+	collisions map[string]struct{}
+}
+
+func newTopicLabelNormalizer(rules []TopicLabelNormalizeRule, logger *zap.Logger) *topicLabelNormalizer {
+	exprs := make([]topicLabelNormalizeExpr, len(rules))
+	for i, rule := range rules {
+		// We can ignore the error because valid compilation has been validated already
+		pattern, _ := regexp.Compile(rule.Pattern)
+		exprs[i] = topicLabelNormalizeExpr{Pattern: pattern, Replacement: rule.Replacement}
+	}
+
+	return &topicLabelNormalizer{
+		logger:     logger,
+		rules:      exprs,
+		collisions: make(map[string]struct{}),
+	}
+}
+
+// normalize returns the label value for the given topic name
+func (n *topicLabelNormalizer) normalize(topicName string) string {
+	label := topicName
+	for _, rule := range n.rules {
+		label = rule.Pattern.ReplaceAllString(label, rule.Replacement)
+	}
+	return label
+}
+
+// wrap returns a channel which forwards all metrics to ch with normalized topic labels. The metrics are buffered
+// until the returned function is called, because colliding topics can only be resolved once all topics of the scrape
+// are known. The returned function must be called once all metrics have been sent, it forwards all metrics whose
+// topic has won its collision, if any. If no rules are configured ch itself is returned.
+func (n *topicLabelNormalizer) wrap(ch chan<- prometheus.Metric) (chan<- prometheus.Metric, func()) {
+	if len(n.rules) == 0 {
+		return ch, func() {}
+	}
+
+	bufferCh := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	var metrics []bufferedTopicMetric
+	// topicsByLabel is indexed by the normalized label value and contains the winning topic name of this scrape
+	topicsByLabel := make(map[string]string)
+	go func() {
+		defer close(done)
+		for metric := range bufferCh {
+			buffered := n.normalizeMetric(metric)
+			metrics = append(metrics, buffered)
+			if !buffered.hasTopic {
+				continue
+			}
+			winner, exists := topicsByLabel[buffered.label]
+			if !exists || buffered.topicName < winner {
+				topicsByLabel[buffered.label] = buffered.topicName
+			}
+		}
+	}()
+
+	return bufferCh, func() {
+		close(bufferCh)
+		<-done
+
+		collisions := make(map[string]struct{})
+		for _, buffered := range metrics {
+			if buffered.hasTopic && topicsByLabel[buffered.label] != buffered.topicName {
+				collisions[buffered.topicName] = struct{}{}
+				continue
+			}
+			ch <- buffered.metric
+		}
+		n.reportCollisions(collisions, topicsByLabel)
+	}
+}
+
+// reportCollisions logs all collisions which did not exist in the previous scrape yet
+func (n *topicLabelNormalizer) reportCollisions(collisions map[string]struct{}, topicsByLabel map[string]string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for topicName := range collisions {
+		if _, isKnown := n.collisions[topicName]; isKnown {
+			continue
+		}
+		label := n.normalize(topicName)
+		n.logger.Error("topic label normalization results in the same label value for two topics, "+
+			"dropping the series of the lexically greater topic",
+			zap.String("topic_name", topicName),
+			zap.String("colliding_topic_name", topicsByLabel[label]),
+			zap.String("normalized_topic_name", label))
+	}
+	n.collisions = collisions
+}
+
+// bufferedTopicMetric is a metric with its normalized topic label, along with its actual topic name
+type bufferedTopicMetric struct {
+	metric    prometheus.Metric
+	hasTopic  bool
+	topicName string
+	label     string
+}
+
+func (n *topicLabelNormalizer) normalizeMetric(metric prometheus.Metric) bufferedTopicMetric {
+	out := &dto.Metric{}
+	err := metric.Write(out)
+	if err != nil {
+		// The registry reports this error when it writes the metric itself
+		return bufferedTopicMetric{metric: metric}
+	}
+
+	for _, label := range out.Label {
+		if label.GetName() != topicLabelName {
+			continue
+		}
+		topicName := label.GetValue()
+		normalizedLabel := n.normalize(topicName)
+		return bufferedTopicMetric{
+			metric:    &normalizedTopicMetric{Metric: metric, topicLabel: normalizedLabel},
+			hasTopic:  true,
+			topicName: topicName,
+			label:     normalizedLabel,
+		}
+	}
+
+	return bufferedTopicMetric{metric: metric}
+}
+
+// normalizedTopicMetric is a metric whose topic_name label value is replaced when it's written
+type normalizedTopicMetric struct {
+	prometheus.Metric
+	topicLabel string
+}
+
+func (m *normalizedTopicMetric) Write(out *dto.Metric) error {
+	err := m.Metric.Write(out)
+	if err != nil {
+		return err
+	}
+	for _, label := range out.Label {
+		if label.GetName() == topicLabelName {
+			label.Value = &m.topicLabel
+		}
+	}
+	return nil
+}
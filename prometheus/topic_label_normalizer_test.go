@@ -0,0 +1,56 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+	"sort"
+	"testing"
+)
+
+func TestTopicLabelNormalizerCollisions(t *testing.T) {
+	normalizer := newTopicLabelNormalizer([]TopicLabelNormalizeRule{{Pattern: "-v[0-9]+$", Replacement: ""}}, zap.NewNop())
+	desc := prometheus.NewDesc("kminion_kafka_topic_info", "info", []string{"topic_name"}, nil)
+
+	// scrape sends the given topics through the normalizer and returns the topic labels of the exported series
+	scrape := func(topicNames ...string) []string {
+		bufferCh := make(chan prometheus.Metric, len(topicNames))
+		ch, finish := normalizer.wrap(bufferCh)
+		for _, topicName := range topicNames {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, topicName)
+		}
+		finish()
+		close(bufferCh)
+
+		exported := make([]string, 0)
+		for metric := range bufferCh {
+			out := &dto.Metric{}
+			if err := metric.Write(out); err != nil {
+				t.Fatal(err)
+			}
+			exported = append(exported, out.Label[0].GetValue())
+		}
+		sort.Strings(exported)
+		return exported
+	}
+
+	// The lexically smallest topic wins the collision, regardless of the order in which the series are sent
+	for _, topicNames := range [][]string{{"orders-v2", "orders-v1", "users"}, {"users", "orders-v1", "orders-v2"}} {
+		exported := scrape(topicNames...)
+		if len(exported) != 2 || exported[0] != "orders" || exported[1] != "users" {
+			t.Fatalf("expected series for orders and users, got %v", exported)
+		}
+		if _, isCollision := normalizer.collisions["orders-v2"]; !isCollision || len(normalizer.collisions) != 1 {
+			t.Fatalf("expected orders-v2 to be the only collision, got %v", normalizer.collisions)
+		}
+	}
+
+	// Once the winning topic is gone, the other topic is exported again
+	exported := scrape("orders-v2", "users")
+	if len(exported) != 2 || exported[0] != "orders" {
+		t.Fatalf("expected series for orders and users, got %v", exported)
+	}
+	if len(normalizer.collisions) != 0 {
+		t.Fatalf("expected no collisions, got %v", normalizer.collisions)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+// deferredRegisterer holds back the metrics that are registered while the services are set up, because the registerer
+// that adds the Kafka cluster id as label can only be created once the connection to Kafka has been established. All
+// collectors that are registered after registerWith has been called are passed through directly.
+type deferredRegisterer struct {
+	mutex      sync.Mutex
+	collectors []promclient.Collector
+	target     promclient.Registerer
+}
+
+func (r *deferredRegisterer) Register(c promclient.Collector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.target != nil {
+		return r.target.Register(c)
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+func (r *deferredRegisterer) MustRegister(cs ...promclient.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *deferredRegisterer) Unregister(c promclient.Collector) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.target != nil {
+		return r.target.Unregister(c)
+	}
+	for i, collector := range r.collectors {
+		if collector == c {
+			r.collectors = append(r.collectors[:i], r.collectors[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// registerWith registers all held back collectors with the given registerer
+func (r *deferredRegisterer) registerWith(target promclient.Registerer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, c := range r.collectors {
+		if err := target.Register(c); err != nil {
+			return fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+	r.collectors = nil
+	r.target = target
+	return nil
+}
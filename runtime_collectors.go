@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// unregisterRuntimeCollectors removes the Go runtime and process collectors from the given registerer, unless they
+// are enabled in the exporter config
+func unregisterRuntimeCollectors(registerer promclient.Registerer, cfg prometheus.Config) {
+	if !cfg.GoCollector {
+		registerer.Unregister(promclient.NewGoCollector())
+	}
+	if !cfg.ProcessCollector {
+		registerer.Unregister(promclient.NewProcessCollector(promclient.ProcessCollectorOpts{}))
+	}
+}
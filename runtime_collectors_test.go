@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/cloudhut/kminion/v2/prometheus"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"strings"
+	"testing"
+)
+
+func TestUnregisterRuntimeCollectors(t *testing.T) {
+	tests := []struct {
+		name             string
+		goCollector      bool
+		processCollector bool
+	}{
+		{"both enabled", true, true},
+		{"go collector disabled", false, true},
+		{"process collector disabled", true, false},
+		{"both disabled", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Same collectors as in the default registry
+			registry := promclient.NewRegistry()
+			registry.MustRegister(
+				promclient.NewGoCollector(),
+				promclient.NewProcessCollector(promclient.ProcessCollectorOpts{}),
+			)
+
+			unregisterRuntimeCollectors(registry, prometheus.Config{
+				GoCollector:      test.goCollector,
+				ProcessCollector: test.processCollector,
+			})
+
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("failed to gather metrics: %v", err)
+			}
+			hasGoMetrics, hasProcessMetrics := false, false
+			for _, family := range families {
+				hasGoMetrics = hasGoMetrics || strings.HasPrefix(family.GetName(), "go_")
+				hasProcessMetrics = hasProcessMetrics || strings.HasPrefix(family.GetName(), "process_")
+			}
+			if hasGoMetrics != test.goCollector {
+				t.Errorf("expected go metrics to be present: %v, got %v", test.goCollector, hasGoMetrics)
+			}
+			if hasProcessMetrics != test.processCollector {
+				t.Errorf("expected process metrics to be present: %v, got %v", test.processCollector, hasProcessMetrics)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"go.uber.org/zap"
+)
+
+// logStartupSummary logs a single structured summary of the effective configuration. It must never log any secrets,
+// hence only non-sensitive settings (e.g. the SASL mechanism, but not the credentials) are picked explicitly.
+func logStartupSummary(logger *zap.Logger, cfg Config, seedBrokerCount int) {
+	authMechanism := "none"
+	if cfg.Kafka.SASL.Enabled {
+		authMechanism = cfg.Kafka.SASL.Mechanism
+		if cfg.Kafka.SASL.DelegationToken.Enabled {
+			authMechanism += " (delegation token)"
+		}
+	}
+
+	collectors := []string{"clusterInfo", "apiVersions", "brokerInfo", "topicInfo", "topicPartitionOffsets"}
+	if cfg.Minion.ConsumerGroups.Enabled {
+		collectors = append(collectors, "consumerGroups", "consumerGroupLags")
+	}
+	if cfg.Minion.LogDirs.Enabled {
+		collectors = append(collectors, "logDirs")
+	}
+	if cfg.Minion.Topics.IncludeAge {
+		collectors = append(collectors, "topicAge")
+	}
+	if cfg.Minion.IngestDelay.Enabled {
+		collectors = append(collectors, "ingestDelay")
+	}
+	if cfg.Minion.Roundtrip.Enabled {
+		collectors = append(collectors, "roundtrip")
+	}
+	if cfg.Minion.Transactions.Enabled {
+		collectors = append(collectors, "transactions")
+	}
+
+	logger.Info("startup summary",
+		zap.Int("seed_broker_count", seedBrokerCount),
+		zap.String("broker_discovery_srv_record", cfg.Kafka.BrokerDiscovery.SRVRecord),
+		zap.String("auth_mechanism", authMechanism),
+		zap.Bool("tls_enabled", cfg.Kafka.TLS.Enabled),
+		zap.Bool("azure_event_hubs", cfg.Kafka.AzureEventHubs.Enabled),
+		zap.Bool("confluent_cloud", cfg.Kafka.ConfluentCloud.Enabled),
+		zap.Strings("enabled_collectors", collectors),
+		zap.String("consumer_group_scrape_mode", cfg.Minion.ConsumerGroups.ScrapeMode),
+		zap.String("consumer_group_granularity", cfg.Minion.ConsumerGroups.Granularity),
+		zap.String("topic_granularity", cfg.Minion.Topics.Granularity),
+		zap.Strings("allowed_groups", cfg.Minion.ConsumerGroups.AllowedGroupIDs),
+		zap.Strings("ignored_groups", cfg.Minion.ConsumerGroups.IgnoredGroupIDs),
+		zap.Strings("required_topics", cfg.Minion.ConsumerGroups.RequireTopics),
+		zap.Strings("included_group_states", cfg.Minion.ConsumerGroups.IncludeStates),
+		zap.Strings("allowed_topics", cfg.Minion.Topics.AllowedTopics),
+		zap.Strings("ignored_topics", cfg.Minion.Topics.IgnoredTopics),
+		zap.Int("topic_overrides", len(cfg.Minion.Topics.Overrides)),
+		zap.String("exporter_mode", cfg.Exporter.Mode),
+	)
+}